@@ -6,198 +6,316 @@ package dataframe
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"time"
 
 	"github.com/Paradigm4/gota/series"
 	"github.com/apache/arrow/go/arrow"
 	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
 	"github.com/apache/arrow/go/arrow/float16"
 	"github.com/apache/arrow/go/arrow/memory"
 )
 
 // Arrow Array Table to a DataFrame
-func TableToDataframe(tbl array.Table) DataFrame {
-	columns := make([]series.Series, tbl.NumCols())
+//
+// The columns read their values from the arrays of the table without copying
+// them (see series.FromArrow), so converting a table takes the same time
+// whatever its number of rows. The DataFrame retains the arrays, and Release
+// releases them. Setting elements, and operations working on whole columns,
+// copy the values of a column once.
+//
+// Arrow types without a matching Series type fall back to the closest one:
+// BINARY and FIXED_SIZE_BINARY columns give String columns, DECIMAL columns
+// give Float columns (see CheckDecimals), and NULL columns give String columns
+// of missing elements. DATE32, DATE64 and TIMESTAMP columns give Time columns.
+//
+// DICTIONARY and LARGE_STRING arrays are not supported by the Arrow version in
+// use, so Categorical columns can't be round-tripped as such: they are exported
+// as STRING fields and come back as String columns.
+func TableToDataframe(tbl array.Table, options ...LoadOption) DataFrame {
+	cfg := loadOptions{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	columns := make([]series.Series, 0, tbl.NumCols())
+	release := func() {
+		for _, col := range columns {
+			col.Release()
+		}
+	}
 	for i := 0; i < int(tbl.NumCols()); i++ {
-		col, err := arrayColumnToSeries(tbl.Column(i))
-		if err != nil {
-			return DataFrame{Err: err}
+		column := tbl.Column(i)
+		col := series.FromArrow(column.Name(), column.Data())
+		if col.Err != nil {
+			release()
+			return DataFrame{Err: col.Err}
+		}
+		columns = append(columns, col)
+		if cfg.checkDecimals && column.DataType().ID() == arrow.DECIMAL {
+			if err := checkDecimals(column, col); err != nil {
+				release()
+				return DataFrame{Err: err}
+			}
 		}
-		columns[i] = col
 	}
-	return New(columns...)
+	nrows, ncols, err := checkColumnsDimensions(columns...)
+	if err != nil {
+		release()
+		return DataFrame{Err: err}
+	}
+	df := DataFrame{
+		columns: columns,
+		ncols:   ncols,
+		nrows:   nrows,
+	}
+	colnames := df.Names()
+	fixColnames(colnames)
+	for i, colname := range colnames {
+		df.columns[i].Name = colname
+	}
+	return df
 }
 
-func arrayColumnToSeries(column *array.Column) (series.Series, error) {
+// Release releases the Arrow arrays backing the columns of a DataFrame returned
+// by TableToDataframe, or by the functions reading Arrow or Parquet data. It is
+// only needed with Arrow allocators other than the Go allocator, and the
+// DataFrame can't be used after that.
+func (df DataFrame) Release() {
+	for _, col := range df.columns {
+		col.Release()
+	}
+}
 
-	var s series.Series
-	switch column.DataType().ID() {
-	case arrow.BOOL:
-		s = series.New(nil, series.Bool, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewBooleanData(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.INT8:
-		s = series.New(nil, series.Int, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewInt8Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.INT16:
-		s = series.New(nil, series.Int, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewInt16Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.INT32:
-		s = series.New(nil, series.Int, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewInt32Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.INT64:
-		s = series.New(nil, series.Int, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewInt64Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.UINT8:
-		s = series.New(nil, series.Uint, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewUint8Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.UINT16:
-		s = series.New(nil, series.Uint, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewUint16Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Elem(i).Set(data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.UINT32:
-		s = series.New(nil, series.Uint, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewUint32Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.UINT64:
-		s = series.New(nil, series.Uint, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewUint64Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
-		}
-	case arrow.FLOAT16:
-		s = series.New(nil, series.Float, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewFloat16Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j).Float32())
+// CheckDecimals makes TableToDataframe fail on the values of DECIMAL columns
+// that don't survive the conversion to Float, that is whose nearest float64
+// doesn't round back to the same decimal. By default they are silently
+// rounded.
+func CheckDecimals(b bool) LoadOption {
+	return func(c *loadOptions) {
+		c.checkDecimals = b
+	}
+}
+
+// checkDecimals returns an error for the first decimal of column that its
+// Float value in s doesn't round back to
+func checkDecimals(column *array.Column, s series.Series) error {
+	dt := column.DataType().(*arrow.Decimal128Type)
+	digits := 0
+	if dt.Scale > 0 {
+		digits = int(dt.Scale)
+	}
+	i := 0
+	for _, c := range column.Data().Chunks() {
+		data := c.(*array.Decimal128)
+		for j := 0; j < data.Len(); j++ {
+			if data.IsValid(j) {
+				v, _ := s.Elem(i).Float()
+				if n, err := floatToDecimal(v, dt); err != nil || n != data.Value(j) {
+					return fmt.Errorf("decimal %s of column %q can't be represented as a Float",
+						series.DecimalRat(data.Value(j), dt.Scale).FloatString(digits), column.Name())
 				}
-				i++
 			}
+			i++
 		}
-	case arrow.FLOAT32:
-		s = series.New(nil, series.Float, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewFloat32Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
+	}
+	return nil
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// timeUnitDuration returns the number of nanoseconds in one Arrow time unit
+func timeUnitDuration(unit arrow.TimeUnit) int64 {
+	switch unit {
+	case arrow.Second:
+		return int64(time.Second)
+	case arrow.Millisecond:
+		return int64(time.Millisecond)
+	case arrow.Microsecond:
+		return int64(time.Microsecond)
+	default:
+		return int64(time.Nanosecond)
+	}
+}
+
+// unixDays returns the number of whole days since the epoch, rounding towards
+// the earlier day for times before the epoch
+func unixDays(t time.Time) int64 {
+	days := t.Unix() / secondsPerDay
+	if t.Unix() < 0 && t.Unix()%secondsPerDay != 0 {
+		days--
+	}
+	return days
+}
+
+// pow10 returns 10 to the power of n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// floatToDecimal returns the decimal of type dt nearest to v, rounding halves
+// away from zero. NaN, infinities and values with more digits than the
+// precision of dt give an error.
+func floatToDecimal(v float64, dt *arrow.Decimal128Type) (decimal128.Num, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return decimal128.Num{}, fmt.Errorf("can't convert %v to %v", v, dt)
+	}
+	r := new(big.Rat).SetFloat64(v)
+	if dt.Scale < 0 {
+		r.Quo(r, new(big.Rat).SetInt(pow10(-dt.Scale)))
+	} else {
+		r.Mul(r, new(big.Rat).SetInt(pow10(dt.Scale)))
+	}
+	n, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Lsh(m.Abs(m), 1).Cmp(r.Denom()) >= 0 {
+		n.Add(n, big.NewInt(int64(r.Sign())))
+	}
+	if n.CmpAbs(pow10(dt.Precision)) >= 0 {
+		return decimal128.Num{}, fmt.Errorf("%v overflows %v", v, dt)
+	}
+	if n.Sign() == 0 {
+		// decimal128.FromBigInt panics on zero
+		return decimal128.Num{}, nil
+	}
+	return decimal128.FromBigInt(n), nil
+}
+
+// DataframeSchema returns an Arrow schema with a nullable field for every
+// column, with the Arrow type matching its Series type: Int columns are
+// INT64, Uint UINT64, Float FLOAT64, String STRING, Bool BOOL and Time
+// nanosecond TIMESTAMP in UTC. Categorical columns are STRING, as DICTIONARY
+// arrays are not supported.
+//
+// The fields given in overrides replace the inferred field of the column of
+// the same name, to use other types or non-nullable fields.
+func DataframeSchema(df DataFrame, overrides ...arrow.Field) (*arrow.Schema, error) {
+	if df.Err != nil {
+		return nil, df.Err
+	}
+	fields := make([]arrow.Field, df.ncols)
+	for i, s := range df.columns {
+		var dt arrow.DataType
+		switch s.Type() {
+		case series.Bool:
+			dt = arrow.FixedWidthTypes.Boolean
+		case series.Int:
+			dt = arrow.PrimitiveTypes.Int64
+		case series.Uint:
+			dt = arrow.PrimitiveTypes.Uint64
+		case series.Float:
+			dt = arrow.PrimitiveTypes.Float64
+		case series.String, series.Categorical:
+			dt = arrow.BinaryTypes.String
+		case series.Time:
+			dt = &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}
 		}
-	case arrow.FLOAT64:
-		s = series.New(nil, series.Float, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewFloat64Data(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
+		fields[i] = arrow.Field{Name: s.Name, Type: dt, Nullable: true}
+	}
+	for _, f := range overrides {
+		i := findInStringSlice(f.Name, df.Names())
+		if i < 0 {
+			return nil, fmt.Errorf("[DataframeSchema] can't find column name: %s", f.Name)
 		}
-	case arrow.STRING:
-		s = series.New(nil, series.String, column.Name(), column.Len())
-		i := 0
-		for _, c := range column.Data().Chunks() {
-			data := array.NewStringData(c.Data())
-			for j := 0; j < data.Len(); j++ {
-				if data.IsValid(j) && !data.IsNull(j) {
-					s.Set(i, data.Value(j))
-				}
-				i++
-			}
+		fields[i] = f
+	}
+	for _, f := range fields {
+		if f.Type == nil {
+			return nil, fmt.Errorf("[DataframeSchema] no Arrow type for column %q of type %v", f.Name, df.Col(f.Name).Type())
 		}
-	default:
-		return series.Series{}, fmt.Errorf("unsupported Arrow Type: %v", column.DataType())
 	}
-	return s, nil
+	return arrow.NewSchema(fields, nil), nil
 }
 
 // Convert a DataFrame to an Arrow Table
 // Order of columns (by name) is given by colNames. If empty, this uses the existing order of columns in the DataFrame
-/*
+// The schema is given by DataframeSchema, and the caller is responsible for Releasing the table
+// See DataframeToTableWithSchema to use other Arrow types
 func DataframeToTable(df DataFrame, colNames ...string) (array.Table, error) {
-*/
+	if len(colNames) > 0 {
+		df = df.Select(colNames)
+	}
+	if df.Err != nil {
+		return nil, fmt.Errorf("[DataframeToTable] %v", df.Err)
+	}
+	schema, err := DataframeSchema(df)
+	if err != nil {
+		return nil, fmt.Errorf("[DataframeToTable] %v", err)
+	}
+	tbl, err := dataframeToTable(df, schema)
+	if err != nil {
+		return nil, fmt.Errorf("[DataframeToTable] %v", err)
+	}
+	return tbl, nil
+}
+
+// Convert a DataFrame to an Arrow Table with the given schema, as returned by DataframeSchema with overrides
+// Only the columns named in the schema are converted, in its order
+// The caller is responsible for Releasing the table
+func DataframeToTableWithSchema(df DataFrame, schema *arrow.Schema) (array.Table, error) {
+	if df.Err != nil {
+		return nil, fmt.Errorf("[DataframeToTableWithSchema] %v", df.Err)
+	}
+	tbl, err := dataframeToTable(df, schema)
+	if err != nil {
+		return nil, fmt.Errorf("[DataframeToTableWithSchema] %v", err)
+	}
+	return tbl, nil
+}
+
+func dataframeToTable(df DataFrame, schema *arrow.Schema) (array.Table, error) {
+	recs, err := dataframeToRecords(df, schema, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	return array.NewTableFromRecords(schema, recs), nil
+}
+
+// Convert a DataFrame to Arrow records of batchRows rows (the last one may be smaller), or a single record if batchRows is 0
+// The schema is given by DataframeSchema with the given overrides, and the caller is responsible for Releasing the records
+// An empty DataFrame gives no records
+func DataframeToRecords(df DataFrame, batchRows int, overrides ...arrow.Field) ([]array.Record, error) {
+	if df.Err != nil {
+		return nil, fmt.Errorf("[DataframeToRecords] %v", df.Err)
+	}
+	if batchRows < 0 {
+		return nil, fmt.Errorf("[DataframeToRecords] negative batch size %d", batchRows)
+	}
+	schema, err := DataframeSchema(df, overrides...)
+	if err != nil {
+		return nil, fmt.Errorf("[DataframeToRecords] %v", err)
+	}
+	recs, err := dataframeToRecords(df, schema, batchRows)
+	if err != nil {
+		return nil, fmt.Errorf("[DataframeToRecords] %v", err)
+	}
+	return recs, nil
+}
+
+// recordCollector keeps the records written to it, see writeRecords
+type recordCollector []array.Record
+
+func (c *recordCollector) Write(rec array.Record) error {
+	rec.Retain()
+	*c = append(*c, rec)
+	return nil
+}
+
+func dataframeToRecords(df DataFrame, schema *arrow.Schema, batchRows int) ([]array.Record, error) {
+	var recs recordCollector
+	if err := df.writeRecords(&recs, schema, batchRows); err != nil {
+		for _, rec := range recs {
+			rec.Release()
+		}
+		return nil, err
+	}
+	return recs, nil
+}
 
 // Caller is responsible for Releasing the record
 // Arrays are arranged in the order given in the schema (fields)
@@ -389,6 +507,103 @@ func DataframeToRecordWithSchema(df DataFrame, schema *arrow.Schema, mem memory.
 					ab.(*array.StringBuilder).AppendNull()
 				}
 			}
+		case arrow.TIMESTAMP:
+			unit := timeUnitDuration(schema.Field(f).Type.(*arrow.TimestampType).Unit)
+			for i := 0; i < l; i++ {
+				e := s.Elem(i)
+				if e.IsValid() {
+					v, err := series.ElementTime(e)
+					if err != nil {
+						return nil, err
+					}
+					ab.(*array.TimestampBuilder).UnsafeAppend(arrow.Timestamp(v.UnixNano() / unit))
+				} else {
+					ab.(*array.TimestampBuilder).UnsafeAppendBoolToBitmap(false)
+				}
+			}
+		case arrow.DATE32:
+			for i := 0; i < l; i++ {
+				e := s.Elem(i)
+				if e.IsValid() {
+					v, err := series.ElementTime(e)
+					if err != nil {
+						return nil, err
+					}
+					ab.(*array.Date32Builder).UnsafeAppend(arrow.Date32(unixDays(v)))
+				} else {
+					ab.(*array.Date32Builder).UnsafeAppendBoolToBitmap(false)
+				}
+			}
+		case arrow.DATE64:
+			for i := 0; i < l; i++ {
+				e := s.Elem(i)
+				if e.IsValid() {
+					v, err := series.ElementTime(e)
+					if err != nil {
+						return nil, err
+					}
+					ab.(*array.Date64Builder).UnsafeAppend(arrow.Date64(unixDays(v) * secondsPerDay * 1000))
+				} else {
+					ab.(*array.Date64Builder).UnsafeAppendBoolToBitmap(false)
+				}
+			}
+		case arrow.BINARY:
+			for i := 0; i < l; i++ {
+				e := s.Elem(i)
+				if e.IsValid() {
+					v, err := e.String()
+					if err != nil {
+						return nil, err
+					}
+					ab.(*array.BinaryBuilder).Append([]byte(v))
+				} else {
+					ab.(*array.BinaryBuilder).AppendNull()
+				}
+			}
+		case arrow.FIXED_SIZE_BINARY:
+			width := schema.Field(f).Type.(*arrow.FixedSizeBinaryType).ByteWidth
+			for i := 0; i < l; i++ {
+				e := s.Elem(i)
+				if e.IsValid() {
+					v, err := e.String()
+					if err != nil {
+						return nil, err
+					}
+					if len(v) != width {
+						return nil, fmt.Errorf("[DataframeToRecordWithSchema] value %q of column %q is not %d bytes long", v, s.Name, width)
+					}
+					ab.(*array.FixedSizeBinaryBuilder).Append([]byte(v))
+				} else {
+					ab.(*array.FixedSizeBinaryBuilder).AppendNull()
+				}
+			}
+		case arrow.DECIMAL:
+			dt := schema.Field(f).Type.(*arrow.Decimal128Type)
+			for i := 0; i < l; i++ {
+				e := s.Elem(i)
+				if e.IsValid() {
+					v, err := e.Float()
+					if err != nil {
+						return nil, err
+					}
+					n, err := floatToDecimal(v, dt)
+					if err != nil {
+						return nil, fmt.Errorf("[DataframeToRecordWithSchema] column %q: %v", s.Name, err)
+					}
+					ab.(*array.Decimal128Builder).UnsafeAppend(n)
+				} else {
+					ab.(*array.Decimal128Builder).UnsafeAppendBoolToBitmap(false)
+				}
+			}
+		case arrow.NULL:
+			for i := 0; i < l; i++ {
+				if s.Elem(i).IsValid() {
+					return nil, fmt.Errorf("[DataframeToRecordWithSchema] column %q has a non-missing value for a NULL field", s.Name)
+				}
+				ab.(*array.NullBuilder).AppendNull()
+			}
+		default:
+			return nil, fmt.Errorf("[DataframeToRecordWithSchema] unsupported Arrow Type: %v", schema.Field(f).Type)
 		}
 	}
 
@@ -0,0 +1,194 @@
+// Paradigm4
+
+package dataframe
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+// WriteSchema sets the Arrow schema used by WriteArrowIPC, WriteArrowFile and
+// WriteParquet.
+// Only the columns named in the schema are written, in its order.
+func WriteSchema(schema *arrow.Schema) WriteOption {
+	return func(c *writeOptions) {
+		c.schema = schema
+	}
+}
+
+// WriteBatchRows sets the number of rows of each record batch written by
+// WriteArrowIPC and WriteArrowFile. By default all the rows go in a single
+// batch.
+func WriteBatchRows(n int) WriteOption {
+	return func(c *writeOptions) {
+		c.batchRows = n
+	}
+}
+
+// ReadArrowIPC reads a DataFrame from an Arrow IPC stream, binding all its
+// record batches by rows. The options are those of TableToDataframe.
+func ReadArrowIPC(r io.Reader, options ...LoadOption) DataFrame {
+	reader, err := ipc.NewReader(r)
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("[ReadArrowIPC] %v", err)}
+	}
+	defer reader.Release()
+
+	var recs []array.Record
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	for reader.Next() {
+		rec := reader.Record()
+		rec.Retain()
+		recs = append(recs, rec)
+	}
+	if err := reader.Err(); err != nil && err != io.EOF {
+		return DataFrame{Err: fmt.Errorf("[ReadArrowIPC] %v", err)}
+	}
+	return recordsToDataframe(reader.Schema(), recs, options...)
+}
+
+// ReadArrowFile reads a DataFrame from an Arrow IPC file, binding all its
+// record batches by rows. r must also be an io.Seeker, or have a Size method
+// as bytes.Reader and io.SectionReader do. The options are those of
+// TableToDataframe.
+func ReadArrowFile(r io.ReaderAt, options ...LoadOption) DataFrame {
+	rs, ok := r.(ipc.ReadAtSeeker)
+	if !ok {
+		sized, ok := r.(interface{ Size() int64 })
+		if !ok {
+			return DataFrame{Err: fmt.Errorf("[ReadArrowFile] can't find the size of a %T", r)}
+		}
+		rs = io.NewSectionReader(r, 0, sized.Size())
+	}
+	reader, err := ipc.NewFileReader(rs)
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("[ReadArrowFile] %v", err)}
+	}
+	defer reader.Close()
+
+	var recs []array.Record
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	for i := 0; i < reader.NumRecords(); i++ {
+		rec, err := reader.Record(i)
+		if err != nil {
+			return DataFrame{Err: fmt.Errorf("[ReadArrowFile] %v", err)}
+		}
+		rec.Retain()
+		recs = append(recs, rec)
+	}
+	return recordsToDataframe(reader.Schema(), recs, options...)
+}
+
+// recordsToDataframe binds the records into a table and converts it
+func recordsToDataframe(schema *arrow.Schema, recs []array.Record, options ...LoadOption) DataFrame {
+	tbl := array.NewTableFromRecords(schema, recs)
+	defer tbl.Release()
+	return TableToDataframe(tbl, options...)
+}
+
+// WriteArrowIPC writes the DataFrame to w as an Arrow IPC stream. The schema is
+// inferred from the column types unless given with WriteSchema, and the rows
+// are split into record batches of WriteBatchRows rows.
+func (df DataFrame) WriteArrowIPC(w io.Writer, options ...WriteOption) error {
+	schema, batchRows, err := df.arrowWriteOptions(options...)
+	if err != nil {
+		return fmt.Errorf("[WriteArrowIPC] %v", err)
+	}
+	writer := ipc.NewWriter(w, ipc.WithSchema(schema))
+	if err := df.writeRecords(writer, schema, batchRows); err != nil {
+		writer.Close()
+		return fmt.Errorf("[WriteArrowIPC] %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("[WriteArrowIPC] %v", err)
+	}
+	return nil
+}
+
+// WriteArrowFile writes the DataFrame to w as an Arrow IPC file, see
+// WriteArrowIPC
+func (df DataFrame) WriteArrowFile(w io.WriteSeeker, options ...WriteOption) error {
+	schema, batchRows, err := df.arrowWriteOptions(options...)
+	if err != nil {
+		return fmt.Errorf("[WriteArrowFile] %v", err)
+	}
+	writer, err := ipc.NewFileWriter(w, ipc.WithSchema(schema))
+	if err != nil {
+		return fmt.Errorf("[WriteArrowFile] %v", err)
+	}
+	if err := df.writeRecords(writer, schema, batchRows); err != nil {
+		writer.Close()
+		return fmt.Errorf("[WriteArrowFile] %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("[WriteArrowFile] %v", err)
+	}
+	return nil
+}
+
+func (df DataFrame) arrowWriteOptions(options ...WriteOption) (*arrow.Schema, int, error) {
+	if df.Err != nil {
+		return nil, 0, df.Err
+	}
+	cfg := writeOptions{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.batchRows < 0 {
+		return nil, 0, fmt.Errorf("negative batch size %d", cfg.batchRows)
+	}
+	if cfg.schema == nil {
+		schema, err := DataframeSchema(df)
+		if err != nil {
+			return nil, 0, err
+		}
+		cfg.schema = schema
+	}
+	return cfg.schema, cfg.batchRows, nil
+}
+
+// writeRecords writes the rows of the DataFrame as records of batchRows rows,
+// or a single one if batchRows is 0
+func (df DataFrame) writeRecords(w interface{ Write(array.Record) error }, schema *arrow.Schema, batchRows int) error {
+	mem := memory.NewGoAllocator()
+	if batchRows == 0 {
+		batchRows = df.nrows
+	}
+	for start := 0; start < df.nrows; start += batchRows {
+		batch := df
+		if batchRows < df.nrows {
+			end := start + batchRows
+			if end > df.nrows {
+				end = df.nrows
+			}
+			rows := make([]int, end-start)
+			for i := range rows {
+				rows[i] = start + i
+			}
+			batch = df.Subset(rows)
+		}
+		rec, err := DataframeToRecordWithSchema(batch, schema, mem)
+		if err != nil {
+			return err
+		}
+		err = w.Write(rec)
+		rec.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
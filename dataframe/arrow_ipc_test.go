@@ -0,0 +1,145 @@
+package dataframe
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Paradigm4/gota/series"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/ipc"
+)
+
+func TestArrow_IPC(t *testing.T) {
+	ts := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	df := New(
+		series.New([]interface{}{1, nil, 3, 4, 5}, series.Int, "int"),
+		series.New([]interface{}{uint(1), uint(2), nil, uint(4), uint(5)}, series.Uint, "uint"),
+		series.New([]interface{}{1.5, "NaN", 3.5, nil, 5.5}, series.Float, "float"),
+		series.New([]interface{}{"a", "b", nil, "d", "e"}, series.String, "string"),
+		series.New([]interface{}{true, false, nil, true, false}, series.Bool, "bool"),
+		series.New([]interface{}{ts, nil, ts.Add(time.Hour), ts, ts}, series.Time, "time"),
+	)
+	expected, _ := df.Records(true)
+
+	for _, batchRows := range []int{0, 2, 5, 10} {
+		var buf bytes.Buffer
+		if err := df.WriteArrowIPC(&buf, WriteBatchRows(batchRows)); err != nil {
+			t.Fatalf("Batch rows %d\nError:%v", batchRows, err)
+		}
+
+		// Count the record batches of the stream
+		reader, err := ipc.NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		batches := 0
+		for reader.Next() {
+			batches++
+		}
+		reader.Release()
+		expBatches := 1
+		if batchRows == 2 {
+			expBatches = 3
+		}
+		if batches != expBatches {
+			t.Errorf("Batch rows %d\nExpected %d batches, received %d", batchRows, expBatches, batches)
+		}
+
+		received := ReadArrowIPC(&buf)
+		if received.Err != nil {
+			t.Fatalf("Batch rows %d\nError:%v", batchRows, received.Err)
+		}
+		if !reflect.DeepEqual(df.Types(), received.Types()) {
+			t.Errorf("Batch rows %d\nDifferent types:\nA:%v\nB:%v", batchRows, df.Types(), received.Types())
+		}
+		rr, _ := received.Records(true)
+		if !reflect.DeepEqual(expected, rr) {
+			t.Errorf("Batch rows %d\nDifferent values:\nA:%v\nB:%v", batchRows, expected, rr)
+		}
+	}
+
+	// Files, read from an os.File and from a bytes.Reader
+	path := filepath.Join(t.TempDir(), "df.arrow")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := df.WriteArrowFile(f, WriteBatchRows(2)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, received := range []DataFrame{ReadArrowFile(f), ReadArrowFile(bytes.NewReader(content))} {
+		if received.Err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, received.Err)
+		}
+		if !reflect.DeepEqual(df.Types(), received.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, df.Types(), received.Types())
+		}
+		rr, _ := received.Records(true)
+		if !reflect.DeepEqual(expected, rr) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, expected, rr)
+		}
+	}
+
+	// A given schema selects and converts the columns
+	schema := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "string", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "int", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		},
+		nil,
+	)
+	var buf bytes.Buffer
+	if err := df.WriteArrowIPC(&buf, WriteSchema(schema)); err != nil {
+		t.Fatal(err)
+	}
+	received := ReadArrowIPC(&buf)
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	exp, _ := df.Select([]string{"string", "int"}).Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(exp, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", exp, rr)
+	}
+
+	// Empty DataFrames keep their columns
+	buf.Reset()
+	if err := df.Subset([]int{}).WriteArrowIPC(&buf); err != nil {
+		t.Fatal(err)
+	}
+	received = ReadArrowIPC(&buf)
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	if received.Nrow() != 0 || !reflect.DeepEqual(df.Names(), received.Names()) {
+		t.Errorf("Expected no rows and columns %v, received:\n%v", df.Names(), received)
+	}
+
+	// Errors
+	if received := ReadArrowIPC(bytes.NewReader([]byte("not arrow"))); received.Err == nil {
+		t.Errorf("Expected error, got:\n%v", received)
+	}
+	if received := ReadArrowFile(bytes.NewReader([]byte("not arrow"))); received.Err == nil {
+		t.Errorf("Expected error, got:\n%v", received)
+	}
+	if err := df.WriteArrowIPC(&buf, WriteBatchRows(-1)); err == nil {
+		t.Errorf("Expected error for a negative batch size")
+	}
+	if err := (DataFrame{Err: os.ErrInvalid}).WriteArrowIPC(&buf); err == nil {
+		t.Errorf("Expected error for a DataFrame with errors")
+	}
+}
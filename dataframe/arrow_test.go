@@ -0,0 +1,426 @@
+package dataframe
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Paradigm4/gota/series"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+func TestArrow_Time(t *testing.T) {
+	ts := []time.Time{
+		time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC),
+		time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	df := New(
+		series.New([]interface{}{ts[0], nil, ts[1]}, series.Time, "ts"),
+		series.New([]interface{}{ts[0], nil, ts[1]}, series.Time, "d32"),
+		series.New([]interface{}{ts[0], nil, ts[1]}, series.Time, "d64"),
+	)
+	schema := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "ts", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}, Nullable: true},
+			{Name: "d32", Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+			{Name: "d64", Type: arrow.FixedWidthTypes.Date64, Nullable: true},
+		},
+		nil,
+	)
+	rec, err := DataframeToRecordWithSchema(df, schema, memory.NewGoAllocator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Release()
+	tbl := array.NewTableFromRecords(schema, []array.Record{rec})
+	defer tbl.Release()
+
+	received := TableToDataframe(tbl)
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	expected := New(
+		series.New([]interface{}{ts[0], nil, ts[1]}, series.Time, "ts"),
+		series.New([]interface{}{"2021-06-01", nil, ts[1]}, series.Time, "d32"),
+		series.New([]interface{}{"2021-06-01", nil, ts[1]}, series.Time, "d64"),
+	)
+	if !reflect.DeepEqual(expected.Types(), received.Types()) {
+		t.Errorf("Different types:\nA:%v\nB:%v", expected.Types(), received.Types())
+	}
+	er, _ := expected.Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(er, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", er, rr)
+	}
+}
+
+func TestArrow_Categorical(t *testing.T) {
+	df := New(series.New([]string{"a", "b", "", "a"}, series.Categorical, "cat"))
+	schema := arrow.NewSchema([]arrow.Field{{Name: "cat", Type: arrow.BinaryTypes.String, Nullable: true}}, nil)
+	rec, err := DataframeToRecordWithSchema(df, schema, memory.NewGoAllocator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Release()
+	tbl := array.NewTableFromRecords(schema, []array.Record{rec})
+	defer tbl.Release()
+
+	received := TableToDataframe(tbl)
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	expected := New(series.New([]string{"a", "b", "", "a"}, series.String, "cat"))
+	if !reflect.DeepEqual(expected.Types(), received.Types()) {
+		t.Errorf("Different types:\nA:%v\nB:%v", expected.Types(), received.Types())
+	}
+	er, _ := expected.Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(er, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", er, rr)
+	}
+}
+
+func TestArrow_DataframeSchema(t *testing.T) {
+	df := New(
+		series.New([]int{1, 2}, series.Int, "int"),
+		series.New([]uint{1, 2}, series.Uint, "uint"),
+		series.New([]float64{1, 2}, series.Float, "float"),
+		series.New([]string{"a", "b"}, series.String, "string"),
+		series.New([]bool{true, false}, series.Bool, "bool"),
+	)
+	schema, err := DataframeSchema(df, arrow.Field{Name: "uint", Type: arrow.PrimitiveTypes.Uint8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "int", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+			{Name: "uint", Type: arrow.PrimitiveTypes.Uint8},
+			{Name: "float", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+			{Name: "string", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "bool", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+		},
+		nil,
+	)
+	if !schema.Equal(expected) {
+		t.Errorf("Different schemas:\nA:%v\nB:%v", expected, schema)
+	}
+	if _, err := DataframeSchema(df, arrow.Field{Name: "missing", Type: arrow.PrimitiveTypes.Uint8}); err == nil {
+		t.Errorf("Expected error for an unknown column")
+	}
+}
+
+func TestArrow_DataframeToTable(t *testing.T) {
+	df := New(
+		series.New([]interface{}{1, nil, 3}, series.Int, "int"),
+		series.New([]interface{}{"a", "b", nil}, series.String, "string"),
+		series.New([]interface{}{1.5, nil, "NaN"}, series.Float, "float"),
+	)
+	table := []struct {
+		colNames []string
+		exp      DataFrame
+	}{
+		{nil, df},
+		{[]string{"float", "int"}, df.Select([]string{"float", "int"})},
+	}
+	for i, tc := range table {
+		tbl, err := DataframeToTable(df, tc.colNames...)
+		if err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		received := TableToDataframe(tbl)
+		tbl.Release()
+		if received.Err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, received.Err)
+		}
+		if !reflect.DeepEqual(tc.exp.Types(), received.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.exp.Types(), received.Types())
+		}
+		er, _ := tc.exp.Records(true)
+		rr, _ := received.Records(true)
+		if !reflect.DeepEqual(er, rr) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, er, rr)
+		}
+	}
+	if _, err := DataframeToTable(df, "missing"); err == nil {
+		t.Errorf("Expected error for an unknown column")
+	}
+
+	// Other types through the schema, which selects the columns
+	schema, err := DataframeSchema(df.Select([]string{"float", "int"}), arrow.Field{Name: "int", Type: arrow.PrimitiveTypes.Int8, Nullable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl, err := DataframeToTableWithSchema(df, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Release()
+	if !tbl.Schema().Equal(schema) {
+		t.Errorf("Expected schema:\n%v\nReceived:\n%v", schema, tbl.Schema())
+	}
+	received := TableToDataframe(tbl)
+	er, _ := df.Select([]string{"float", "int"}).Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(er, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", er, rr)
+	}
+	schema = arrow.NewSchema([]arrow.Field{{Name: "string", Type: arrow.PrimitiveTypes.Int8}}, nil)
+	if _, err := DataframeToTableWithSchema(df, schema); err == nil || !strings.HasPrefix(err.Error(), "[DataframeToTableWithSchema]") {
+		t.Errorf("Expected error for strings in an INT8 field, received %v", err)
+	}
+}
+
+func TestArrow_DataframeToRecords(t *testing.T) {
+	df := New(
+		series.New([]interface{}{1, nil, 3, 4, 5}, series.Int, "int"),
+		series.New([]interface{}{"a", "b", nil, "d", "e"}, series.String, "string"),
+	)
+	expected, _ := df.Records(true)
+	for _, tc := range []struct {
+		batchRows int
+		lens      []int
+	}{
+		{0, []int{5}},
+		{2, []int{2, 2, 1}},
+		{5, []int{5}},
+		{10, []int{5}},
+	} {
+		recs, err := DataframeToRecords(df, tc.batchRows)
+		if err != nil {
+			t.Fatalf("Batch rows %d\nError:%v", tc.batchRows, err)
+		}
+		lens := make([]int, len(recs))
+		for i, rec := range recs {
+			lens[i] = int(rec.NumRows())
+		}
+		if !reflect.DeepEqual(tc.lens, lens) {
+			t.Errorf("Batch rows %d\nExpected records of %v rows, received %v", tc.batchRows, tc.lens, lens)
+		}
+		tbl := array.NewTableFromRecords(recs[0].Schema(), recs)
+		for _, rec := range recs {
+			rec.Release()
+		}
+		received := TableToDataframe(tbl)
+		tbl.Release()
+		rr, _ := received.Records(true)
+		if !reflect.DeepEqual(expected, rr) {
+			t.Errorf("Batch rows %d\nDifferent values:\nA:%v\nB:%v", tc.batchRows, expected, rr)
+		}
+	}
+	if recs, err := DataframeToRecords(df.Subset([]int{}), 2); err != nil || len(recs) != 0 {
+		t.Errorf("Expected no records, received %v (%v)", recs, err)
+	}
+	if _, err := DataframeToRecords(df, -1); err == nil {
+		t.Errorf("Expected error for a negative batch size")
+	}
+
+	recs, err := DataframeToRecords(df, 0, arrow.Field{Name: "int", Type: arrow.PrimitiveTypes.Uint16, Nullable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dt := recs[0].Schema().Field(0).Type; dt.ID() != arrow.UINT16 {
+		t.Errorf("Expected UINT16 field, received %v", dt)
+	}
+	recs[0].Release()
+	if _, err := DataframeToRecords(df, 0, arrow.Field{Name: "missing", Type: arrow.PrimitiveTypes.Uint16}); err == nil || !strings.HasPrefix(err.Error(), "[DataframeToRecords]") {
+		t.Errorf("Expected error for an unknown column, received %v", err)
+	}
+}
+
+func TestArrow_Types(t *testing.T) {
+	ts := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	table := []struct {
+		dt  arrow.DataType
+		in  series.Series
+		exp series.Series
+	}{
+		{
+			arrow.BinaryTypes.Binary,
+			series.New([]interface{}{"a", nil, "bc", "", "d"}, series.String, "x"),
+			series.New([]interface{}{"a", nil, "bc", "", "d"}, series.String, "x"),
+		},
+		{
+			&arrow.FixedSizeBinaryType{ByteWidth: 2},
+			series.New([]interface{}{"ab", nil, "cd", "ef", nil}, series.String, "x"),
+			series.New([]interface{}{"ab", nil, "cd", "ef", nil}, series.String, "x"),
+		},
+		{
+			&arrow.Decimal128Type{Precision: 5, Scale: 2},
+			series.New([]interface{}{1.25, nil, -3.125, 999.99, 0}, series.Float, "x"),
+			series.New([]interface{}{1.25, nil, -3.13, 999.99, 0}, series.Float, "x"),
+		},
+		{
+			&arrow.Decimal128Type{Precision: 3, Scale: -2},
+			series.New([]interface{}{1250, nil, -300, 49, 99900}, series.Float, "x"),
+			series.New([]interface{}{1300, nil, -300, 0, 99900}, series.Float, "x"),
+		},
+		{
+			arrow.Null,
+			series.New([]interface{}{nil, nil, nil, nil, nil}, series.Int, "x"),
+			series.New([]interface{}{nil, nil, nil, nil, nil}, series.String, "x"),
+		},
+		{
+			arrow.FixedWidthTypes.Date32,
+			series.New([]interface{}{ts, nil, day, ts, day}, series.Time, "x"),
+			series.New([]interface{}{day, nil, day, day, day}, series.Time, "x"),
+		},
+		{
+			arrow.FixedWidthTypes.Date64,
+			series.New([]interface{}{ts, nil, day, ts, day}, series.Time, "x"),
+			series.New([]interface{}{day, nil, day, day, day}, series.Time, "x"),
+		},
+		{
+			&arrow.TimestampType{Unit: arrow.Second, TimeZone: "UTC"},
+			series.New([]interface{}{ts, nil, day, ts, day}, series.Time, "x"),
+			series.New([]interface{}{ts, nil, day, ts, day}, series.Time, "x"),
+		},
+	}
+	for i, tc := range table {
+		schema := arrow.NewSchema([]arrow.Field{{Name: "x", Type: tc.dt, Nullable: true}}, nil)
+		// Records of 2 rows, so that the table has several chunks
+		recs, err := dataframeToRecords(New(tc.in), schema, 2)
+		if err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		tbl := array.NewTableFromRecords(schema, recs)
+		for _, rec := range recs {
+			rec.Release()
+		}
+		if n := len(tbl.Column(0).Data().Chunks()); n != 3 {
+			t.Errorf("Test: %d\nExpected 3 chunks, received %d", i, n)
+		}
+		received := TableToDataframe(tbl)
+		tbl.Release()
+		if received.Err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, received.Err)
+		}
+		expected := New(tc.exp)
+		if !reflect.DeepEqual(expected.Types(), received.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, expected.Types(), received.Types())
+		}
+		er, _ := expected.Records(true)
+		rr, _ := received.Records(true)
+		if !reflect.DeepEqual(er, rr) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, er, rr)
+		}
+	}
+}
+
+func TestArrow_TypeErrors(t *testing.T) {
+	table := []struct {
+		dt arrow.DataType
+		in series.Series
+	}{
+		{&arrow.FixedSizeBinaryType{ByteWidth: 2}, series.New([]string{"ab", "c"}, series.String, "x")},
+		{&arrow.Decimal128Type{Precision: 3, Scale: 1}, series.New([]float64{1, 100}, series.Float, "x")},
+		{&arrow.Decimal128Type{Precision: 10, Scale: 1}, series.New([]interface{}{1, "NaN"}, series.Float, "x")},
+		{arrow.Null, series.New([]interface{}{nil, 1}, series.Int, "x")},
+	}
+	for i, tc := range table {
+		schema := arrow.NewSchema([]arrow.Field{{Name: "x", Type: tc.dt, Nullable: true}}, nil)
+		if rec, err := DataframeToRecordWithSchema(New(tc.in), schema, memory.NewGoAllocator()); err == nil {
+			rec.Release()
+			t.Errorf("Test: %d\nExpected error for %v", i, tc.in)
+		}
+	}
+}
+
+func TestArrow_CheckDecimals(t *testing.T) {
+	dt := &arrow.Decimal128Type{Precision: 38, Scale: 2}
+	b := array.NewDecimal128Builder(memory.NewGoAllocator(), dt)
+	b.Append(decimal128.FromI64(12345))
+	b.AppendNull()
+	schema := arrow.NewSchema([]arrow.Field{{Name: "x", Type: dt, Nullable: true}}, nil)
+	exact := b.NewArray()
+	// 2^60 + 1 hundredths don't fit in the 53 bits of a float64 mantissa
+	b.Append(decimal128.FromI64(1<<60 + 1))
+	inexact := b.NewArray()
+	b.Release()
+
+	for i, tc := range []struct {
+		arr   array.Interface
+		check bool
+		err   bool
+	}{
+		{exact, false, false},
+		{exact, true, false},
+		{inexact, false, false},
+		{inexact, true, true},
+	} {
+		rec := array.NewRecord(schema, []array.Interface{tc.arr}, int64(tc.arr.Len()))
+		tbl := array.NewTableFromRecords(schema, []array.Record{rec})
+		rec.Release()
+		received := TableToDataframe(tbl, CheckDecimals(tc.check))
+		tbl.Release()
+		if (received.Err != nil) != tc.err {
+			t.Errorf("Test: %d\nUnexpected error: %v", i, received.Err)
+		}
+	}
+	exact.Release()
+	inexact.Release()
+}
+
+func TestArrow_ZeroCopy(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	df := New(
+		series.New([]interface{}{"b", "a", nil, "b", "a"}, series.String, "key"),
+		series.New([]interface{}{1, 2, 3, nil, 5}, series.Int, "int"),
+		series.New([]interface{}{1.5, nil, 3.5, 4.5, 5.5}, series.Float, "float"),
+	)
+	schema, err := DataframeSchema(df)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two chunks per column
+	var recs []array.Record
+	for _, idx := range [][]int{{0, 1}, {2, 3, 4}} {
+		rec, err := DataframeToRecordWithSchema(df.Subset(idx), schema, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	tbl := array.NewTableFromRecords(schema, recs)
+	for _, rec := range recs {
+		rec.Release()
+	}
+	received := TableToDataframe(tbl)
+	tbl.Release()
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	defer received.Release()
+
+	same := func(name string, a, b DataFrame) {
+		t.Helper()
+		ar, _ := a.Records(true)
+		br, _ := b.Records(true)
+		if !reflect.DeepEqual(ar, br) {
+			t.Errorf("%s: different values:\nA:%v\nB:%v", name, ar, br)
+		}
+	}
+	same("records", df, received)
+	same("filter",
+		df.Filter(F{Colname: "int", Comparator: series.Greater, Comparando: 1}),
+		received.Filter(F{Colname: "int", Comparator: series.Greater, Comparando: 1}))
+	same("arrange", df.Arrange(RevSort("float")), received.Arrange(RevSort("float")))
+	same("aggregate",
+		df.GroupBy("key").Aggregation([]AggregationType{Aggregation_SUM}, []string{"float"}),
+		received.GroupBy("key").Aggregation([]AggregationType{Aggregation_SUM}, []string{"float"}))
+	same("join", df.InnerJoin(received, "key"), received.InnerJoin(df, "key"))
+
+	// Setting elements copies the values instead of writing to the arrays
+	set := New(
+		series.New([]string{"c"}, series.String, "key"),
+		series.New([]int{10}, series.Int, "int"),
+		series.New([]float64{10.5}, series.Float, "float"),
+	)
+	same("set", df.Copy().Set([]int{2}, set), received.Set([]int{2}, set))
+}
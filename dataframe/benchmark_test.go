@@ -265,3 +265,86 @@ func BenchmarkDataFrame_Elem(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkDataFrame_InnerJoin(b *testing.B) {
+	dim := dataframe.New(
+		series.New(generateIntsN(100000, 100000), series.Int, "id"),
+		series.New(generateIntsN(100000, 10), series.Int, "dim"),
+	)
+	table := []struct {
+		name string
+		data dataframe.DataFrame
+	}{
+		{
+			"10000x100000",
+			dataframe.New(
+				series.New(generateIntsN(10000, 100000), series.Int, "id"),
+				series.New(generateIntsN(10000, 10), series.Int, "fact"),
+			),
+		},
+		{
+			"1000000x100000",
+			dataframe.New(
+				series.New(generateIntsN(1000000, 100000), series.Int, "id"),
+				series.New(generateIntsN(1000000, 10), series.Int, "fact"),
+			),
+		},
+	}
+	for _, test := range table {
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test.data.InnerJoin(dim, "id")
+			}
+		})
+	}
+}
+
+func BenchmarkTableToDataframe(b *testing.B) {
+	table := []struct {
+		name string
+		data dataframe.DataFrame
+	}{
+		{"100000x4", dataframe.New(generateSeries(100000, 1)...)},
+		{"1000000x4", dataframe.New(generateSeries(1000000, 1)...)},
+	}
+	for _, test := range table {
+		tbl, err := dataframe.DataframeToTable(test.data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dataframe.TableToDataframe(tbl).Release()
+			}
+		})
+		tbl.Release()
+	}
+}
+
+func BenchmarkGroups_Aggregate(b *testing.B) {
+	n := 200000
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = i % 100
+	}
+	df := dataframe.New(append(generateSeries(n, 1), series.Ints(keys))...)
+	tbl, err := dataframe.DataframeToTable(df)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tbl.Release()
+	table := []struct {
+		name string
+		data dataframe.DataFrame
+	}{
+		{"native", df},
+		{"arrow", dataframe.TableToDataframe(tbl)},
+	}
+	for _, test := range table {
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test.data.GroupBy("X4").Aggregation([]dataframe.AggregationType{dataframe.Aggregation_SUM}, []string{"X0"})
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package dataframe
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+// CSVChunkReader reads a CSV file as a sequence of DataFrames of a fixed
+// number of rows, so that the whole file doesn't have to be kept in memory.
+// The column names and types are set by the first chunk, taking the same
+// LoadOption as ReadCSV, and are used for all the following chunks. Values of
+// later chunks that can't be parsed as the type of their column are loaded
+// the same way as LoadRecords does with WithTypes.
+type CSVChunkReader struct {
+	reader    *csv.Reader
+	chunkRows int
+	options   []LoadOption
+	hasHeader bool
+	names     []string
+	types     map[string]series.Type
+	err       error
+}
+
+// NewCSVChunkReader creates a CSVChunkReader reading chunks of chunkRows rows
+// from r
+func NewCSVChunkReader(r io.Reader, chunkRows int, options ...LoadOption) *CSVChunkReader {
+	cfg := loadOptions{
+		hasHeader: true,
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+	cr := &CSVChunkReader{
+		reader:    newCSVReader(r, options...),
+		chunkRows: chunkRows,
+		options:   options,
+		hasHeader: cfg.hasHeader,
+	}
+	if chunkRows <= 0 {
+		cr.err = fmt.Errorf("csv chunk reader: chunkRows must be positive, got %d", chunkRows)
+	}
+	return cr
+}
+
+// Next returns the next chunk of the file. The last chunk may have fewer rows
+// than the chunk size. Once all the rows have been read, Next returns io.EOF.
+func (cr *CSVChunkReader) Next() (DataFrame, error) {
+	if cr.err != nil {
+		return DataFrame{Err: cr.err}, cr.err
+	}
+	first := cr.types == nil
+	var records [][]string
+	if first && cr.hasHeader {
+		header, err := cr.reader.Read()
+		if err != nil {
+			return cr.fail(err)
+		}
+		records = append(records, header)
+	}
+	for nrows := 0; nrows < cr.chunkRows; nrows++ {
+		record, err := cr.reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return cr.fail(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 || (first && cr.hasHeader && len(records) == 1) {
+		return cr.fail(io.EOF)
+	}
+
+	if first {
+		df := LoadRecords(records, cr.options...)
+		if df.Err != nil {
+			return cr.fail(df.Err)
+		}
+		cr.names = df.Names()
+		cr.types = make(map[string]series.Type, df.ncols)
+		for i, name := range cr.names {
+			cr.types[name] = df.columns[i].Type()
+		}
+		return df, nil
+	}
+
+	options := append(append([]LoadOption{}, cr.options...),
+		HasHeader(false),
+		Names(cr.names...),
+		WithTypes(cr.types),
+	)
+	df := LoadRecords(records, options...)
+	if df.Err != nil {
+		return cr.fail(df.Err)
+	}
+	return df, nil
+}
+
+// Names returns the column names of the chunks, or nil if no chunk has been
+// read yet
+func (cr *CSVChunkReader) Names() []string {
+	return append([]string(nil), cr.names...)
+}
+
+// Types returns the column types of the chunks, or nil if no chunk has been
+// read yet
+func (cr *CSVChunkReader) Types() []series.Type {
+	if cr.names == nil {
+		return nil
+	}
+	types := make([]series.Type, len(cr.names))
+	for i, name := range cr.names {
+		types[i] = cr.types[name]
+	}
+	return types
+}
+
+// fail stores err so that it is returned by every later call to Next
+func (cr *CSVChunkReader) fail(err error) (DataFrame, error) {
+	if err != io.EOF {
+		err = fmt.Errorf("csv chunk reader: %v", err)
+	}
+	cr.err = err
+	return DataFrame{Err: err}, err
+}
+
+// newCSVReader creates a csv.Reader set up with the delimiter and comment
+// options
+func newCSVReader(r io.Reader, options ...LoadOption) *csv.Reader {
+	csvReader := csv.NewReader(r)
+	cfg := loadOptions{
+		delimiter: ',',
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.delimiter != ',' {
+		csvReader.Comma = cfg.delimiter
+	}
+	if cfg.comment != 0 {
+		csvReader.Comment = cfg.comment
+	}
+	return csvReader
+}
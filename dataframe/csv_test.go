@@ -0,0 +1,118 @@
+package dataframe
+
+import (
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+func TestCSVChunkReader(t *testing.T) {
+	csvStr := `
+Country,Age,Amount,Id
+"United States",50,112.1,01234
+"United Kingdom",17,18,12345
+Spain,NA,555.42,00241
+France,12,abc,3
+Italy,30,1.5,4
+`
+	full := ReadCSV(strings.NewReader(csvStr))
+
+	tests := []struct {
+		chunkRows int
+		options   []LoadOption
+		expRows   []int
+		expTypes  []series.Type
+	}{
+		{
+			2,
+			nil,
+			[]int{2, 2, 1},
+			[]series.Type{series.String, series.Int, series.Float, series.Int},
+		},
+		{
+			3,
+			[]LoadOption{WithTypes(map[string]series.Type{"Amount": series.String})},
+			[]int{3, 2},
+			[]series.Type{series.String, series.Int, series.String, series.Int},
+		},
+		{
+			10,
+			nil,
+			[]int{5},
+			[]series.Type{series.String, series.Int, series.String, series.Int},
+		},
+	}
+	for testnum, test := range tests {
+		cr := NewCSVChunkReader(strings.NewReader(csvStr), test.chunkRows, test.options...)
+		var rows []int
+		var chunks []DataFrame
+		for {
+			df, err := cr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Test:%v\nError:%v", testnum, err)
+			}
+			if received := df.Types(); !reflect.DeepEqual(test.expTypes, received) {
+				t.Errorf("Test:%v\nExpected types:\n%v\nReceived:\n%v", testnum, test.expTypes, received)
+			}
+			rows = append(rows, df.Nrow())
+			chunks = append(chunks, df)
+		}
+		if !reflect.DeepEqual(test.expRows, rows) {
+			t.Errorf("Test:%v\nExpected rows:\n%v\nReceived:\n%v", testnum, test.expRows, rows)
+		}
+		if !reflect.DeepEqual(test.expTypes, cr.Types()) {
+			t.Errorf("Test:%v\nExpected types:\n%v\nReceived:\n%v", testnum, test.expTypes, cr.Types())
+		}
+		if _, err := cr.Next(); err != io.EOF {
+			t.Errorf("Test:%v\nExpected io.EOF after the last chunk, got %v", testnum, err)
+		}
+
+		// The country and id columns read the same as the whole file
+		received := chunks[0]
+		for _, df := range chunks[1:] {
+			received = received.RBind(df)
+		}
+		expected := full.Select([]string{"Country", "Id"})
+		er, _ := expected.Records(true)
+		rr, _ := received.Select([]string{"Country", "Id"}).Records(true)
+		if !reflect.DeepEqual(er, rr) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", testnum, expected, received)
+		}
+	}
+
+	// Values not matching the type of the first chunk are read as NaN
+	cr := NewCSVChunkReader(strings.NewReader(csvStr), 2)
+	cr.Next()
+	second, err := cr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := second.Col("Amount").Float(true); len(f) != 2 || !math.IsNaN(f[1]) {
+		t.Errorf("Expected NaN Amount, received:\n%v", second)
+	}
+	if cr.Names() == nil || !reflect.DeepEqual(cr.Names(), full.Names()) {
+		t.Errorf("Expected names %v, received %v", full.Names(), cr.Names())
+	}
+
+	// Errors
+	if _, err := NewCSVChunkReader(strings.NewReader(csvStr), 0).Next(); err == nil || err == io.EOF {
+		t.Errorf("Expected error for a chunk size of 0, got %v", err)
+	}
+	if _, err := NewCSVChunkReader(strings.NewReader("a,b\n"), 2).Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF for a header only file, got %v", err)
+	}
+	cr = NewCSVChunkReader(strings.NewReader("a,b\n1,2\n3\n"), 1)
+	if _, err := cr.Next(); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	if _, err := cr.Next(); err == nil || err == io.EOF {
+		t.Errorf("Expected error for a short record, got %v", err)
+	}
+}
@@ -7,13 +7,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/Paradigm4/gota/series"
+	"github.com/apache/arrow/go/arrow"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
@@ -388,13 +391,17 @@ func (df DataFrame) Drop(indexes SelectIndexes) DataFrame {
 
 const KEY_ERROR = "KEY_ERROR"
 
-//GroupBy Group dataframe by columns
+// GroupBy groups the rows of the DataFrame by the values of the given columns.
+// Keys are hashed using their typed values, and groups are kept in order of
+// first appearance of their key (see SortKeys). Non-valid key values, as well
+// as NaNs, form groups of their own.
 func (df DataFrame) GroupBy(colnames ...string) *Groups {
 	if len(colnames) <= 0 {
 		return nil
 	}
-	groupDataFrame := make(map[string]DataFrame)
-	groupSeries := make(map[string][]map[string]interface{})
+	if df.Err != nil {
+		return &Groups{Err: fmt.Errorf("GroupBy: %v", df.Err)}
+	}
 	// Check that colname exist on dataframe
 	for _, c := range colnames {
 		if idx := findInStringSlice(c, df.Names()); idx == -1 {
@@ -402,36 +409,37 @@ func (df DataFrame) GroupBy(colnames ...string) *Groups {
 		}
 	}
 
-	for _, s := range df.Maps() {
-		// Gen Key for per Series
-		key := ""
-		for i, c := range colnames {
-			format := ""
-			if i == 0 {
-				format = "%s%"
-			} else {
-				format = "%s_%"
+	// Combine the codes of every key column into a single group code per row
+	codes, n := df.Col(colnames[0]).GroupCodes()
+	for _, c := range colnames[1:] {
+		next, _ := df.Col(c).GroupCodes()
+		combined := make(map[[2]int]int, n)
+		for i := range codes {
+			k := [2]int{codes[i], next[i]}
+			code, ok := combined[k]
+			if !ok {
+				code = len(combined)
+				combined[k] = code
 			}
-			switch s[c].(type) {
-			case string, bool:
-				format += "s"
-			case int, int16, int32, int64:
-				format += "d"
-			case float32, float64:
-				format += "f"
-			default:
-				return &Groups{Err: fmt.Errorf("GroupBy: type not found")}
-			}
-			key = fmt.Sprintf(format, key, s[c])
+			codes[i] = code
 		}
-		groupSeries[key] = append(groupSeries[key], s)
+		n = len(combined)
 	}
 
-	for k, cMaps := range groupSeries {
-		groupDataFrame[k] = LoadMaps(cMaps)
+	indices := make([][]int, n)
+	first := make([]int, n)
+	for i, code := range codes {
+		if indices[code] == nil {
+			first[code] = i
+		}
+		indices[code] = append(indices[code], i)
+	}
+	return &Groups{
+		df:       df,
+		colnames: colnames,
+		keys:     df.Select(colnames).Subset(first),
+		indices:  indices,
 	}
-	groups := &Groups{groups: groupDataFrame, colnames: colnames}
-	return groups
 }
 
 //AggregationType Aggregation method type
@@ -445,6 +453,15 @@ const (
 	Aggregation_STD    AggregationType = 4
 	Aggregation_SUM    AggregationType = 5
 	Aggregation_COUNT  AggregationType = 6
+
+	Aggregation_FIRST         AggregationType = 7  // first valid element
+	Aggregation_LAST          AggregationType = 8  // last valid element
+	Aggregation_NUNIQUE       AggregationType = 9  // number of distinct valid values
+	Aggregation_COUNT_NONNULL AggregationType = 10 // number of valid elements
+	Aggregation_QUANTILE      AggregationType = 11 // quantile P, see QuantileAgg
+	Aggregation_VAR           AggregationType = 12
+	Aggregation_PROD          AggregationType = 13
+	Aggregation_CUSTOM        AggregationType = 14 // user-defined reducer, see CustomAgg
 )
 
 func (aggregation AggregationType) String() string {
@@ -463,6 +480,22 @@ func (aggregation AggregationType) String() string {
 		return "SUM"
 	case Aggregation_COUNT:
 		return "COUNT"
+	case Aggregation_FIRST:
+		return "FIRST"
+	case Aggregation_LAST:
+		return "LAST"
+	case Aggregation_NUNIQUE:
+		return "NUNIQUE"
+	case Aggregation_COUNT_NONNULL:
+		return "COUNT_NONNULL"
+	case Aggregation_QUANTILE:
+		return "QUANTILE"
+	case Aggregation_VAR:
+		return "VAR"
+	case Aggregation_PROD:
+		return "PROD"
+	case Aggregation_CUSTOM:
+		return "CUSTOM"
 	default:
 		return "UNKNOWN"
 	}
@@ -470,62 +503,385 @@ func (aggregation AggregationType) String() string {
 
 //Groups : structure generated by groupby
 type Groups struct {
-	groups      map[string]DataFrame
+	df          DataFrame
 	colnames    []string
+	keys        DataFrame // the key of each group, one row per group
+	indices     [][]int   // the rows of df in each group
 	aggregation DataFrame
 	Err         error
 }
 
-// Aggregation :Aggregate dataframe by aggregation type and aggregation column name
-func (gps Groups) Aggregation(typs []AggregationType, colnames []string) DataFrame {
-	if gps.groups == nil {
-		return DataFrame{Err: fmt.Errorf("Aggregation: input is nil")}
+// SortKeys orders the groups by their keys. Non-valid keys are placed last.
+func (gps *Groups) SortKeys() *Groups {
+	if gps.Err != nil || gps.indices == nil {
+		return gps
+	}
+	idx := make([]int, len(gps.indices))
+	for i := range idx {
+		idx[i] = i
+	}
+	for i := len(gps.colnames) - 1; i >= 0; i-- {
+		idx = gps.keys.columns[i].OrderUsingIndex(false, idx)
+	}
+	indices := make([][]int, len(idx))
+	for i, j := range idx {
+		indices[i] = gps.indices[j]
 	}
+	return &Groups{
+		df:       gps.df,
+		colnames: gps.colnames,
+		keys:     gps.keys.Subset(idx),
+		indices:  indices,
+	}
+}
+
+// Agg describes an aggregation of a column of the groups, see Groups.Aggregate
+type Agg struct {
+	Colname string
+	Type    AggregationType
+	P       float64                             // probability for Aggregation_QUANTILE
+	Func    func(series.Series) series.Element // reducer for Aggregation_CUSTOM
+	Name    string                              // output column name, COLNAME_TYPE if empty
+	err     error
+}
+
+// NewAgg returns the built-in aggregation typ of the given column. QUANTILE
+// needs a probability and is built with QuantileAgg instead.
+func NewAgg(colname string, typ AggregationType) Agg {
+	a := Agg{Colname: colname, Type: typ}
+	if typ == Aggregation_QUANTILE {
+		a.err = fmt.Errorf("no probability given for %s, see QuantileAgg", a.name())
+	}
+	return a
+}
+
+// QuantileAgg returns the aggregation computing the quantile p of the given
+// column, p being between 0 and 1
+func QuantileAgg(colname string, p float64) Agg {
+	a := Agg{Colname: colname, Type: Aggregation_QUANTILE, P: p}
+	if p < 0 || p > 1 || math.IsNaN(p) {
+		a.err = fmt.Errorf("quantile %v out of bounds for %s", p, a.name())
+	}
+	return a
+}
+
+// CustomAgg returns the aggregation applying f to the given column of every
+// group. The type of the output column is the type of the returned elements.
+func CustomAgg(colname, name string, f func(series.Series) series.Element) Agg {
+	return Agg{Colname: colname, Type: Aggregation_CUSTOM, Func: f, Name: name}
+}
+
+// As sets the name of the output column
+func (a Agg) As(name string) Agg {
+	a.Name = name
+	return a
+}
+
+func (a Agg) name() string {
+	switch {
+	case a.Name != "":
+		return a.Name
+	case a.Type == Aggregation_QUANTILE:
+		return fmt.Sprintf("%s_%s_%v", a.Colname, a.Type, a.P)
+	}
+	return fmt.Sprintf("%s_%s", a.Colname, a.Type)
+}
+
+// Aggregation :Aggregate dataframe by aggregation type and aggregation column name.
+// The result has a row per group, holding the key columns with their original
+// types followed by a column per aggregation, named as COLNAME_TYPE.
+func (gps Groups) Aggregation(typs []AggregationType, colnames []string) DataFrame {
 	if len(typs) != len(colnames) {
 		return DataFrame{Err: fmt.Errorf("Aggregation: len(typs) != len(colanmes)")}
 	}
-	dfMaps := make([]map[string]interface{}, 0)
-	for _, df := range gps.groups {
-		targetMap := df.Maps()[0]
-		curMap := make(map[string]interface{})
-		// add columns of  group by
-		for _, c := range gps.colnames {
-			if value, ok := targetMap[c]; ok {
-				curMap[c] = value
-			} else {
-				return DataFrame{Err: fmt.Errorf("Aggregation: can't find column name: %s", c)}
-			}
+	aggs := make([]Agg, len(typs))
+	for i := range typs {
+		aggs[i] = NewAgg(colnames[i], typs[i])
+	}
+	return gps.Aggregate(aggs...)
+}
+
+// Aggregate computes the given aggregations for every group. The result has a
+// row per group, holding the key columns with their original types followed by
+// a column per aggregation. A column may be aggregated several times.
+//
+// COUNT, COUNT_NONNULL and NUNIQUE return Int columns, FIRST and LAST keep the
+// type of the input column, and the rest of built-ins return Float columns.
+// These leave the missing values out, giving NaN for the groups without any.
+func (gps Groups) Aggregate(aggs ...Agg) DataFrame {
+	if gps.Err != nil {
+		return DataFrame{Err: fmt.Errorf("Aggregation: %v", gps.Err)}
+	}
+	if gps.indices == nil {
+		return DataFrame{Err: fmt.Errorf("Aggregation: input is nil")}
+	}
+	columns := gps.keys.Copy().columns
+	for _, a := range aggs {
+		if a.err != nil {
+			return DataFrame{Err: fmt.Errorf("Aggregation: %v", a.err)}
 		}
-		// Aggregation
-		for i, c := range colnames {
-			curSeries := df.Col(c)
-			var value float64
-			switch typs[i] {
-			case Aggregation_MAX:
-				value, _ = curSeries.Max()
-			case Aggregation_MEAN:
-				value, _ = curSeries.Mean()
-			case Aggregation_MEDIAN:
-				value, _ = curSeries.Median()
-			case Aggregation_MIN:
-				value, _ = curSeries.Min()
-			case Aggregation_STD:
-				value, _ = curSeries.StdDev()
-			case Aggregation_SUM:
-				value, _ = curSeries.Sum(true)
-			case Aggregation_COUNT:
-				value = float64(curSeries.Len())
+		idx := findInStringSlice(a.Colname, gps.df.Names())
+		if idx < 0 {
+			return DataFrame{Err: fmt.Errorf("Aggregation: can't find column name: %s", a.Colname)}
+		}
+		col, err := a.apply(gps.df.columns[idx], gps.indices)
+		if err != nil {
+			return DataFrame{Err: fmt.Errorf("Aggregation: %v", err)}
+		}
+		col.Name = a.name()
+		columns = append(columns, col)
+	}
+	gps.aggregation = New(columns...)
+	return gps.aggregation
+}
+
+// apply computes the aggregation over the rows of every group of s
+func (a Agg) apply(s series.Series, groups [][]int) (series.Series, error) {
+	switch a.Type {
+	case Aggregation_COUNT, Aggregation_COUNT_NONNULL, Aggregation_NUNIQUE:
+		var codes []int
+		if a.Type == Aggregation_NUNIQUE {
+			codes, _ = s.GroupCodes()
+		}
+		values := make([]int, len(groups))
+		for g, rows := range groups {
+			values[g] = countRows(a.Type, s, codes, rows)
+		}
+		return series.New(values, series.Int, ""), nil
+	case Aggregation_FIRST, Aggregation_LAST, Aggregation_CUSTOM:
+		if a.Type == Aggregation_CUSTOM && a.Func == nil {
+			return series.Series{}, fmt.Errorf("no function given for %s", a.name())
+		}
+		values := make([]interface{}, len(groups))
+		t := s.Type()
+		typed := false
+		for g, rows := range groups {
+			var e series.Element
+			switch a.Type {
+			case Aggregation_FIRST:
+				e = firstValid(s, rows, false)
+			case Aggregation_LAST:
+				e = firstValid(s, rows, true)
 			default:
-				return DataFrame{Err: fmt.Errorf("Aggregation: this method %s not found", typs[i])}
+				e = a.Func(s.Subset(rows))
+				if e != nil && !typed {
+					t, typed = e.Type(), true
+				}
+			}
+			if e != nil && e.IsValid() {
+				values[g] = e
+			}
+		}
+		ret := series.New(values, t, "")
+		return ret, ret.Err
+	}
+	// Missing values are left out, as for COUNT_NONNULL
+	valid := s.IsValid()
+	values := make([]float64, len(groups))
+	for g, rows := range groups {
+		curSeries := s.Subset(validRows(valid, rows))
+		var value float64
+		var err error
+		switch a.Type {
+		case Aggregation_MAX:
+			value, err = curSeries.Max()
+		case Aggregation_MEAN:
+			value, err = curSeries.Mean()
+		case Aggregation_MEDIAN:
+			value, err = curSeries.Median()
+		case Aggregation_MIN:
+			value, err = curSeries.Min()
+		case Aggregation_STD:
+			value, err = curSeries.StdDev()
+		case Aggregation_SUM:
+			value, err = curSeries.Sum(true)
+		case Aggregation_QUANTILE:
+			value, err = curSeries.Quantile(a.P)
+		case Aggregation_VAR:
+			value, err = curSeries.Var()
+		case Aggregation_PROD:
+			value, err = curSeries.Prod(true)
+		default:
+			return series.Series{}, fmt.Errorf("this method %s not found", a.Type)
+		}
+		if err != nil {
+			value = math.NaN()
+		}
+		values[g] = value
+	}
+	return series.New(values, series.Float, ""), nil
+}
+
+// validRows returns the rows holding a valid element
+func validRows(valid []bool, rows []int) []int {
+	ret := make([]int, 0, len(rows))
+	for _, i := range rows {
+		if valid[i] {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
 
+// countRows implements the counting aggregations over the given rows of s.
+// NUNIQUE needs the group codes of s.
+func countRows(typ AggregationType, s series.Series, codes []int, rows []int) int {
+	if typ == Aggregation_COUNT {
+		return len(rows)
+	}
+	numeric := s.Type() == series.Int || s.Type() == series.Uint || s.Type() == series.Float
+	n := 0
+	seen := make(map[int]bool)
+	for _, i := range rows {
+		e := s.Elem(i)
+		if !e.IsValid() {
+			continue
+		}
+		if typ == Aggregation_COUNT_NONNULL {
+			n++
+			continue
+		}
+		if (numeric && e.IsNaN()) || seen[codes[i]] {
+			continue
+		}
+		seen[codes[i]] = true
+		n++
+	}
+	return n
+}
+
+// firstValid returns the first valid element of s among the given rows, or the
+// last one if reverse is true. Returns nil if there are none.
+func firstValid(s series.Series, rows []int, reverse bool) series.Element {
+	for k := range rows {
+		if reverse {
+			k = len(rows) - 1 - k
+		}
+		if e := s.Elem(rows[k]); e.IsValid() {
+			return e
+		}
+	}
+	return nil
+}
+
+// Keys returns the key of every group, as a DataFrame with a row per group and
+// the key columns with their original types
+func (gps Groups) Keys() DataFrame {
+	if gps.Err != nil {
+		return DataFrame{Err: fmt.Errorf("Keys: %v", gps.Err)}
+	}
+	return gps.keys.Copy()
+}
+
+// Get returns the group with the given key, one value per key column. A nil
+// value matches non-valid keys.
+func (gps Groups) Get(key ...interface{}) DataFrame {
+	if gps.Err != nil {
+		return DataFrame{Err: fmt.Errorf("Get: %v", gps.Err)}
+	}
+	if len(key) != len(gps.colnames) {
+		return DataFrame{Err: fmt.Errorf("Get: wrong number of key values %d, expecting %d", len(key), len(gps.colnames))}
+	}
+	mask := make([]bool, gps.keys.nrows)
+	for i := range mask {
+		mask[i] = true
+	}
+	for j, value := range key {
+		var match []bool
+		if value == nil {
+			match = gps.keys.columns[j].IsValid()
+			for i := range match {
+				match[i] = !match[i]
+			}
+		} else {
+			res := gps.keys.columns[j].Compare(series.Eq, value)
+			if res.Err != nil {
+				return DataFrame{Err: fmt.Errorf("Get: %v", res.Err)}
 			}
-			curMap[fmt.Sprintf("%s_%s", c, typs[i])] = value
+			match, _ = res.Bool()
+		}
+		for i := range mask {
+			mask[i] = mask[i] && match[i]
+		}
+	}
+	for g, ok := range mask {
+		if ok {
+			return gps.df.Subset(gps.indices[g])
 		}
-		dfMaps = append(dfMaps, curMap)
+	}
+	return DataFrame{Err: fmt.Errorf("Get: can't find group %v", key)}
+}
 
+// Apply calls f on every group and binds the resulting DataFrames by rows, in
+// the order of the groups
+func (gps Groups) Apply(f func(DataFrame) DataFrame) DataFrame {
+	if gps.Err != nil {
+		return DataFrame{Err: fmt.Errorf("Apply: %v", gps.Err)}
 	}
-	gps.aggregation = LoadMaps(dfMaps)
-	return gps.aggregation
+	if len(gps.indices) == 0 {
+		return f(gps.df.Subset([]int{}))
+	}
+	var ret DataFrame
+	for g, rows := range gps.indices {
+		res := f(gps.df.Subset(rows))
+		if res.Err != nil {
+			return DataFrame{Err: fmt.Errorf("Apply: %v", res.Err)}
+		}
+		if g == 0 {
+			ret = res
+		} else {
+			ret = ret.RBind(res)
+		}
+		if ret.Err != nil {
+			return DataFrame{Err: fmt.Errorf("Apply: %v", ret.Err)}
+		}
+	}
+	return ret
+}
+
+// Transform calls f on the given columns of every group and puts the results
+// back on the rows of the group, returning the original DataFrame with those
+// columns replaced. f must return a Series with as many elements as the group,
+// or a single element which is then repeated. The type of the new column is
+// the one returned by f.
+func (gps Groups) Transform(f func(series.Series) series.Series, colnames ...string) DataFrame {
+	if gps.Err != nil {
+		return DataFrame{Err: fmt.Errorf("Transform: %v", gps.Err)}
+	}
+	ret := gps.df.Copy()
+	for _, c := range colnames {
+		idx := findInStringSlice(c, ret.Names())
+		if idx < 0 {
+			return DataFrame{Err: fmt.Errorf("Transform: can't find column name: %s", c)}
+		}
+		var col series.Series
+		for g, rows := range gps.indices {
+			res := f(gps.df.columns[idx].Subset(rows))
+			if res.Err != nil {
+				return DataFrame{Err: fmt.Errorf("Transform: %v", res.Err)}
+			}
+			if g == 0 {
+				col = series.New(nil, res.Type(), c, gps.df.nrows)
+			}
+			switch res.Len() {
+			case len(rows):
+				col = col.Update(rows, res)
+			case 1:
+				for _, i := range rows {
+					col = col.Set(i, res.Elem(0))
+				}
+			default:
+				return DataFrame{Err: fmt.Errorf("Transform: wrong length %d for a group of %d rows", res.Len(), len(rows))}
+			}
+			if col.Err != nil {
+				return DataFrame{Err: fmt.Errorf("Transform: %v", col.Err)}
+			}
+		}
+		if len(gps.indices) > 0 {
+			ret.columns[idx] = col
+		}
+	}
+	return ret
 }
 
 // Rename changes the name of one of the columns of a DataFrame
@@ -799,19 +1155,10 @@ func (df DataFrame) FilterAggregation(agg Aggregation, filters ...F) DataFrame {
 	if df.nrows == 0 {
 		return df
 	}
-	compResults := make([]series.Series, len(filters))
+	compResults := make([][]bool, len(filters))
 	for i, f := range filters {
-		var idx int
-		if f.Colname == "" {
-			idx = f.Colidx
-		} else {
-			idx = findInStringSlice(f.Colname, df.Names())
-			if idx < 0 {
-				return DataFrame{Err: fmt.Errorf("filter: can't find column name")}
-			}
-		}
-		res := df.columns[idx].Compare(f.Comparator, f.Comparando)
-		if err := res.Err; err != nil {
+		res, err := df.compare(f)
+		if err != nil {
 			return DataFrame{Err: fmt.Errorf("filter: %v", err)}
 		}
 		compResults[i] = res
@@ -820,15 +1167,9 @@ func (df DataFrame) FilterAggregation(agg Aggregation, filters ...F) DataFrame {
 		return df.Empty()
 	}
 	// Join compResults via "OR"
-	res, err := compResults[0].Bool()
-	if err != nil {
-		return DataFrame{Err: fmt.Errorf("filter: %v", err)}
-	}
+	res := compResults[0]
 	for i := 1; i < len(compResults); i++ {
-		nextRes, err := compResults[i].Bool()
-		if err != nil {
-			return DataFrame{Err: fmt.Errorf("filter: %v", err)}
-		}
+		nextRes := compResults[i]
 		for j := 0; j < len(res); j++ {
 			switch agg {
 			case Or:
@@ -843,6 +1184,25 @@ func (df DataFrame) FilterAggregation(agg Aggregation, filters ...F) DataFrame {
 	return df.Subset(res)
 }
 
+// compare returns the mask resulting of applying the filter to its column
+func (df DataFrame) compare(f F) ([]bool, error) {
+	idx := f.Colidx
+	if f.Colname != "" {
+		idx = findInStringSlice(f.Colname, df.Names())
+		if idx < 0 {
+			return nil, fmt.Errorf("can't find column name")
+		}
+	}
+	if idx < 0 || idx >= df.ncols {
+		return nil, fmt.Errorf("column index out of range: %d", idx)
+	}
+	res := df.columns[idx].Compare(f.Comparator, f.Comparando)
+	if err := res.Err; err != nil {
+		return nil, err
+	}
+	return res.Bool()
+}
+
 // Order is the ordering structure
 type Order struct {
 	Colname string
@@ -905,6 +1265,58 @@ func (df DataFrame) Capply(f func(series.Series) series.Series) DataFrame {
 	return New(columns...)
 }
 
+// Shift moves the elements of the given columns n rows forward, or backward if
+// n is negative, as in series.Series.Shift. With no colnames every column is
+// shifted.
+func (df DataFrame) Shift(n int, colnames ...string) DataFrame {
+	return df.applyColumns("shift", func(s series.Series) series.Series { return s.Shift(n) }, colnames, nil)
+}
+
+// Diff replaces the given columns by the difference between every row and the
+// one n rows before it, as in series.Series.Diff. With no colnames every Int,
+// Uint, Float and Time column is used.
+func (df DataFrame) Diff(n int, colnames ...string) DataFrame {
+	return df.applyColumns("diff", func(s series.Series) series.Series { return s.Diff(n) }, colnames,
+		[]series.Type{series.Int, series.Uint, series.Float, series.Time})
+}
+
+// applyColumns replaces the given columns by the result of f, or every column
+// of the given types if colnames is empty. A nil types means any type.
+func (df DataFrame) applyColumns(name string, f func(series.Series) series.Series, colnames []string, types []series.Type) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	var idx []int
+	for _, c := range colnames {
+		i := findInStringSlice(c, df.Names())
+		if i < 0 {
+			return DataFrame{Err: fmt.Errorf("%s: can't find column name: %s", name, c)}
+		}
+		idx = append(idx, i)
+	}
+	if len(colnames) == 0 {
+		for i, s := range df.columns {
+			for _, t := range types {
+				if s.Type() == t {
+					idx = append(idx, i)
+				}
+			}
+			if types == nil {
+				idx = append(idx, i)
+			}
+		}
+	}
+	ret := df.Copy()
+	for _, i := range idx {
+		res := f(df.columns[i])
+		if res.Err != nil {
+			return DataFrame{Err: fmt.Errorf("column %s: %v", df.columns[i].Name, res.Err)}
+		}
+		ret.columns[i] = res
+	}
+	return ret
+}
+
 // Rapply applies the given function to the rows of a DataFrame. Prior to applying
 // the function the elements of each row are cast to a Series of a specific
 // type. In order of priority: String -> Float -> Int -> Bool. This casting also
@@ -915,10 +1327,12 @@ func (df DataFrame) Rapply(f func(series.Series) series.Series) DataFrame {
 	}
 
 	detectType := func(types []series.Type) series.Type {
-		var hasStrings, hasFloats, hasInts, hasUints, hasBools bool
+		var hasStrings, hasFloats, hasInts, hasUints, hasBools, hasTimes bool
 		for _, t := range types {
 			switch t {
-			case series.String:
+			case series.Time:
+				hasTimes = true
+			case series.String, series.Categorical:
 				hasStrings = true
 			case series.Float:
 				hasFloats = true
@@ -933,6 +1347,10 @@ func (df DataFrame) Rapply(f func(series.Series) series.Series) DataFrame {
 		switch {
 		case hasStrings:
 			return series.String
+		case hasTimes && (hasBools || hasFloats || hasInts || hasUints):
+			return series.String
+		case hasTimes:
+			return series.Time
 		case hasBools:
 			return series.Bool
 		case hasFloats:
@@ -1042,6 +1460,21 @@ type loadOptions struct {
 
 	// The types of specific columns can be specified via column name.
 	types map[string]series.Type
+
+	// Defines the layout used to parse the values of Time columns.
+	timeLayout string
+
+	// Defines the location used to parse the values of Time columns that don't
+	// carry their own time zone.
+	timeLocation *time.Location
+
+	// The columns and row groups read by ReadParquet, all of them if nil.
+	columns   []string
+	rowGroups []int
+
+	// If set, Arrow DECIMAL values that don't survive the conversion to
+	// float64 give an error.
+	checkDecimals bool
 }
 
 // DefaultType sets the defaultType option for loadOptions.
@@ -1093,6 +1526,22 @@ func WithTypes(coltypes map[string]series.Type) LoadOption {
 	}
 }
 
+// WithTimeLayout sets the layout (as in time.Parse) used for parsing the
+// values of Time columns. Defaults to time.RFC3339.
+func WithTimeLayout(layout string) LoadOption {
+	return func(c *loadOptions) {
+		c.timeLayout = layout
+	}
+}
+
+// WithTimeLocation sets the location used for parsing the values of Time
+// columns whose layout has no time zone information. Defaults to UTC.
+func WithTimeLocation(loc *time.Location) LoadOption {
+	return func(c *loadOptions) {
+		c.timeLocation = loc
+	}
+}
+
 // WithDelimiter sets the csv delimiter other than ',', for example '\t'
 func WithDelimiter(b rune) LoadOption {
 	return func(c *loadOptions) {
@@ -1151,6 +1600,8 @@ func LoadStructs(i interface{}, options ...LoadOption) DataFrame {
 		hasHeader:     true,
 		nanValues:     []string{"NaN"},
 		missingValues: []string{"", "NA", "<nil>"},
+		timeLayout:    time.RFC3339,
+		timeLocation:  time.UTC,
 	}
 
 	// Set any custom load options
@@ -1255,6 +1706,10 @@ func parseType(s string) (series.Type, error) {
 		return series.String, nil
 	case "bool":
 		return series.Bool, nil
+	case "time", "time.Time":
+		return series.Time, nil
+	case "categorical":
+		return series.Categorical, nil
 	}
 	return "", fmt.Errorf("type (%s) is not supported", s)
 }
@@ -1268,6 +1723,8 @@ func LoadRecords(records [][]string, options ...LoadOption) DataFrame {
 		hasHeader:     true,
 		nanValues:     []string{"NaN"},
 		missingValues: []string{"", "NA", "<nil>"},
+		timeLayout:    time.RFC3339,
+		timeLocation:  time.UTC,
 	}
 
 	// Set any custom load options
@@ -1327,7 +1784,12 @@ func LoadRecords(records [][]string, options ...LoadOption) DataFrame {
 
 	columns := make([]series.Series, len(headers))
 	for i, colname := range headers {
-		col := series.New(rawcols[i], types[i], colname)
+		var col series.Series
+		if types[i] == series.Time {
+			col = series.ParseTimes(rawcols[i], cfg.timeLayout, cfg.timeLocation, colname)
+		} else {
+			col = series.New(rawcols[i], types[i], colname)
+		}
 		if col.Err != nil {
 			return DataFrame{Err: col.Err}
 		}
@@ -1424,21 +1886,7 @@ func LoadMatrix(mat Matrix) DataFrame {
 // ReadCSV reads a CSV file from a io.Reader and builds a DataFrame with the
 // resulting records.
 func ReadCSV(r io.Reader, options ...LoadOption) DataFrame {
-	csvReader := csv.NewReader(r)
-	cfg := loadOptions{
-		delimiter: ',',
-	}
-	for _, option := range options {
-		option(&cfg)
-	}
-	if cfg.delimiter != ',' {
-		csvReader.Comma = cfg.delimiter
-	}
-	if cfg.comment != 0 {
-		csvReader.Comment = cfg.comment
-	}
-
-	records, err := csvReader.ReadAll()
+	records, err := newCSVReader(r, options...).ReadAll()
 	if err != nil {
 		return DataFrame{Err: err}
 	}
@@ -1465,6 +1913,13 @@ type writeOptions struct {
 	// Specifies whether the header is also written
 	writeHeader    bool
 	writeDelimiter rune
+	// Arrow schema and number of rows of each record batch, see WriteArrowIPC
+	schema    *arrow.Schema
+	batchRows int
+	// Compression codec and maximum number of rows of each row group, see
+	// WriteParquet
+	codec        ParquetCodec
+	rowGroupRows int
 }
 
 // WriteHeader sets the writeHeader option for writeOptions.
@@ -1826,416 +2281,22 @@ func (df DataFrame) Duplicated(subset SelectIndexes, keep string) series.Series
 
 // InnerJoin returns a DataFrame containing the inner join of two DataFrames.
 func (df DataFrame) InnerJoin(b DataFrame, keys ...string) DataFrame {
-	if len(keys) == 0 {
-		return DataFrame{Err: fmt.Errorf("join keys not specified")}
-	}
-	// Check that we have all given keys in both DataFrames
-	var iKeysA []int
-	var iKeysB []int
-	var errorArr []string
-	for _, key := range keys {
-		i := df.colIndex(key)
-		if i < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on left DataFrame", key))
-		}
-		iKeysA = append(iKeysA, i)
-		j := b.colIndex(key)
-		if j < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on right DataFrame", key))
-		}
-		iKeysB = append(iKeysB, j)
-	}
-	if len(errorArr) != 0 {
-		return DataFrame{Err: fmt.Errorf(strings.Join(errorArr, "\n"))}
-	}
-
-	aCols := df.columns
-	bCols := b.columns
-	// Initialize newCols
-	var newCols []series.Series
-	for _, i := range iKeysA {
-		newCols = append(newCols, aCols[i].Empty())
-	}
-	var iNotKeysA []int
-	for i := 0; i < df.ncols; i++ {
-		if !inIntSlice(i, iKeysA) {
-			iNotKeysA = append(iNotKeysA, i)
-			newCols = append(newCols, aCols[i].Empty())
-		}
-	}
-	var iNotKeysB []int
-	for i := 0; i < b.ncols; i++ {
-		if !inIntSlice(i, iKeysB) {
-			iNotKeysB = append(iNotKeysB, i)
-			newCols = append(newCols, bCols[i].Empty())
-		}
-	}
-
-	// Fill newCols
-	for i := 0; i < df.nrows; i++ {
-		for j := 0; j < b.nrows; j++ {
-			match := true
-			for k := range keys {
-				aElem := aCols[iKeysA[k]].Elem(i)
-				bElem := bCols[iKeysB[k]].Elem(j)
-				match = match && aElem.Eq(bElem)
-			}
-			if match {
-				ii := 0
-				for _, k := range iKeysA {
-					elem := aCols[k].Elem(i)
-					newCols[ii].Append(elem)
-					ii++
-				}
-				for _, k := range iNotKeysA {
-					elem := aCols[k].Elem(i)
-					newCols[ii].Append(elem)
-					ii++
-				}
-				for _, k := range iNotKeysB {
-					elem := bCols[k].Elem(j)
-					newCols[ii].Append(elem)
-					ii++
-				}
-			}
-		}
-	}
-	return New(newCols...)
+	return df.Join(b, Join_INNER, On(keys...))
 }
 
 // LeftJoin returns a DataFrame containing the left join of two DataFrames.
 func (df DataFrame) LeftJoin(b DataFrame, keys ...string) DataFrame {
-	if len(keys) == 0 {
-		return DataFrame{Err: fmt.Errorf("join keys not specified")}
-	}
-	// Check that we have all given keys in both DataFrames
-	var iKeysA []int
-	var iKeysB []int
-	var errorArr []string
-	for _, key := range keys {
-		i := df.colIndex(key)
-		if i < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on left DataFrame", key))
-		}
-		iKeysA = append(iKeysA, i)
-		j := b.colIndex(key)
-		if j < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on right DataFrame", key))
-		}
-		iKeysB = append(iKeysB, j)
-	}
-	if len(errorArr) != 0 {
-		return DataFrame{Err: fmt.Errorf(strings.Join(errorArr, "\n"))}
-	}
-
-	aCols := df.columns
-	bCols := b.columns
-	// Initialize newCols
-	var newCols []series.Series
-	for _, i := range iKeysA {
-		newCols = append(newCols, aCols[i].Empty())
-	}
-	var iNotKeysA []int
-	for i := 0; i < df.ncols; i++ {
-		if !inIntSlice(i, iKeysA) {
-			iNotKeysA = append(iNotKeysA, i)
-			newCols = append(newCols, aCols[i].Empty())
-		}
-	}
-	var iNotKeysB []int
-	for i := 0; i < b.ncols; i++ {
-		if !inIntSlice(i, iKeysB) {
-			iNotKeysB = append(iNotKeysB, i)
-			newCols = append(newCols, bCols[i].Empty())
-		}
-	}
-
-	// Fill newCols
-	for i := 0; i < df.nrows; i++ {
-		matched := false
-		for j := 0; j < b.nrows; j++ {
-			match := true
-			for k := range keys {
-				aElem := aCols[iKeysA[k]].Elem(i)
-				bElem := bCols[iKeysB[k]].Elem(j)
-				match = match && aElem.Eq(bElem)
-			}
-			if match {
-				matched = true
-				ii := 0
-				for _, k := range iKeysA {
-					elem := aCols[k].Elem(i)
-					newCols[ii].Append(elem)
-					ii++
-				}
-				for _, k := range iNotKeysA {
-					elem := aCols[k].Elem(i)
-					newCols[ii].Append(elem)
-					ii++
-				}
-				for _, k := range iNotKeysB {
-					elem := bCols[k].Elem(j)
-					newCols[ii].Append(elem)
-					ii++
-				}
-			}
-		}
-		if !matched {
-			ii := 0
-			for _, k := range iKeysA {
-				elem := aCols[k].Elem(i)
-				newCols[ii].Append(elem)
-				ii++
-			}
-			for _, k := range iNotKeysA {
-				elem := aCols[k].Elem(i)
-				newCols[ii].Append(elem)
-				ii++
-			}
-			for range iNotKeysB {
-				newCols[ii].Append("NaN") // expected behavior is for promotion to NaN, not a non-valid <nil> cell
-				ii++
-			}
-		}
-	}
-	return New(newCols...)
+	return df.Join(b, Join_LEFT, On(keys...))
 }
 
 // RightJoin returns a DataFrame containing the right join of two DataFrames.
 func (df DataFrame) RightJoin(b DataFrame, keys ...string) DataFrame {
-	if len(keys) == 0 {
-		return DataFrame{Err: fmt.Errorf("join keys not specified")}
-	}
-	// Check that we have all given keys in both DataFrames
-	var iKeysA []int
-	var iKeysB []int
-	var errorArr []string
-	for _, key := range keys {
-		i := df.colIndex(key)
-		if i < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on left DataFrame", key))
-		}
-		iKeysA = append(iKeysA, i)
-		j := b.colIndex(key)
-		if j < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on right DataFrame", key))
-		}
-		iKeysB = append(iKeysB, j)
-	}
-	if len(errorArr) != 0 {
-		return DataFrame{Err: fmt.Errorf(strings.Join(errorArr, "\n"))}
-	}
-
-	aCols := df.columns
-	bCols := b.columns
-	// Initialize newCols
-	var newCols []series.Series
-	for _, i := range iKeysA {
-		newCols = append(newCols, aCols[i].Empty())
-	}
-	var iNotKeysA []int
-	for i := 0; i < df.ncols; i++ {
-		if !inIntSlice(i, iKeysA) {
-			iNotKeysA = append(iNotKeysA, i)
-			newCols = append(newCols, aCols[i].Empty())
-		}
-	}
-	var iNotKeysB []int
-	for i := 0; i < b.ncols; i++ {
-		if !inIntSlice(i, iKeysB) {
-			iNotKeysB = append(iNotKeysB, i)
-			newCols = append(newCols, bCols[i].Empty())
-		}
-	}
-
-	// Fill newCols
-	var yesmatched []struct{ i, j int }
-	var nonmatched []int
-	for j := 0; j < b.nrows; j++ {
-		matched := false
-		for i := 0; i < df.nrows; i++ {
-			match := true
-			for k := range keys {
-				aElem := aCols[iKeysA[k]].Elem(i)
-				bElem := bCols[iKeysB[k]].Elem(j)
-				match = match && aElem.Eq(bElem)
-			}
-			if match {
-				matched = true
-				yesmatched = append(yesmatched, struct{ i, j int }{i, j})
-			}
-		}
-		if !matched {
-			nonmatched = append(nonmatched, j)
-		}
-	}
-	for _, v := range yesmatched {
-		i := v.i
-		j := v.j
-		ii := 0
-		for _, k := range iKeysA {
-			elem := aCols[k].Elem(i)
-			newCols[ii].Append(elem)
-			ii++
-		}
-		for _, k := range iNotKeysA {
-			elem := aCols[k].Elem(i)
-			newCols[ii].Append(elem)
-			ii++
-		}
-		for _, k := range iNotKeysB {
-			elem := bCols[k].Elem(j)
-			newCols[ii].Append(elem)
-			ii++
-		}
-	}
-	for _, j := range nonmatched {
-		ii := 0
-		for _, k := range iKeysB {
-			elem := bCols[k].Elem(j)
-			newCols[ii].Append(elem)
-			ii++
-		}
-		for range iNotKeysA {
-			newCols[ii].Append("NaN") // expected behavior is for promotion to NaN, not a non-valid <nil> cell
-			ii++
-		}
-		for _, k := range iNotKeysB {
-			elem := bCols[k].Elem(j)
-			newCols[ii].Append(elem)
-			ii++
-		}
-	}
-	return New(newCols...)
+	return df.Join(b, Join_RIGHT, On(keys...))
 }
 
 // OuterJoin returns a DataFrame containing the outer join of two DataFrames.
 func (df DataFrame) OuterJoin(b DataFrame, keys ...string) DataFrame {
-	if len(keys) == 0 {
-		return DataFrame{Err: fmt.Errorf("join keys not specified")}
-	}
-	// Check that we have all given keys in both DataFrames
-	var iKeysA []int
-	var iKeysB []int
-	var errorArr []string
-	for _, key := range keys {
-		i := df.colIndex(key)
-		if i < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on left DataFrame", key))
-		}
-		iKeysA = append(iKeysA, i)
-		j := b.colIndex(key)
-		if j < 0 {
-			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on right DataFrame", key))
-		}
-		iKeysB = append(iKeysB, j)
-	}
-	if len(errorArr) != 0 {
-		return DataFrame{Err: fmt.Errorf(strings.Join(errorArr, "\n"))}
-	}
-
-	aCols := df.columns
-	bCols := b.columns
-	// Initialize newCols
-	var newCols []series.Series
-	for _, i := range iKeysA {
-		newCols = append(newCols, aCols[i].Empty())
-	}
-	var iNotKeysA []int
-	for i := 0; i < df.ncols; i++ {
-		if !inIntSlice(i, iKeysA) {
-			iNotKeysA = append(iNotKeysA, i)
-			newCols = append(newCols, aCols[i].Empty())
-		}
-	}
-	var iNotKeysB []int
-	for i := 0; i < b.ncols; i++ {
-		if !inIntSlice(i, iKeysB) {
-			iNotKeysB = append(iNotKeysB, i)
-			newCols = append(newCols, bCols[i].Empty())
-		}
-	}
-
-	// Fill newCols
-	for i := 0; i < df.nrows; i++ {
-		matched := false
-		for j := 0; j < b.nrows; j++ {
-			match := true
-			for k := range keys {
-				aElem := aCols[iKeysA[k]].Elem(i)
-				bElem := bCols[iKeysB[k]].Elem(j)
-				match = match && aElem.Eq(bElem)
-			}
-			if match {
-				matched = true
-				ii := 0
-				for _, k := range iKeysA {
-					elem := aCols[k].Elem(i)
-					newCols[ii].Append(elem)
-					ii++
-				}
-				for _, k := range iNotKeysA {
-					elem := aCols[k].Elem(i)
-					newCols[ii].Append(elem)
-					ii++
-				}
-				for _, k := range iNotKeysB {
-					elem := bCols[k].Elem(j)
-					newCols[ii].Append(elem)
-					ii++
-				}
-			}
-		}
-		if !matched {
-			ii := 0
-			for _, k := range iKeysA {
-				elem := aCols[k].Elem(i)
-				newCols[ii].Append(elem)
-				ii++
-			}
-			for _, k := range iNotKeysA {
-				elem := aCols[k].Elem(i)
-				newCols[ii].Append(elem)
-				ii++
-			}
-			for range iNotKeysB {
-				newCols[ii].Append("NaN") // expected behavior is for promotion to NaN, not a non-valid <nil> cell
-				ii++
-			}
-		}
-	}
-	for j := 0; j < b.nrows; j++ {
-		matched := false
-		for i := 0; i < df.nrows; i++ {
-			match := true
-			for k := range keys {
-				aElem := aCols[iKeysA[k]].Elem(i)
-				bElem := bCols[iKeysB[k]].Elem(j)
-				match = match && aElem.Eq(bElem)
-			}
-			if match {
-				matched = true
-			}
-		}
-		if !matched {
-			ii := 0
-			for _, k := range iKeysB {
-				elem := bCols[k].Elem(j)
-				newCols[ii].Append(elem)
-				ii++
-			}
-			for range iNotKeysA {
-				newCols[ii].Append("NaN") // expected behavior is for promotion to NaN, not a non-valid <nil> cell
-				ii++
-			}
-			for _, k := range iNotKeysB {
-				elem := bCols[k].Elem(j)
-				newCols[ii].Append(elem)
-				ii++
-			}
-		}
-	}
-	return New(newCols...)
+	return df.Join(b, Join_OUTER, On(keys...))
 }
 
 // CrossJoin returns a DataFrame containing the cross join of two DataFrames.
@@ -2548,7 +2609,7 @@ func (df DataFrame) Describe() DataFrame {
 	for _, col := range df.columns {
 		var newCol series.Series
 		switch col.Type() {
-		case series.String:
+		case series.String, series.Categorical:
 			minstr, err := col.MinStr()
 			if err != nil {
 				return DataFrame{Err: err}
@@ -2570,6 +2631,32 @@ func (df DataFrame) Describe() DataFrame {
 				col.Type(),
 				col.Name,
 			)
+		case series.Time:
+			// Order pushes the non-valid elements to the end
+			var valid []int
+			for _, i := range col.Order(false) {
+				if col.Elem(i).IsValid() {
+					valid = append(valid, i)
+				}
+			}
+			minstr, maxstr := "-", "-"
+			if len(valid) > 0 {
+				minstr, _ = col.Elem(valid[0]).String()
+				maxstr, _ = col.Elem(valid[len(valid)-1]).String()
+			}
+			newCol = series.New([]string{
+				"-",
+				"-",
+				"-",
+				minstr,
+				"-",
+				"-",
+				"-",
+				maxstr,
+			},
+				series.String,
+				col.Name,
+			)
 		case series.Bool:
 			fallthrough
 		case series.Float:
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"math"
 
@@ -981,6 +982,83 @@ func TestDataFrame_Filter_Or(t *testing.T) {
 	}
 }
 
+func TestDataFrame_Where(t *testing.T) {
+	a := New(
+		series.New([]string{"b", "a", "b", "c", "d"}, series.String, "COL.1"),
+		series.New([]interface{}{1, 2, 4, nil, 4}, series.Int, "COL.2"),
+		series.New([]float64{3.0, 4.0, 5.3, 3.2, 1.2}, series.Float, "COL.3"),
+	)
+	table := []struct {
+		expr  Expr
+		expDf DataFrame
+	}{
+		{
+			Col("COL.2").GtEq(4),
+			New(
+				series.New([]string{"b", "d"}, series.String, "COL.1"),
+				series.New([]int{4, 4}, series.Int, "COL.2"),
+				series.New([]float64{5.3, 1.2}, series.Float, "COL.3"),
+			),
+		},
+		{
+			Col("COL.2").Gt(1).And(Col("COL.1").In([]string{"a", "b"})),
+			New(
+				series.New([]string{"a", "b"}, series.String, "COL.1"),
+				series.New([]int{2, 4}, series.Int, "COL.2"),
+				series.New([]float64{4.0, 5.3}, series.Float, "COL.3"),
+			),
+		},
+		{
+			Col("COL.2").Gt(1).And(Col("COL.1").In([]string{"a", "b"})).Not(),
+			New(
+				series.New([]string{"b", "c", "d"}, series.String, "COL.1"),
+				series.New([]interface{}{1, nil, 4}, series.Int, "COL.2"),
+				series.New([]float64{3.0, 3.2, 1.2}, series.Float, "COL.3"),
+			),
+		},
+		{
+			Col("COL.1").Eq("c").Or(Col("COL.3").Lt(2), Col("COL.2").Eq(1)),
+			New(
+				series.New([]string{"b", "c", "d"}, series.String, "COL.1"),
+				series.New([]interface{}{1, nil, 4}, series.Int, "COL.2"),
+				series.New([]float64{3.0, 3.2, 1.2}, series.Float, "COL.3"),
+			),
+		},
+		{
+			Cond(F{Colidx: 2, Comparator: series.LessEq, Comparando: 3.2}).And(Col("COL.1").Neq("d")),
+			New(
+				series.New([]string{"b", "c"}, series.String, "COL.1"),
+				series.New([]interface{}{1, nil}, series.Int, "COL.2"),
+				series.New([]float64{3.0, 3.2}, series.Float, "COL.3"),
+			),
+		},
+	}
+	for i, tc := range table {
+		b := a.Where(tc.expr)
+		if b.Err != nil {
+			t.Errorf("Test: %d\nError:%v", i, b.Err)
+		}
+		if !reflect.DeepEqual(tc.expDf.Types(), b.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expDf.Types(), b.Types())
+		}
+		if !reflect.DeepEqual(tc.expDf.Names(), b.Names()) {
+			t.Errorf("Test: %d\nDifferent colnames:\nA:%v\nB:%v", i, tc.expDf.Names(), b.Names())
+		}
+		tcr, _ := tc.expDf.Records(true)
+		br, _ := b.Records(true)
+		if !reflect.DeepEqual(tcr, br) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tcr, br)
+		}
+	}
+
+	if b := a.Where(Col("COL.9").Eq(1)); b.Err == nil {
+		t.Error("Expected error for a missing column")
+	}
+	if b := a.Where(Expr{}); b.Err == nil {
+		t.Error("Expected error for an empty expression")
+	}
+}
+
 func TestDataFrame_Filter_And(t *testing.T) {
 	a := New(
 		series.New([]string{"b", "a", "b", "c", "d"}, series.String, "COL.1"),
@@ -1358,6 +1436,49 @@ func TestLoadRecords(t *testing.T) {
 			),
 			false,
 		},
+		{
+			LoadRecords(
+				[][]string{
+					{"A", "T"},
+					{"a", "2021-06-01T10:00:00+02:00"},
+					{"b", ""},
+				},
+				WithTypes(map[string]series.Type{"T": series.Time}),
+			),
+			New(
+				series.New([]string{"a", "b"}, series.String, "A"),
+				series.New([]string{"2021-06-01T10:00:00+02:00", ""}, series.Time, "T"),
+			),
+			false,
+		},
+		{
+			LoadRecords(
+				[][]string{
+					{"A", "T"},
+					{"a", "01/06/2021 10:00"},
+					{"b", "NA"},
+				},
+				WithTypes(map[string]series.Type{"T": series.Time}),
+				WithTimeLayout("02/01/2006 15:04"),
+				WithTimeLocation(time.FixedZone("CEST", 2*3600)),
+			),
+			New(
+				series.New([]string{"a", "b"}, series.String, "A"),
+				series.New([]string{"2021-06-01T10:00:00+02:00", ""}, series.Time, "T"),
+			),
+			false,
+		},
+		{
+			LoadRecords(
+				[][]string{
+					{"A", "T"},
+					{"a", "yesterday"},
+				},
+				WithTypes(map[string]series.Type{"T": series.Time}),
+			),
+			DataFrame{},
+			true,
+		},
 	}
 
 	for i, tc := range table {
@@ -1885,6 +2006,142 @@ func TestDataFrame_InnerJoin(t *testing.T) {
 	}
 }
 
+func TestDataFrame_Join_Keys(t *testing.T) {
+	// Non-valid keys match each other, NaN keys never match, and keys of
+	// different types are compared as the type of the left DataFrame
+	a := New(
+		series.New([]interface{}{1.0, nil, math.NaN(), 2.0}, series.Float, "A"),
+		series.New([]string{"a", "b", "c", "d"}, series.String, "B"),
+	)
+	b := New(
+		series.New([]interface{}{"NaN", "2", nil, "1", "1"}, series.Categorical, "A"),
+		series.New([]int{1, 2, 3, 4, 5}, series.Int, "C"),
+	)
+	table := []struct {
+		df     DataFrame
+		expDf  DataFrame
+		method string
+	}{
+		{
+			a.InnerJoin(b, "A"),
+			New(
+				series.New([]interface{}{1.0, 1.0, nil, 2.0}, series.Float, "A"),
+				series.New([]string{"a", "a", "b", "d"}, series.String, "B"),
+				series.New([]int{4, 5, 3, 2}, series.Int, "C"),
+			),
+			"inner",
+		},
+		{
+			a.OuterJoin(b, "A"),
+			New(
+				series.New([]interface{}{1.0, 1.0, nil, math.NaN(), 2.0, math.NaN()}, series.Float, "A"),
+				series.New([]string{"a", "a", "b", "c", "d", "NaN"}, series.String, "B"),
+				series.New([]string{"4", "5", "3", "NaN", "2", "1"}, series.Int, "C"),
+			),
+			"outer",
+		},
+	}
+	for i, tc := range table {
+		if err := tc.df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if !reflect.DeepEqual(tc.expDf.Types(), tc.df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expDf.Types(), tc.df.Types())
+		}
+		expr, _ := tc.expDf.Records(true)
+		r, _ := tc.df.Records(true)
+		if !reflect.DeepEqual(expr, r) {
+			t.Errorf("Test: %d (%s)\nDifferent values:\nA:%v\nB:%v", i, tc.method, expr, r)
+		}
+	}
+}
+
+func TestDataFrame_Join_Options(t *testing.T) {
+	a := LoadRecords(
+		[][]string{
+			{"id", "name", "value"},
+			{"1", "a", "5.1"},
+			{"2", "b", "6.0"},
+			{"3", "c", "7.2"},
+		},
+	)
+	b := LoadRecords(
+		[][]string{
+			{"key", "value", "id"},
+			{"1", "10", "x"},
+			{"1", "11", "y"},
+			{"4", "12", "z"},
+		},
+	)
+	table := []struct {
+		df    DataFrame
+		expDf DataFrame
+	}{
+		{
+			a.Join(b, Join_INNER, LeftOn("id"), RightOn("key")),
+			LoadRecords(
+				[][]string{
+					{"id_0", "name", "value_0", "value_1", "id_1"},
+					{"1", "a", "5.1", "10", "x"},
+					{"1", "a", "5.1", "11", "y"},
+				},
+			),
+		},
+		{
+			a.Join(b, Join_OUTER, LeftOn("id"), RightOn("key"), Suffixes("_l", "_r"), Indicator("")),
+			LoadRecords(
+				[][]string{
+					{"id", "name", "value_l", "value_r", "id_r", "_merge"},
+					{"1", "a", "5.1", "10", "x", "both"},
+					{"1", "a", "5.1", "11", "y", "both"},
+					{"2", "b", "6.0", "NaN", "NaN", "left_only"},
+					{"3", "c", "7.2", "NaN", "NaN", "left_only"},
+					{"4", "NaN", "NaN", "12", "z", "right_only"},
+				},
+				WithTypes(map[string]series.Type{"_merge": series.Categorical}),
+			),
+		},
+		{
+			a.Join(b, Join_LEFT, LeftOn("id"), RightOn("key"), Validate("one_to_many")),
+			LoadRecords(
+				[][]string{
+					{"id_0", "name", "value_0", "value_1", "id_1"},
+					{"1", "a", "5.1", "10", "x"},
+					{"1", "a", "5.1", "11", "y"},
+					{"2", "b", "6.0", "NaN", "NaN"},
+					{"3", "c", "7.2", "NaN", "NaN"},
+				},
+			),
+		},
+	}
+	for i, tc := range table {
+		if err := tc.df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if !reflect.DeepEqual(tc.expDf.Types(), tc.df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expDf.Types(), tc.df.Types())
+		}
+		expr, _ := tc.expDf.Records(true)
+		r, _ := tc.df.Records(true)
+		if !reflect.DeepEqual(expr, r) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, expr, r)
+		}
+	}
+
+	errors := []DataFrame{
+		a.Join(b, Join_INNER, LeftOn("id"), RightOn("key"), Validate("one_to_one")),
+		a.Join(b, Join_INNER, LeftOn("id"), RightOn("key"), Validate("one_to_two")),
+		a.Join(b, Join_INNER, LeftOn("id", "name"), RightOn("key")),
+		a.Join(b, Join_INNER, On("key")),
+		a.Join(b, Join_INNER),
+	}
+	for i, df := range errors {
+		if df.Err == nil {
+			t.Errorf("Test: %d\nExpected error, got:\n%v", i, df)
+		}
+	}
+}
+
 func TestDataFrame_LeftJoin(t *testing.T) {
 	a := LoadRecords(
 		[][]string{
@@ -2834,6 +3091,85 @@ func TestDataFrame_String(t *testing.T) {
 	}
 }
 
+func TestDataFrame_Shift_Diff(t *testing.T) {
+	a := LoadRecords(
+		[][]string{
+			{"A", "B", "C", "D"},
+			{"a", "4", "5.5", "true"},
+			{"b", "6", "6.0", "true"},
+			{"c", "3", "8.5", "false"},
+		},
+	)
+	table := []struct {
+		df       DataFrame
+		expTypes []series.Type
+		expected [][]string
+	}{
+		{
+			a.Shift(1),
+			[]series.Type{series.String, series.Int, series.Float, series.Bool},
+			[][]string{
+				{"A", "B", "C", "D"},
+				{"", "", "", ""},
+				{"a", "4", "5.500000", "true"},
+				{"b", "6", "6.000000", "true"},
+			},
+		},
+		{
+			a.Shift(-1, "A", "C"),
+			[]series.Type{series.String, series.Int, series.Float, series.Bool},
+			[][]string{
+				{"A", "B", "C", "D"},
+				{"b", "4", "6.000000", "true"},
+				{"c", "6", "8.500000", "true"},
+				{"", "3", "", "false"},
+			},
+		},
+		{
+			a.Diff(1),
+			[]series.Type{series.String, series.Int, series.Float, series.Bool},
+			[][]string{
+				{"A", "B", "C", "D"},
+				{"a", "", "", "true"},
+				{"b", "2", "0.500000", "true"},
+				{"c", "-3", "2.500000", "false"},
+			},
+		},
+		{
+			a.Diff(2, "C"),
+			[]series.Type{series.String, series.Int, series.Float, series.Bool},
+			[][]string{
+				{"A", "B", "C", "D"},
+				{"a", "4", "", "true"},
+				{"b", "6", "", "true"},
+				{"c", "3", "3.000000", "false"},
+			},
+		},
+	}
+	for i, tc := range table {
+		if err := tc.df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if !reflect.DeepEqual(tc.expTypes, tc.df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expTypes, tc.df.Types())
+		}
+		received, _ := tc.df.Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, received)
+		}
+	}
+
+	errors := []DataFrame{
+		a.Shift(1, "E"),
+		a.Diff(1, "A"),
+	}
+	for i, df := range errors {
+		if df.Err == nil {
+			t.Errorf("Test: %d\nExpected error, got:\n%v", i, df)
+		}
+	}
+}
+
 func TestDataFrame_Rapply(t *testing.T) {
 	a := LoadRecords(
 		[][]string{
@@ -3204,14 +3540,15 @@ func TestDataFrame_GroupBy(t *testing.T) {
 	resultMap[fmt.Sprintf("%s_%d", "b", 1)] = 3 + 5.3
 	resultMap[fmt.Sprintf("%s_%d", "b", 2)] = 1.2
 
-	for k, values := range groups.groups {
+	for g, rows := range groups.indices {
+		key := fmt.Sprintf("%s_%d", groups.keys.Elem(g, 0).Val(), groups.keys.Elem(g, 1).Val())
 		curV := 0.0
-		for _, vMap := range values.Maps() {
+		for _, vMap := range a.Subset(rows).Maps() {
 			curV += vMap["values"].(float64)
 		}
-		targetV, ok := resultMap[k]
+		targetV, ok := resultMap[key]
 		if !ok {
-			t.Errorf("GroupBy: %s not found", k)
+			t.Errorf("GroupBy: %s not found", key)
 			return
 		}
 		if !IsEqual(float64(targetV), curV) {
@@ -3228,6 +3565,66 @@ func TestDataFrame_GroupBy(t *testing.T) {
 	}
 }
 
+func TestDataFrame_GroupBy_Keys(t *testing.T) {
+	a := New(
+		series.New([]string{"a_b", "a", "a_b", "a", "b"}, series.String, "key1"),
+		series.New([]string{"c", "b_c", "c", "b_c", "c"}, series.String, "key2"),
+		series.New([]interface{}{uint(7), nil, uint(7), nil, uint(3)}, series.Uint, "key3"),
+		series.New([]float64{1, 2, 3, 4, 5}, series.Float, "values"),
+	)
+	groups := a.GroupBy("key1", "key2", "key3")
+	if groups.Err != nil {
+		t.Fatal(groups.Err)
+	}
+	expected := [][]string{
+		{"key1", "key2", "key3"},
+		{"a_b", "c", "7"},
+		{"a", "b_c", ""},
+		{"b", "c", "3"},
+	}
+	received, _ := groups.keys.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different keys:\nA:%v\nB:%v", expected, received)
+	}
+	if !reflect.DeepEqual([][]int{{0, 2}, {1, 3}, {4}}, groups.indices) {
+		t.Errorf("Different rows: %v", groups.indices)
+	}
+
+	sorted := groups.SortKeys()
+	expected = [][]string{
+		{"key1", "key2", "key3"},
+		{"a", "b_c", ""},
+		{"a_b", "c", "7"},
+		{"b", "c", "3"},
+	}
+	received, _ = sorted.keys.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different sorted keys:\nA:%v\nB:%v", expected, received)
+	}
+	if !reflect.DeepEqual([][]int{{1, 3}, {0, 2}, {4}}, sorted.indices) {
+		t.Errorf("Different sorted rows: %v", sorted.indices)
+	}
+
+	df := sorted.Aggregation([]AggregationType{Aggregation_SUM}, []string{"values"})
+	if df.Err != nil {
+		t.Fatal(df.Err)
+	}
+	expTypes := []series.Type{series.String, series.String, series.Uint, series.Float}
+	if !reflect.DeepEqual(expTypes, df.Types()) {
+		t.Errorf("Different types:\nA:%v\nB:%v", expTypes, df.Types())
+	}
+	expected = [][]string{
+		{"key1", "key2", "key3", "values_SUM"},
+		{"a", "b_c", "", "6.000000"},
+		{"a_b", "c", "7", "4.000000"},
+		{"b", "c", "3", "5.000000"},
+	}
+	received, _ = df.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different values:\nA:%v\nB:%v", expected, received)
+	}
+}
+
 func TestDataFrame_Aggregation(t *testing.T) {
 	a := New(
 		series.New([]string{"b", "a", "b", "a", "b"}, series.String, "key1"),
@@ -3248,3 +3645,175 @@ func TestDataFrame_Aggregation(t *testing.T) {
 		}
 	}
 }
+
+func TestGroups_Aggregate(t *testing.T) {
+	a := New(
+		series.New([]string{"b", "a", "b", "a", "b"}, series.String, "key"),
+		series.New([]interface{}{1, 2, nil, 2, 3}, series.Int, "values"),
+		series.New([]float64{1.0, 4.0, 2.0, 3.0, 4.0}, series.Float, "values2"),
+	)
+	groups := a.GroupBy("key")
+	df := groups.Aggregate(
+		NewAgg("values", Aggregation_COUNT),
+		NewAgg("values", Aggregation_COUNT_NONNULL),
+		NewAgg("values", Aggregation_NUNIQUE),
+		NewAgg("values", Aggregation_FIRST),
+		NewAgg("values", Aggregation_LAST).As("last"),
+		NewAgg("values2", Aggregation_PROD),
+		NewAgg("values2", Aggregation_VAR),
+		QuantileAgg("values2", 0.5),
+		CustomAgg("values2", "range", func(s series.Series) series.Element {
+			max, _ := s.Max()
+			min, _ := s.Min()
+			return series.Floats(max - min).Elem(0)
+		}),
+	)
+	if df.Err != nil {
+		t.Fatal(df.Err)
+	}
+	expNames := []string{
+		"key", "values_COUNT", "values_COUNT_NONNULL", "values_NUNIQUE",
+		"values_FIRST", "last", "values2_PROD", "values2_VAR",
+		"values2_QUANTILE_0.5", "range",
+	}
+	if !reflect.DeepEqual(expNames, df.Names()) {
+		t.Errorf("Different colnames:\nA:%v\nB:%v", expNames, df.Names())
+	}
+	expTypes := []series.Type{
+		series.String, series.Int, series.Int, series.Int,
+		series.Int, series.Int, series.Float, series.Float,
+		series.Float, series.Float,
+	}
+	if !reflect.DeepEqual(expTypes, df.Types()) {
+		t.Errorf("Different types:\nA:%v\nB:%v", expTypes, df.Types())
+	}
+	expected := [][]string{
+		expNames,
+		{"b", "3", "2", "2", "1", "3", "8.000000", "2.333333", "2.000000", "3.000000"},
+		{"a", "2", "2", "1", "2", "2", "12.000000", "0.500000", "3.000000", "1.000000"},
+	}
+	received, _ := df.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different values:\nA:%v\nB:%v", expected, received)
+	}
+
+	if df := groups.Aggregate(NewAgg("values", Aggregation_CUSTOM)); df.Err == nil {
+		t.Error("Expected error for a custom aggregation without function")
+	}
+	if df := groups.Aggregate(NewAgg("missing", Aggregation_SUM)); df.Err == nil {
+		t.Error("Expected error for a missing column")
+	}
+	if df := groups.Aggregate(NewAgg("values2", Aggregation_QUANTILE)); df.Err == nil {
+		t.Error("Expected error for a quantile without probability")
+	}
+	if df := groups.Aggregation([]AggregationType{Aggregation_QUANTILE}, []string{"values2"}); df.Err == nil {
+		t.Error("Expected error for a quantile without probability")
+	}
+	for _, p := range []float64{-0.5, 1.5, math.NaN()} {
+		if df := groups.Aggregate(QuantileAgg("values2", p)); df.Err == nil {
+			t.Errorf("Expected error for the quantile %v", p)
+		}
+	}
+}
+
+func TestGroups_Aggregate_Nulls(t *testing.T) {
+	a := New(
+		series.New([]string{"b", "a", "b", "a", "b", "c"}, series.String, "key"),
+		series.New([]interface{}{1, 2, nil, 2, 3, nil}, series.Int, "values"),
+	)
+	typs := []AggregationType{
+		Aggregation_SUM, Aggregation_MEAN, Aggregation_VAR, Aggregation_STD,
+		Aggregation_PROD, Aggregation_MEDIAN, Aggregation_MAX, Aggregation_MIN,
+	}
+	aggs := []Agg{QuantileAgg("values", 0.5)}
+	for _, typ := range typs {
+		aggs = append(aggs, NewAgg("values", typ))
+	}
+	df := a.GroupBy("key").Aggregate(aggs...)
+	if df.Err != nil {
+		t.Fatal(df.Err)
+	}
+	// Missing values are left out, groups without values give NaN
+	expected := [][]string{
+		{"b", "1.000000", "4.000000", "2.000000", "2.000000", "1.414214", "3.000000", "2.000000", "3.000000", "1.000000"},
+		{"a", "2.000000", "4.000000", "2.000000", "0.000000", "0.000000", "4.000000", "2.000000", "2.000000", "2.000000"},
+		{"c", "NaN", "NaN", "NaN", "NaN", "NaN", "NaN", "NaN", "NaN", "NaN"},
+	}
+	received, _ := df.Records(true)
+	if !reflect.DeepEqual(expected, received[1:]) {
+		t.Errorf("Different values:\nA:%v\nB:%v", expected, received[1:])
+	}
+}
+
+func TestGroups_Iteration(t *testing.T) {
+	a := New(
+		series.New([]interface{}{"b", "a", "b", nil, "b"}, series.String, "key"),
+		series.New([]float64{1.0, 4.0, 2.0, 3.0, 6.0}, series.Float, "values"),
+	)
+	groups := a.GroupBy("key")
+
+	keys := groups.Keys()
+	expected := [][]string{{"key"}, {"b"}, {"a"}, {""}}
+	received, _ := keys.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different keys:\nA:%v\nB:%v", expected, received)
+	}
+
+	b := groups.Get("b")
+	expected = [][]string{{"key", "values"}, {"b", "1.000000"}, {"b", "2.000000"}, {"b", "6.000000"}}
+	received, _ = b.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different group:\nA:%v\nB:%v", expected, received)
+	}
+	if n := groups.Get(nil).Nrow(); n != 1 {
+		t.Errorf("Expected a single row for the nil key, got %d", n)
+	}
+	if groups.Get("c").Err == nil {
+		t.Error("Expected error for a missing key")
+	}
+	if groups.Get("a", "b").Err == nil {
+		t.Error("Expected error for the wrong number of key values")
+	}
+
+	first := groups.Apply(func(df DataFrame) DataFrame { return df.Subset(0) })
+	expected = [][]string{{"key", "values"}, {"b", "1.000000"}, {"a", "4.000000"}, {"", "3.000000"}}
+	received, _ = first.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different apply:\nA:%v\nB:%v", expected, received)
+	}
+
+	normalized := groups.Transform(func(s series.Series) series.Series {
+		mean, _ := s.Mean()
+		values, _ := s.Float(false)
+		for i := range values {
+			values[i] -= mean
+		}
+		return series.Floats(values)
+	}, "values")
+	expected = [][]string{
+		{"key", "values"},
+		{"b", "-2.000000"},
+		{"a", "0.000000"},
+		{"b", "-1.000000"},
+		{"", "0.000000"},
+		{"b", "3.000000"},
+	}
+	received, _ = normalized.Records(true)
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Different transform:\nA:%v\nB:%v", expected, received)
+	}
+
+	counts := groups.Transform(func(s series.Series) series.Series {
+		return series.Ints(s.Len())
+	}, "values")
+	if !reflect.DeepEqual([]series.Type{series.String, series.Int}, counts.Types()) {
+		t.Errorf("Different transform types: %v", counts.Types())
+	}
+	received, _ = counts.Records(true)
+	if !reflect.DeepEqual([]string{"values", "3", "1", "3", "1", "3"}, []string{received[0][1], received[1][1], received[2][1], received[3][1], received[4][1], received[5][1]}) {
+		t.Errorf("Different broadcast transform: %v", received)
+	}
+	if groups.Transform(func(s series.Series) series.Series { return series.Ints([]int{1, 2, 3, 4}) }, "values").Err == nil {
+		t.Error("Expected error for a result of the wrong length")
+	}
+}
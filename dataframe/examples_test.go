@@ -290,6 +290,31 @@ func ExampleDataFrame_Filter() {
 
 }
 
+func ExampleDataFrame_Where() {
+	df := dataframe.LoadRecords(
+		[][]string{
+			{"A", "B", "C", "D"},
+			{"a", "4", "5.1", "true"},
+			{"k", "5", "7.0", "true"},
+			{"k", "4", "6.0", "true"},
+			{"a", "2", "7.1", "false"},
+		},
+	)
+	fil := df.Where(
+		dataframe.Col("A").Eq("a").Or(dataframe.Col("B").Gt(4)).
+			And(dataframe.Col("D").Eq(true)),
+	)
+	fmt.Println(fil)
+
+	// Output:
+	// [2x4] DataFrame
+	//
+	//     A        B       C         D
+	//  0: a        4       5.100000  true
+	//  1: k        5       7.000000  true
+	//     <string> <int64> <float64> <bool>
+}
+
 func ExampleDataFrame_Update() {
 	df := dataframe.LoadRecords(
 		[][]string{
@@ -320,7 +345,7 @@ func ExampleDataFrame_Update() {
 	//  2: k        4       c        true
 	//  3: a        2       d        false
 	//     <string> <int64> <string> <bool>
-
+	//
 	// [4x5] DataFrame
 	//
 	//     A        B       C         D      E
@@ -0,0 +1,171 @@
+package dataframe
+
+import (
+	"fmt"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+// Expr is a boolean expression over the columns of a DataFrame, used for
+// selecting rows with Where. Expressions are built from column comparisons,
+// starting with Col, and combined with And, Or and Not:
+//
+//	Col("a").Gt(3).And(Col("b").In([]string{"x", "y"})).Not()
+//
+// Comparisons follow series.Compare, so they are false for non-valid elements.
+// Note that Not negates the whole mask, so it selects those rows as well.
+type Expr struct {
+	op       exprOp
+	filter   F
+	operands []Expr
+}
+
+type exprOp int
+
+const (
+	exprEmpty exprOp = iota
+	exprCompare
+	exprAnd
+	exprOr
+	exprNot
+)
+
+// ColExpr refers to a column by name when building an Expr
+type ColExpr struct {
+	name string
+}
+
+// Col starts an Expr on the column with the given name
+func Col(colname string) ColExpr {
+	return ColExpr{colname}
+}
+
+// Compare is true for the rows where the column compares true against the
+// comparando, as in series.Compare
+func (c ColExpr) Compare(comparator series.Comparator, comparando interface{}) Expr {
+	return Cond(F{Colname: c.name, Comparator: comparator, Comparando: comparando})
+}
+
+// Eq is true for the rows where the column is equal to value
+func (c ColExpr) Eq(value interface{}) Expr { return c.Compare(series.Eq, value) }
+
+// Neq is true for the rows where the column is not equal to value
+func (c ColExpr) Neq(value interface{}) Expr { return c.Compare(series.Neq, value) }
+
+// Gt is true for the rows where the column is greater than value
+func (c ColExpr) Gt(value interface{}) Expr { return c.Compare(series.Greater, value) }
+
+// GtEq is true for the rows where the column is greater or equal than value
+func (c ColExpr) GtEq(value interface{}) Expr { return c.Compare(series.GreaterEq, value) }
+
+// Lt is true for the rows where the column is lesser than value
+func (c ColExpr) Lt(value interface{}) Expr { return c.Compare(series.Less, value) }
+
+// LtEq is true for the rows where the column is lesser or equal than value
+func (c ColExpr) LtEq(value interface{}) Expr { return c.Compare(series.LessEq, value) }
+
+// In is true for the rows where the column is equal to any of the values
+func (c ColExpr) In(values interface{}) Expr { return c.Compare(series.In, values) }
+
+// Cond wraps an existing filter into an Expr
+func Cond(f F) Expr {
+	return Expr{op: exprCompare, filter: f}
+}
+
+// And is true for the rows where e and all the others are true
+func (e Expr) And(others ...Expr) Expr {
+	return Expr{op: exprAnd, operands: append([]Expr{e}, others...)}
+}
+
+// Or is true for the rows where e or any of the others is true
+func (e Expr) Or(others ...Expr) Expr {
+	return Expr{op: exprOr, operands: append([]Expr{e}, others...)}
+}
+
+// Not is true for the rows where e is false
+func (e Expr) Not() Expr {
+	return Expr{op: exprNot, operands: []Expr{e}}
+}
+
+// String returns a representation of the expression
+func (e Expr) String() string {
+	switch e.op {
+	case exprCompare:
+		col := e.filter.Colname
+		if col == "" {
+			col = fmt.Sprintf("#%d", e.filter.Colidx)
+		}
+		return fmt.Sprintf("%s %s %v", col, e.filter.Comparator, e.filter.Comparando)
+	case exprNot:
+		return fmt.Sprintf("NOT (%v)", e.operands[0])
+	case exprAnd, exprOr:
+		sep := " AND "
+		if e.op == exprOr {
+			sep = " OR "
+		}
+		str := ""
+		for i, o := range e.operands {
+			if i > 0 {
+				str += sep
+			}
+			str += "(" + o.String() + ")"
+		}
+		return str
+	}
+	return "<empty>"
+}
+
+// Eval returns the mask of the rows of the DataFrame matching the expression
+func (e Expr) Eval(df DataFrame) ([]bool, error) {
+	if df.Err != nil {
+		return nil, df.Err
+	}
+	switch e.op {
+	case exprCompare:
+		return df.compare(e.filter)
+	case exprNot:
+		res, err := e.operands[0].Eval(df)
+		if err != nil {
+			return nil, err
+		}
+		for i := range res {
+			res[i] = !res[i]
+		}
+		return res, nil
+	case exprAnd, exprOr:
+		res, err := e.operands[0].Eval(df)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range e.operands[1:] {
+			next, err := o.Eval(df)
+			if err != nil {
+				return nil, err
+			}
+			for i := range res {
+				if e.op == exprAnd {
+					res[i] = res[i] && next[i]
+				} else {
+					res[i] = res[i] || next[i]
+				}
+			}
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("empty expression")
+}
+
+// Where returns the rows of the DataFrame for which the expression is true
+func (df DataFrame) Where(e Expr) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	if df.nrows == 0 {
+		return df
+	}
+	mask, err := e.Eval(df)
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("where: %v", err)}
+	}
+	return df.Subset(mask)
+}
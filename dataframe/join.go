@@ -0,0 +1,769 @@
+package dataframe
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+// JoinType is the type of join performed by Join
+type JoinType int
+
+const (
+	Join_INNER JoinType = 0
+	Join_LEFT  JoinType = 1
+	Join_RIGHT JoinType = 2
+	Join_OUTER JoinType = 3
+)
+
+func (how JoinType) String() string {
+	switch how {
+	case Join_INNER:
+		return "INNER"
+	case Join_LEFT:
+		return "LEFT"
+	case Join_RIGHT:
+		return "RIGHT"
+	case Join_OUTER:
+		return "OUTER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Values of the indicator column, see Indicator
+const (
+	JoinLeftOnly  = "left_only"
+	JoinRightOnly = "right_only"
+	JoinBoth      = "both"
+)
+
+// JoinOption is the type used to configure a join
+type JoinOption func(*joinOptions)
+
+type joinOptions struct {
+	// The key columns of the left and right DataFrames.
+	leftOn, rightOn []string
+
+	// If set, the suffixes added to the names of the non-key columns found
+	// on both DataFrames.
+	suffixes *[2]string
+
+	// If set, the name of a column telling which DataFrame each row comes
+	// from.
+	indicator string
+
+	// The expected cardinality of the keys, checked if set.
+	validate string
+}
+
+// On sets the key columns of the join, with the same names on both DataFrames
+func On(keys ...string) JoinOption {
+	return func(c *joinOptions) {
+		c.leftOn = keys
+		c.rightOn = keys
+	}
+}
+
+// LeftOn sets the key columns of the left DataFrame, to be used along with
+// RightOn when they have different names. The resulting key columns take the
+// names of the left DataFrame.
+func LeftOn(keys ...string) JoinOption {
+	return func(c *joinOptions) {
+		c.leftOn = keys
+	}
+}
+
+// RightOn sets the key columns of the right DataFrame, see LeftOn
+func RightOn(keys ...string) JoinOption {
+	return func(c *joinOptions) {
+		c.rightOn = keys
+	}
+}
+
+// Suffixes sets the suffixes appended to the names of the non-key columns
+// present on both DataFrames. Without it, the names are made unique with a
+// _0, _1... suffix as in New.
+func Suffixes(left, right string) JoinOption {
+	return func(c *joinOptions) {
+		c.suffixes = &[2]string{left, right}
+	}
+}
+
+// Indicator adds a Categorical column with the given name, "_merge" if empty,
+// holding JoinLeftOnly, JoinRightOnly or JoinBoth depending on which
+// DataFrames the row comes from.
+func Indicator(name string) JoinOption {
+	if name == "" {
+		name = "_merge"
+	}
+	return func(c *joinOptions) {
+		c.indicator = name
+	}
+}
+
+// Validate checks that the keys have the expected cardinality, returning an
+// error otherwise. The valid values are "one_to_one", "one_to_many",
+// "many_to_one" and "many_to_many", where "one" means that the keys are unique
+// on that DataFrame.
+func Validate(validate string) JoinOption {
+	return func(c *joinOptions) {
+		c.validate = validate
+	}
+}
+
+// Join returns a DataFrame containing the join of two DataFrames of the given
+// type, configured with JoinOption. The key columns are set with On, or
+// LeftOn and RightOn.
+func (df DataFrame) Join(b DataFrame, how JoinType, options ...JoinOption) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	if b.Err != nil {
+		return b
+	}
+	cfg := joinOptions{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if how < Join_INNER || how > Join_OUTER {
+		return DataFrame{Err: fmt.Errorf("join: unknown join type %v", how)}
+	}
+	if len(cfg.leftOn) != len(cfg.rightOn) {
+		return DataFrame{Err: fmt.Errorf("join: LeftOn and RightOn have different lengths")}
+	}
+	iKeysA, iKeysB, err := df.joinKeys(b, cfg.leftOn, cfg.rightOn)
+	if err != nil {
+		return DataFrame{Err: err}
+	}
+	codes := df.joinCodes(b, iKeysA, iKeysB)
+	if cfg.validate != "" {
+		if err := validateJoin(cfg.validate, codes, df.nrows); err != nil {
+			return DataFrame{Err: fmt.Errorf("join: %v", err)}
+		}
+	}
+
+	ia, ib := joinPairs(codes, df.nrows, how != Join_RIGHT)
+	ia, ib = completeJoin(how, ia, ib, df.nrows, b.nrows)
+	return df.joinColumns(b, iKeysA, iKeysB, ia, ib, cfg)
+}
+
+// joinKeys returns the index of the key columns on both DataFrames
+func (df DataFrame) joinKeys(b DataFrame, leftOn, rightOn []string) (iKeysA, iKeysB []int, err error) {
+	if len(leftOn) == 0 {
+		return nil, nil, fmt.Errorf("join keys not specified")
+	}
+	// Check that we have all given keys in both DataFrames
+	var errorArr []string
+	for k := range leftOn {
+		i := df.colIndex(leftOn[k])
+		if i < 0 {
+			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on left DataFrame", leftOn[k]))
+		}
+		iKeysA = append(iKeysA, i)
+		j := b.colIndex(rightOn[k])
+		if j < 0 {
+			errorArr = append(errorArr, fmt.Sprintf("can't find key %q on right DataFrame", rightOn[k]))
+		}
+		iKeysB = append(iKeysB, j)
+	}
+	if len(errorArr) != 0 {
+		return nil, nil, fmt.Errorf(strings.Join(errorArr, "\n"))
+	}
+	return iKeysA, iKeysB, nil
+}
+
+// validateJoin checks the cardinality of the join codes, as in Validate
+func validateJoin(validate string, codes []int, na int) error {
+	var leftOne, rightOne bool
+	switch validate {
+	case "one_to_one":
+		leftOne, rightOne = true, true
+	case "one_to_many":
+		leftOne = true
+	case "many_to_one":
+		rightOne = true
+	case "many_to_many":
+	default:
+		return fmt.Errorf("unknown validation %q", validate)
+	}
+	if leftOne && hasDuplicateCodes(codes[:na]) {
+		return fmt.Errorf("validate %s: keys are not unique on left DataFrame", validate)
+	}
+	if rightOne && hasDuplicateCodes(codes[na:]) {
+		return fmt.Errorf("validate %s: keys are not unique on right DataFrame", validate)
+	}
+	return nil
+}
+
+// hasDuplicateCodes tells whether any code, other than -1, appears twice
+func hasDuplicateCodes(codes []int) bool {
+	seen := make(map[int]bool, len(codes))
+	for _, c := range codes {
+		if c < 0 {
+			continue
+		}
+		if seen[c] {
+			return true
+		}
+		seen[c] = true
+	}
+	return false
+}
+
+// joinCodes encodes the keys of the rows of both DataFrames, so that rows
+// with equal keys get the same code. The first df.nrows codes belong to df and
+// the rest to b. Keys follow Element.Eq: non-valid keys match each other,
+// while NaN keys never match and get code -1. Key columns are made comparable
+// with joinCompatible first.
+func (df DataFrame) joinCodes(b DataFrame, iKeysA, iKeysB []int) []int {
+	var codes []int
+	for k := range iKeysA {
+		a, other := joinCompatible(df.columns[iKeysA[k]], b.columns[iKeysB[k]])
+		col := a.Copy()
+		col.Append(other)
+
+		next, n := col.GroupCodes()
+		switch col.Type() {
+		case series.Int, series.Uint, series.Float:
+			valid, nan := col.IsValid(), col.IsNaN()
+			for i := range next {
+				if valid[i] && nan[i] {
+					next[i] = -1
+				}
+			}
+		}
+		if codes == nil {
+			codes = next
+			continue
+		}
+		// Combine with the codes of the previous key columns
+		combined := make(map[[2]int]int, n)
+		for i := range codes {
+			if codes[i] < 0 || next[i] < 0 {
+				codes[i] = -1
+				continue
+			}
+			k := [2]int{codes[i], next[i]}
+			code, ok := combined[k]
+			if !ok {
+				code = len(combined)
+				combined[k] = code
+			}
+			codes[i] = code
+		}
+	}
+	return codes
+}
+
+// joinCompatible converts the column of b to the type of the column of df,
+// or both to String if the df column is Categorical, following Element.Eq
+func joinCompatible(a, b series.Series) (series.Series, series.Series) {
+	if a.Type() == b.Type() {
+		return a, b
+	}
+	if a.Type() == series.Categorical {
+		a = series.New(a, series.String, a.Name)
+	}
+	return a, series.New(b, a.Type(), b.Name)
+}
+
+// joinPairs returns the pairs of rows of df (ia) and b (ib) with equal join
+// codes. The hash table is built on the rows of the smaller DataFrame and
+// probed with the rows of the other one. Pairs are sorted by the rows of df
+// if byLeft is set, or else by the rows of b, and then by the rows of the
+// other DataFrame.
+func joinPairs(codes []int, na int, byLeft bool) (ia, ib []int) {
+	build, probe := codes[na:], codes[:na]
+	buildLeft := len(build) > len(probe)
+	if buildLeft {
+		build, probe = probe, build
+	}
+	table := make(map[int][]int, len(build))
+	for r, c := range build {
+		if c >= 0 {
+			table[c] = append(table[c], r)
+		}
+	}
+	var pr, br []int
+	for p, c := range probe {
+		if c < 0 {
+			continue
+		}
+		for _, r := range table[c] {
+			pr = append(pr, p)
+			br = append(br, r)
+		}
+	}
+	if buildLeft == byLeft {
+		// Pairs are in probe order and have to be sorted by the build rows,
+		// which keeps the probe rows sorted within each build row
+		br, pr = sortPairs(br, pr, len(build))
+	}
+	if buildLeft {
+		return br, pr
+	}
+	return pr, br
+}
+
+// sortPairs stable sorts the pairs (x, y) by x, where 0 <= x < n
+func sortPairs(x, y []int, n int) ([]int, []int) {
+	start := make([]int, n+1)
+	for _, v := range x {
+		start[v+1]++
+	}
+	for i := 1; i <= n; i++ {
+		start[i] += start[i-1]
+	}
+	sx, sy := make([]int, len(x)), make([]int, len(y))
+	for i, v := range x {
+		sx[start[v]], sy[start[v]] = v, y[i]
+		start[v]++
+	}
+	return sx, sy
+}
+
+// leftRows adds the rows of df with no match to the pairs sorted by the rows
+// of df, keeping that order
+func leftRows(ia, ib []int, na int) ([]int, []int) {
+	ra, rb := make([]int, 0, len(ia)+na), make([]int, 0, len(ib)+na)
+	p := 0
+	for i := 0; i < na; i++ {
+		if p >= len(ia) || ia[p] != i {
+			ra, rb = append(ra, i), append(rb, -1)
+			continue
+		}
+		for ; p < len(ia) && ia[p] == i; p++ {
+			ra, rb = append(ra, i), append(rb, ib[p])
+		}
+	}
+	return ra, rb
+}
+
+// rightRows appends the rows of b with no match to the pairs
+func rightRows(ia, ib []int, nb int) ([]int, []int) {
+	matched := make([]bool, nb)
+	for _, j := range ib {
+		if j >= 0 {
+			matched[j] = true
+		}
+	}
+	for j := 0; j < nb; j++ {
+		if !matched[j] {
+			ia, ib = append(ia, -1), append(ib, j)
+		}
+	}
+	return ia, ib
+}
+
+// completeJoin adds the rows with no match needed by the join type to the
+// matching pairs, which are sorted by the rows of b for Join_RIGHT and by the
+// rows of df otherwise
+func completeJoin(how JoinType, ia, ib []int, na, nb int) ([]int, []int) {
+	switch how {
+	case Join_LEFT:
+		ia, ib = leftRows(ia, ib, na)
+	case Join_RIGHT:
+		ia, ib = rightRows(ia, ib, nb)
+	case Join_OUTER:
+		ia, ib = leftRows(ia, ib, na)
+		ia, ib = rightRows(ia, ib, nb)
+	}
+	return ia, ib
+}
+
+// joinColumns builds the result of a join from the pairs of rows ia and ib.
+// The key columns come first, taking the values of df, or of b for the rows
+// with no df row (-1). Then come the rest of the columns of df and b, with
+// NaN for the missing rows, and the indicator column if set.
+func (df DataFrame) joinColumns(b DataFrame, iKeysA, iKeysB, ia, ib []int, cfg joinOptions) DataFrame {
+	var newCols []series.Series
+	for k, i := range iKeysA {
+		col := df.columns[i].Copy()
+		col.Append(b.columns[iKeysB[k]])
+		idx := make([]int, len(ia))
+		for r := range ia {
+			idx[r] = ia[r]
+			if ia[r] < 0 {
+				idx[r] = df.nrows + ib[r]
+			}
+		}
+		newCols = append(newCols, col.Subset(idx))
+	}
+	var colsA, colsB []series.Series
+	for i := 0; i < df.ncols; i++ {
+		if !inIntSlice(i, iKeysA) {
+			colsA = append(colsA, joinColumn(df.columns[i], ia))
+		}
+	}
+	for i := 0; i < b.ncols; i++ {
+		if !inIntSlice(i, iKeysB) {
+			colsB = append(colsB, joinColumn(b.columns[i], ib))
+		}
+	}
+	if cfg.suffixes != nil {
+		addJoinSuffixes(newCols, colsA, colsB, *cfg.suffixes)
+	}
+	newCols = append(append(newCols, colsA...), colsB...)
+
+	if cfg.indicator != "" {
+		indicator := make([]string, len(ia))
+		for r := range ia {
+			switch {
+			case ia[r] < 0:
+				indicator[r] = JoinRightOnly
+			case ib[r] < 0:
+				indicator[r] = JoinLeftOnly
+			default:
+				indicator[r] = JoinBoth
+			}
+		}
+		newCols = append(newCols, series.New(indicator, series.Categorical, cfg.indicator))
+	}
+	return New(newCols...)
+}
+
+// addJoinSuffixes renames the non-key columns of df and b whose name is found
+// on the other DataFrame, or among the keys for the columns of b
+func addJoinSuffixes(keys, colsA, colsB []series.Series, suffixes [2]string) {
+	names := func(cols []series.Series) map[string]bool {
+		ret := make(map[string]bool, len(cols))
+		for _, c := range cols {
+			ret[c.Name] = true
+		}
+		return ret
+	}
+	namesKeys, namesA, namesB := names(keys), names(colsA), names(colsB)
+	for i := range colsA {
+		if namesB[colsA[i].Name] {
+			colsA[i].Name += suffixes[0]
+		}
+	}
+	for i := range colsB {
+		if namesA[colsB[i].Name] || namesKeys[colsB[i].Name] {
+			colsB[i].Name += suffixes[1]
+		}
+	}
+}
+
+// joinColumn subsets a column by rows, with NaN for the rows set to -1
+func joinColumn(s series.Series, rows []int) series.Series {
+	col := s.Copy()
+	col.Append("NaN") // expected behavior is for promotion to NaN, not a non-valid <nil> cell
+	idx := make([]int, len(rows))
+	for r, i := range rows {
+		idx[r] = i
+		if i < 0 {
+			idx[r] = s.Len()
+		}
+	}
+	return col.Subset(idx)
+}
+
+// AsofDirection is the direction in which AsofJoin looks for a match
+type AsofDirection int
+
+const (
+	Asof_BACKWARD AsofDirection = 0 // last row with an on value lesser or equal
+	Asof_FORWARD  AsofDirection = 1 // first row with an on value greater or equal
+	Asof_NEAREST  AsofDirection = 2 // closest row, backward on ties
+)
+
+func (direction AsofDirection) String() string {
+	switch direction {
+	case Asof_BACKWARD:
+		return "BACKWARD"
+	case Asof_FORWARD:
+		return "FORWARD"
+	case Asof_NEAREST:
+		return "NEAREST"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// AsofJoin returns the rows of df, in the same order, next to the columns of
+// the row of b whose on value is the closest in the given direction. Only the
+// rows of b with the same by values are considered, compared as in Join. The
+// on column has to be Int, Uint, Float or Time, and is converted to the type
+// of df on b if needed.
+//
+// If tolerance is not negative, matches further away are discarded. For Time
+// columns it is measured in nanoseconds, so a time.Duration d is passed as
+// float64(d). Rows of df with no match, or with a non-valid or NaN on value,
+// get NaN on the columns of b. The on and by columns of b are left out.
+func (df DataFrame) AsofJoin(b DataFrame, on string, by []string, direction AsofDirection, tolerance float64) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	if b.Err != nil {
+		return b
+	}
+	if direction < Asof_BACKWARD || direction > Asof_NEAREST {
+		return DataFrame{Err: fmt.Errorf("asof join: unknown direction %v", direction)}
+	}
+	iOnA, iOnB, err := df.joinKeys(b, []string{on}, []string{on})
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("asof join: %v", err)}
+	}
+	onA, onB := joinCompatible(df.columns[iOnA[0]], b.columns[iOnB[0]])
+	switch onA.Type() {
+	case series.Int, series.Uint, series.Float, series.Time:
+	default:
+		return DataFrame{Err: fmt.Errorf("asof join: can't join on a %v column", onA.Type())}
+	}
+	codes := make([]int, df.nrows+b.nrows)
+	var iByB []int
+	if len(by) > 0 {
+		var iByA []int
+		iByA, iByB, err = df.joinKeys(b, by, by)
+		if err != nil {
+			return DataFrame{Err: fmt.Errorf("asof join: %v", err)}
+		}
+		codes = df.joinCodes(b, iByA, iByB)
+	}
+
+	// The rows of each group sorted by their on value
+	rowsA := make(map[int][]int)
+	for _, i := range orderedRows(onA) {
+		if c := codes[i]; c >= 0 {
+			rowsA[c] = append(rowsA[c], i)
+		}
+	}
+	rowsB := make(map[int][]int)
+	for _, j := range orderedRows(onB) {
+		if c := codes[df.nrows+j]; c >= 0 {
+			rowsB[c] = append(rowsB[c], j)
+		}
+	}
+
+	ib := make([]int, df.nrows)
+	for i := range ib {
+		ib[i] = -1
+	}
+	for c, rows := range rowsA {
+		candidates := rowsB[c]
+		// candidates[:lo] are lesser than the current value, and
+		// candidates[:hi] are lesser or equal
+		lo, hi := 0, 0
+		for _, i := range rows {
+			x := onA.Elem(i)
+			for lo < len(candidates) && onB.Elem(candidates[lo]).Less(x) {
+				lo++
+			}
+			if hi < lo {
+				hi = lo
+			}
+			for hi < len(candidates) && onB.Elem(candidates[hi]).LessEq(x) {
+				hi++
+			}
+			backward, forward := -1, -1
+			if hi > 0 {
+				backward = candidates[hi-1]
+			}
+			if lo < len(candidates) {
+				forward = candidates[lo]
+			}
+			match := backward
+			switch direction {
+			case Asof_FORWARD:
+				match = forward
+			case Asof_NEAREST:
+				if backward < 0 || forward >= 0 &&
+					asofDistance(x, onB.Elem(forward)) < asofDistance(x, onB.Elem(backward)) {
+					match = forward
+				}
+			}
+			if match >= 0 && (tolerance < 0 || asofDistance(x, onB.Elem(match)) <= tolerance) {
+				ib[i] = match
+			}
+		}
+	}
+
+	columns := append([]series.Series{}, df.columns...)
+	for j := 0; j < b.ncols; j++ {
+		if j != iOnB[0] && !inIntSlice(j, iByB) {
+			columns = append(columns, joinColumn(b.columns[j], ib))
+		}
+	}
+	return New(columns...)
+}
+
+// asofDistance returns the absolute difference between two elements, in
+// nanoseconds for Time elements
+func asofDistance(x, y series.Element) float64 {
+	if x.Type() == series.Time {
+		xi, _ := x.Int()
+		yi, _ := y.Int()
+		return math.Abs(float64(xi - yi))
+	}
+	xf, _ := x.Float()
+	yf, _ := y.Float()
+	return math.Abs(xf - yf)
+}
+
+// RangeJoin returns a DataFrame containing the join of two DataFrames of the
+// given type, where a row of df matches the rows of b for which its x value is
+// between the lo and hi values, both included:
+//
+//	df.x BETWEEN b.lo AND b.hi
+//
+// All the columns of both DataFrames are kept. As with Join, pairs are sorted
+// by the rows of df, or by the rows of b for Join_RIGHT, and then by the rows
+// of the other DataFrame. Non-valid and NaN values never match. The lo and hi
+// columns are converted to the type of x if needed.
+func (df DataFrame) RangeJoin(b DataFrame, x, lo, hi string, how JoinType) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	if b.Err != nil {
+		return b
+	}
+	if how < Join_INNER || how > Join_OUTER {
+		return DataFrame{Err: fmt.Errorf("range join: unknown join type %v", how)}
+	}
+	iX := df.colIndex(x)
+	if iX < 0 {
+		return DataFrame{Err: fmt.Errorf("range join: can't find column %q on left DataFrame", x)}
+	}
+	for _, c := range []string{lo, hi} {
+		if b.colIndex(c) < 0 {
+			return DataFrame{Err: fmt.Errorf("range join: can't find column %q on right DataFrame", c)}
+		}
+	}
+	xs, los := joinCompatible(df.columns[iX], b.columns[b.colIndex(lo)])
+	_, his := joinCompatible(df.columns[iX], b.columns[b.colIndex(hi)])
+
+	// Sweep the values of x in order, keeping a heap of the intervals whose lo
+	// is lesser or equal than the current value, from which the intervals
+	// whose hi is lesser than it are removed
+	intervals := orderedRows(los)
+	active := &intervalHeap{hi: his}
+	matches := make([][]int, df.nrows)
+	p := 0
+	for _, i := range orderedRows(xs) {
+		v := xs.Elem(i)
+		for p < len(intervals) && los.Elem(intervals[p]).LessEq(v) {
+			if !isMissing(his, intervals[p]) {
+				heap.Push(active, intervals[p])
+			}
+			p++
+		}
+		for active.Len() > 0 && his.Elem(active.rows[0]).Less(v) {
+			heap.Pop(active)
+		}
+		matches[i] = append([]int{}, active.rows...)
+		sort.Ints(matches[i])
+	}
+
+	var ia, ib []int
+	for i, rows := range matches {
+		for _, j := range rows {
+			ia = append(ia, i)
+			ib = append(ib, j)
+		}
+	}
+	if how == Join_RIGHT {
+		ib, ia = sortPairs(ib, ia, b.nrows)
+	}
+	ia, ib = completeJoin(how, ia, ib, df.nrows, b.nrows)
+
+	var columns []series.Series
+	for _, col := range df.columns {
+		columns = append(columns, joinColumn(col, ia))
+	}
+	for _, col := range b.columns {
+		columns = append(columns, joinColumn(col, ib))
+	}
+	return New(columns...)
+}
+
+// intervalHeap is a min-heap of rows ordered by their hi value
+type intervalHeap struct {
+	rows []int
+	hi   series.Series
+}
+
+func (h intervalHeap) Len() int { return len(h.rows) }
+func (h intervalHeap) Less(i, j int) bool {
+	return h.hi.Elem(h.rows[i]).Less(h.hi.Elem(h.rows[j]))
+}
+func (h intervalHeap) Swap(i, j int)       { h.rows[i], h.rows[j] = h.rows[j], h.rows[i] }
+func (h *intervalHeap) Push(x interface{}) { h.rows = append(h.rows, x.(int)) }
+func (h *intervalHeap) Pop() interface{} {
+	n := len(h.rows)
+	x := h.rows[n-1]
+	h.rows = h.rows[:n-1]
+	return x
+}
+
+// orderedRows returns the rows of s sorted by value as in Series.Order,
+// leaving out the non-valid and NaN values, which Order puts last
+func orderedRows(s series.Series) []int {
+	order := s.Order(false)
+	n := len(order)
+	for n > 0 && isMissing(s, order[n-1]) {
+		n--
+	}
+	return order[:n]
+}
+
+// isMissing tells whether the element i of s is non-valid, or NaN for the
+// numeric types
+func isMissing(s series.Series, i int) bool {
+	e := s.Elem(i)
+	if !e.IsValid() {
+		return true
+	}
+	switch s.Type() {
+	case series.Int, series.Uint, series.Float:
+		return e.IsNaN()
+	}
+	return false
+}
+
+// SemiJoin returns the rows of df with a matching row on b, compared on the
+// given keys as in Join. Unlike InnerJoin, rows are not repeated and the
+// columns of b are left out.
+func (df DataFrame) SemiJoin(b DataFrame, keys ...string) DataFrame {
+	return df.filterJoin(b, keys, true)
+}
+
+// AntiJoin returns the rows of df with no matching row on b, compared on the
+// given keys as in Join. Rows with a NaN key are kept, as they never match.
+func (df DataFrame) AntiJoin(b DataFrame, keys ...string) DataFrame {
+	return df.filterJoin(b, keys, false)
+}
+
+// filterJoin returns the rows of df whose key tuple is found on the set of key
+// tuples of b, or the rest of them if matched is false
+func (df DataFrame) filterJoin(b DataFrame, keys []string, matched bool) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	if b.Err != nil {
+		return b
+	}
+	iKeysA, iKeysB, err := df.joinKeys(b, keys, keys)
+	if err != nil {
+		return DataFrame{Err: err}
+	}
+	codes := df.joinCodes(b, iKeysA, iKeysB)
+	set := make(map[int]bool, b.nrows)
+	for _, c := range codes[df.nrows:] {
+		if c >= 0 {
+			set[c] = true
+		}
+	}
+	rows := []int{}
+	for i, c := range codes[:df.nrows] {
+		if (c >= 0 && set[c]) == matched {
+			rows = append(rows, i)
+		}
+	}
+	return df.Subset(rows)
+}
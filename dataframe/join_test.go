@@ -0,0 +1,205 @@
+package dataframe
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+func TestDataFrame_AsofJoin(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2021, 6, 1, 0, 0, sec, 0, time.UTC) }
+	readings := New(
+		series.New([]time.Time{at(1), at(5), at(10), at(10), at(20)}, series.Time, "time"),
+		series.New([]string{"s1", "s1", "s1", "s2", "s2"}, series.String, "sensor"),
+		series.New([]float64{1.5, 2.5, 3.5, 4.5, 5.5}, series.Float, "value"),
+	)
+	events := New(
+		series.New([]interface{}{at(9), at(2), at(4), at(12), nil}, series.Time, "time"),
+		series.New([]string{"s1", "s1", "s1", "s2", "s1"}, series.String, "sensor"),
+		series.New([]string{"c", "a", "b", "d", "e"}, series.String, "event"),
+	)
+	table := []struct {
+		df       DataFrame
+		expected []string
+	}{
+		{
+			readings.AsofJoin(events, "time", []string{"sensor"}, Asof_BACKWARD, -1),
+			[]string{"NaN", "b", "c", "NaN", "d"},
+		},
+		{
+			readings.AsofJoin(events, "time", []string{"sensor"}, Asof_FORWARD, -1),
+			[]string{"a", "c", "NaN", "d", "NaN"},
+		},
+		{
+			readings.AsofJoin(events, "time", []string{"sensor"}, Asof_NEAREST, -1),
+			[]string{"a", "b", "c", "d", "d"},
+		},
+		{
+			readings.AsofJoin(events, "time", []string{"sensor"}, Asof_NEAREST, float64(time.Second)),
+			[]string{"a", "b", "c", "NaN", "NaN"},
+		},
+		{
+			readings.AsofJoin(events, "time", nil, Asof_BACKWARD, -1),
+			[]string{"NaN", "b", "c", "c", "d"},
+		},
+	}
+	for i, tc := range table {
+		if err := tc.df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		received, _ := tc.df.Col("event").Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, received)
+		}
+	}
+
+	// The on and by columns of b are left out
+	df := readings.AsofJoin(events, "time", []string{"sensor"}, Asof_BACKWARD, -1)
+	if expNames := []string{"time", "sensor", "value", "event"}; !reflect.DeepEqual(expNames, df.Names()) {
+		t.Errorf("Different colnames:\nA:%v\nB:%v", expNames, df.Names())
+	}
+	df = readings.AsofJoin(events, "time", nil, Asof_BACKWARD, -1)
+	if expNames := []string{"time", "sensor_0", "value", "sensor_1", "event"}; !reflect.DeepEqual(expNames, df.Names()) {
+		t.Errorf("Different colnames:\nA:%v\nB:%v", expNames, df.Names())
+	}
+
+	// Numeric keys of different types
+	a := New(series.New([]int{1, 4, 7}, series.Int, "x"))
+	b := New(
+		series.New([]float64{2.5, 0.5, 6.5}, series.Float, "x"),
+		series.New([]int{1, 2, 3}, series.Int, "y"),
+	)
+	df = a.AsofJoin(b, "x", nil, Asof_NEAREST, 1)
+	if received, _ := df.Col("y").Records(true); !reflect.DeepEqual([]string{"2", "NaN", "3"}, received) {
+		t.Errorf("Different values:\nA:%v\nB:%v", []string{"2", "NaN", "3"}, received)
+	}
+
+	errors := []DataFrame{
+		readings.AsofJoin(events, "value", nil, Asof_BACKWARD, -1),
+		readings.AsofJoin(events, "sensor", nil, Asof_BACKWARD, -1),
+		readings.AsofJoin(events, "time", []string{"event"}, Asof_BACKWARD, -1),
+		readings.AsofJoin(events, "time", nil, AsofDirection(5), -1),
+	}
+	for i, df := range errors {
+		if df.Err == nil {
+			t.Errorf("Test: %d\nExpected error, got:\n%v", i, df)
+		}
+	}
+}
+
+func TestDataFrame_RangeJoin(t *testing.T) {
+	a := New(
+		series.New([]interface{}{5, 1, nil, 12, 8}, series.Int, "x"),
+		series.New([]string{"a", "b", "c", "d", "e"}, series.String, "name"),
+	)
+	b := New(
+		series.New([]interface{}{4.0, 0.0, 6.0, 20.0}, series.Float, "lo"),
+		series.New([]interface{}{8.0, 5.0, nil, 30.0}, series.Float, "hi"),
+		series.New([]string{"w", "y", "z", "v"}, series.String, "window"),
+	)
+	table := []struct {
+		how   JoinType
+		expDf DataFrame
+	}{
+		{
+			Join_INNER,
+			LoadRecords(
+				[][]string{
+					{"x", "name", "lo", "hi", "window"},
+					{"5", "a", "4.0", "8.0", "w"},
+					{"5", "a", "0.0", "5.0", "y"},
+					{"1", "b", "0.0", "5.0", "y"},
+					{"8", "e", "4.0", "8.0", "w"},
+				},
+			),
+		},
+		{
+			Join_LEFT,
+			LoadRecords(
+				[][]string{
+					{"x", "name", "lo", "hi", "window"},
+					{"5", "a", "4.0", "8.0", "w"},
+					{"5", "a", "0.0", "5.0", "y"},
+					{"1", "b", "0.0", "5.0", "y"},
+					{"", "c", "NaN", "NaN", "NaN"},
+					{"12", "d", "NaN", "NaN", "NaN"},
+					{"8", "e", "4.0", "8.0", "w"},
+				},
+			),
+		},
+		{
+			Join_RIGHT,
+			LoadRecords(
+				[][]string{
+					{"x", "name", "lo", "hi", "window"},
+					{"5", "a", "4.0", "8.0", "w"},
+					{"8", "e", "4.0", "8.0", "w"},
+					{"5", "a", "0.0", "5.0", "y"},
+					{"1", "b", "0.0", "5.0", "y"},
+					{"NaN", "NaN", "6.0", "", "z"},
+					{"NaN", "NaN", "20.0", "30.0", "v"},
+				},
+			),
+		},
+	}
+	for i, tc := range table {
+		df := a.RangeJoin(b, "x", "lo", "hi", tc.how)
+		if err := df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if !reflect.DeepEqual(tc.expDf.Types(), df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expDf.Types(), df.Types())
+		}
+		expr, _ := tc.expDf.Records(true)
+		r, _ := df.Records(true)
+		if !reflect.DeepEqual(expr, r) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, expr, r)
+		}
+	}
+
+	if df := a.RangeJoin(b, "x", "lo", "upper", Join_INNER); df.Err == nil {
+		t.Errorf("Expected error, got:\n%v", df)
+	}
+}
+
+func TestDataFrame_SemiJoin_AntiJoin(t *testing.T) {
+	a := New(
+		series.New([]interface{}{1, 2, nil, 1, 3, "NaN"}, series.Int, "A"),
+		series.New([]string{"a", "b", "c", "d", "e", "f"}, series.String, "B"),
+	)
+	b := New(
+		series.New([]interface{}{1.0, 1.0, nil, 4.0, math.NaN()}, series.Float, "A"),
+		series.New([]string{"x", "y", "z", "w", "v"}, series.String, "C"),
+	)
+	table := []struct {
+		df       DataFrame
+		expected []string
+	}{
+		{a.SemiJoin(b, "A"), []string{"a", "c", "d"}},
+		{a.AntiJoin(b, "A"), []string{"b", "e", "f"}},
+		{a.SemiJoin(b.Subset([]int{3}), "A"), []string{}},
+		{a.AntiJoin(b.Subset([]int{3}), "A"), []string{"a", "b", "c", "d", "e", "f"}},
+	}
+	for i, tc := range table {
+		if err := tc.df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if !reflect.DeepEqual([]string{"A", "B"}, tc.df.Names()) {
+			t.Errorf("Test: %d\nDifferent colnames:\n%v", i, tc.df.Names())
+		}
+		received, _ := tc.df.Col("B").Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, received)
+		}
+	}
+
+	if df := a.SemiJoin(b, "B"); df.Err == nil {
+		t.Errorf("Expected error, got:\n%v", df)
+	}
+	if df := a.AntiJoin(b); df.Err == nil {
+		t.Errorf("Expected error, got:\n%v", df)
+	}
+}
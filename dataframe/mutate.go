@@ -0,0 +1,746 @@
+package dataframe
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+// Formula is an expression computing a Series from the columns of a DataFrame,
+// used by Mutate. Formulas are evaluated over whole columns, using the
+// arithmetic and comparisons of the series package, and can be built in Go
+// from Col and Lit:
+//
+//	Col("revenue").Sub(Col("cost")).Div(Col("revenue"))
+//
+// or parsed from a string with ParseFormula:
+//
+//	(revenue - cost) / revenue
+//
+// Single values, such as literals, are repeated over every row.
+type Formula struct {
+	op    formulaOp
+	col   string
+	value interface{}
+	expr  Expr
+	fn    string
+	args  []Formula
+}
+
+type formulaOp int
+
+const (
+	formulaEmpty formulaOp = iota
+	formulaCol
+	formulaLit
+	formulaExpr
+	formulaAdd
+	formulaSub
+	formulaMul
+	formulaDiv
+	formulaMod
+	formulaPow
+	formulaNeg
+	formulaEq
+	formulaNeq
+	formulaGt
+	formulaGtEq
+	formulaLt
+	formulaLtEq
+	formulaAnd
+	formulaOr
+	formulaNot
+	formulaCall
+)
+
+// formulaSymbols are the operators of the binary and unary formulas, as
+// written by ParseFormula
+var formulaSymbols = map[formulaOp]string{
+	formulaAdd:  "+",
+	formulaSub:  "-",
+	formulaMul:  "*",
+	formulaDiv:  "/",
+	formulaMod:  "%",
+	formulaPow:  "^",
+	formulaNeg:  "-",
+	formulaEq:   "==",
+	formulaNeq:  "!=",
+	formulaGt:   ">",
+	formulaGtEq: ">=",
+	formulaLt:   "<",
+	formulaLtEq: "<=",
+	formulaAnd:  "&&",
+	formulaOr:   "||",
+	formulaNot:  "!",
+}
+
+// formulaFuncs are the functions that can be called from a Formula, taking a
+// single Series
+var formulaFuncs = map[string]func(series.Series) (series.Series, error){
+	"abs": func(s series.Series) (series.Series, error) {
+		res := s.Abs()
+		return res, res.Err
+	},
+	"sqrt":  floatFunc(math.Sqrt),
+	"exp":   floatFunc(math.Exp),
+	"log":   floatFunc(math.Log),
+	"floor": floatFunc(math.Floor),
+	"ceil":  floatFunc(math.Ceil),
+	"round": floatFunc(math.Round),
+	"isna":  isNA(false),
+	"notna": isNA(true),
+}
+
+// Lit creates a Formula holding a single value, of the Series type matching
+// its Go type
+func Lit(value interface{}) Formula {
+	return Formula{op: formulaLit, value: value}
+}
+
+// Call creates a Formula calling the function with the given name over its
+// argument, which can be anything taken by the arithmetic methods. The
+// functions are abs, sqrt, exp, log, floor, ceil, round, isna and notna.
+func Call(fn string, arg interface{}) Formula {
+	return Formula{op: formulaCall, fn: fn, args: []Formula{toFormula(arg)}}
+}
+
+// Formula returns the column as a Formula
+func (c ColExpr) Formula() Formula {
+	return Formula{op: formulaCol, col: c.name}
+}
+
+// Add is the sum of the column and x, see Formula.Add
+func (c ColExpr) Add(x interface{}) Formula { return c.Formula().Add(x) }
+
+// Sub is the difference of the column and x, see Formula.Add
+func (c ColExpr) Sub(x interface{}) Formula { return c.Formula().Sub(x) }
+
+// Mul is the product of the column and x, see Formula.Add
+func (c ColExpr) Mul(x interface{}) Formula { return c.Formula().Mul(x) }
+
+// Div is the quotient of the column and x, see Formula.Add
+func (c ColExpr) Div(x interface{}) Formula { return c.Formula().Div(x) }
+
+// Mod is the remainder of the column divided by x, see Formula.Add
+func (c ColExpr) Mod(x interface{}) Formula { return c.Formula().Mod(x) }
+
+// Pow is the column raised to the power of x, see Formula.Add
+func (c ColExpr) Pow(x interface{}) Formula { return c.Formula().Pow(x) }
+
+// Add is the sum of f and x, as in series.Series.Add. x can be a Formula, a
+// ColExpr, an Expr or a single value.
+func (f Formula) Add(x interface{}) Formula { return f.binary(formulaAdd, x) }
+
+// Sub is the difference of f and x, see Add
+func (f Formula) Sub(x interface{}) Formula { return f.binary(formulaSub, x) }
+
+// Mul is the product of f and x, see Add
+func (f Formula) Mul(x interface{}) Formula { return f.binary(formulaMul, x) }
+
+// Div is the quotient of f and x, see Add
+func (f Formula) Div(x interface{}) Formula { return f.binary(formulaDiv, x) }
+
+// Mod is the remainder of f divided by x, see Add
+func (f Formula) Mod(x interface{}) Formula { return f.binary(formulaMod, x) }
+
+// Pow is f raised to the power of x, see Add
+func (f Formula) Pow(x interface{}) Formula { return f.binary(formulaPow, x) }
+
+// Neg is f negated
+func (f Formula) Neg() Formula { return Formula{op: formulaNeg, args: []Formula{f}} }
+
+// Eq is true where f is equal to x, see Add. Numeric values of different
+// types are compared as Float, otherwise x is converted to the type of f as
+// in series.Series.Compare.
+func (f Formula) Eq(x interface{}) Formula { return f.binary(formulaEq, x) }
+
+// Neq is true where f is not equal to x, see Eq
+func (f Formula) Neq(x interface{}) Formula { return f.binary(formulaNeq, x) }
+
+// Gt is true where f is greater than x, see Eq
+func (f Formula) Gt(x interface{}) Formula { return f.binary(formulaGt, x) }
+
+// GtEq is true where f is greater or equal than x, see Eq
+func (f Formula) GtEq(x interface{}) Formula { return f.binary(formulaGtEq, x) }
+
+// Lt is true where f is lesser than x, see Eq
+func (f Formula) Lt(x interface{}) Formula { return f.binary(formulaLt, x) }
+
+// LtEq is true where f is lesser or equal than x, see Eq
+func (f Formula) LtEq(x interface{}) Formula { return f.binary(formulaLtEq, x) }
+
+// And is true where both f and x are true. Non-valid elements count as false.
+func (f Formula) And(x interface{}) Formula { return f.binary(formulaAnd, x) }
+
+// Or is true where f or x are true, see And
+func (f Formula) Or(x interface{}) Formula { return f.binary(formulaOr, x) }
+
+// Not is true where f is false, see And
+func (f Formula) Not() Formula { return Formula{op: formulaNot, args: []Formula{f}} }
+
+func (f Formula) binary(op formulaOp, x interface{}) Formula {
+	return Formula{op: op, args: []Formula{f, toFormula(x)}}
+}
+
+// toFormula wraps the operand of a Formula method
+func toFormula(x interface{}) Formula {
+	switch v := x.(type) {
+	case Formula:
+		return v
+	case ColExpr:
+		return v.Formula()
+	case Expr:
+		return Formula{op: formulaExpr, expr: v}
+	}
+	return Lit(x)
+}
+
+// String returns a representation of the formula
+func (f Formula) String() string {
+	switch f.op {
+	case formulaCol:
+		if isFormulaIdent(f.col) {
+			return f.col
+		}
+		return "`" + f.col + "`"
+	case formulaLit:
+		if s, ok := f.value.(string); ok {
+			return strconv.Quote(s)
+		}
+		return fmt.Sprint(f.value)
+	case formulaExpr:
+		return "{" + f.expr.String() + "}"
+	case formulaNeg, formulaNot:
+		return formulaSymbols[f.op] + "(" + f.args[0].String() + ")"
+	case formulaCall:
+		return f.fn + "(" + f.args[0].String() + ")"
+	case formulaEmpty:
+		return "<empty>"
+	}
+	return "(" + f.args[0].String() + " " + formulaSymbols[f.op] + " " + f.args[1].String() + ")"
+}
+
+// Eval returns the Series computed by the formula over the DataFrame. The
+// Series holds a single element if the formula doesn't depend on any column.
+func (f Formula) Eval(df DataFrame) (series.Series, error) {
+	if df.Err != nil {
+		return series.Series{}, df.Err
+	}
+	switch f.op {
+	case formulaCol:
+		idx := findInStringSlice(f.col, df.Names())
+		if idx < 0 {
+			return series.Series{}, fmt.Errorf("can't find column name: %s", f.col)
+		}
+		return df.columns[idx], nil
+	case formulaLit:
+		if s, ok := f.value.(series.Series); ok {
+			return s, s.Err
+		}
+		s := series.New(f.value, literalType(f.value), "")
+		return s, s.Err
+	case formulaExpr:
+		mask, err := f.expr.Eval(df)
+		if err != nil {
+			return series.Series{}, err
+		}
+		return series.Bools(mask), nil
+	case formulaCall:
+		fn, ok := formulaFuncs[f.fn]
+		if !ok {
+			return series.Series{}, fmt.Errorf("unknown function %q", f.fn)
+		}
+		arg, err := f.args[0].Eval(df)
+		if err != nil {
+			return series.Series{}, err
+		}
+		return fn(arg)
+	case formulaNeg, formulaNot:
+		arg, err := f.args[0].Eval(df)
+		if err != nil {
+			return series.Series{}, err
+		}
+		if f.op == formulaNot {
+			mask, err := formulaMask(arg, "!")
+			if err != nil {
+				return series.Series{}, err
+			}
+			for i := range mask {
+				mask[i] = !mask[i]
+			}
+			return series.Bools(mask), nil
+		}
+		res := arg.Neg()
+		return res, res.Err
+	case formulaEmpty:
+		return series.Series{}, fmt.Errorf("empty formula")
+	}
+
+	a, err := f.args[0].Eval(df)
+	if err != nil {
+		return series.Series{}, err
+	}
+	b, err := f.args[1].Eval(df)
+	if err != nil {
+		return series.Series{}, err
+	}
+	if a.Len() == 1 && b.Len() != 1 {
+		a = a.Subset(make([]int, b.Len()))
+	}
+	var res series.Series
+	switch f.op {
+	case formulaAdd:
+		res = a.Add(b)
+	case formulaSub:
+		res = a.Sub(b)
+	case formulaMul:
+		res = a.Mul(b)
+	case formulaDiv:
+		res = a.Div(b)
+	case formulaMod:
+		res = a.Mod(b)
+	case formulaPow:
+		res = a.Pow(b)
+	case formulaEq, formulaNeq, formulaGt, formulaGtEq, formulaLt, formulaLtEq:
+		if isNumeric(a.Type()) && isNumeric(b.Type()) && a.Type() != b.Type() {
+			a = series.New(a, series.Float, a.Name)
+			b = series.New(b, series.Float, b.Name)
+		}
+		comparators := map[formulaOp]series.Comparator{
+			formulaEq:   series.Eq,
+			formulaNeq:  series.Neq,
+			formulaGt:   series.Greater,
+			formulaGtEq: series.GreaterEq,
+			formulaLt:   series.Less,
+			formulaLtEq: series.LessEq,
+		}
+		res = a.Compare(comparators[f.op], b)
+	case formulaAnd, formulaOr:
+		maskA, err := formulaMask(a, formulaSymbols[f.op])
+		if err != nil {
+			return series.Series{}, err
+		}
+		maskB, err := formulaMask(b, formulaSymbols[f.op])
+		if err != nil {
+			return series.Series{}, err
+		}
+		if len(maskB) == 1 && len(maskA) != 1 {
+			value := maskB[0]
+			maskB = make([]bool, len(maskA))
+			for i := range maskB {
+				maskB[i] = value
+			}
+		}
+		if len(maskA) != len(maskB) {
+			return series.Series{}, fmt.Errorf("can't apply %s: length mismatch", formulaSymbols[f.op])
+		}
+		for i := range maskA {
+			if f.op == formulaAnd {
+				maskA[i] = maskA[i] && maskB[i]
+			} else {
+				maskA[i] = maskA[i] || maskB[i]
+			}
+		}
+		return series.Bools(maskA), nil
+	}
+	return res, res.Err
+}
+
+// formulaMask returns the values of a Bool Series, with the non-valid ones set
+// to false
+func formulaMask(s series.Series, op string) ([]bool, error) {
+	if s.Type() != series.Bool {
+		return nil, fmt.Errorf("can't apply %s to a %v series", op, s.Type())
+	}
+	return s.Compare(series.Eq, true).Bool()
+}
+
+// floatFunc applies f to every valid element of a numeric Series, returning a
+// Float Series
+func floatFunc(f func(float64) float64) func(series.Series) (series.Series, error) {
+	return func(s series.Series) (series.Series, error) {
+		if !isNumeric(s.Type()) {
+			return series.Series{}, fmt.Errorf("can't apply a function to a %v series", s.Type())
+		}
+		vals, _ := s.Float(true)
+		for i, v := range vals {
+			vals[i] = f(v)
+		}
+		return series.FromSlice(vals, s.IsValid(), s.Name), nil
+	}
+}
+
+// isNA returns a function telling the non-valid and NaN elements of a Series,
+// or the others if not is true
+func isNA(not bool) func(series.Series) (series.Series, error) {
+	return func(s series.Series) (series.Series, error) {
+		valid, nan := s.IsValid(), s.IsNaN()
+		mask := make([]bool, len(valid))
+		for i := range mask {
+			mask[i] = (!valid[i] || nan[i]) != not
+		}
+		return series.Bools(mask), nil
+	}
+}
+
+func isNumeric(t series.Type) bool {
+	return t == series.Int || t == series.Uint || t == series.Float
+}
+
+// literalType returns the Series type used for a single value
+func literalType(value interface{}) series.Type {
+	switch value.(type) {
+	case int, int8, int16, int32, int64:
+		return series.Int
+	case uint, uint8, uint16, uint32, uint64:
+		return series.Uint
+	case float32, float64:
+		return series.Float
+	case bool:
+		return series.Bool
+	}
+	return series.String
+}
+
+// Mutate adds a column with the given name, or replaces it if it exists,
+// computed by expr. expr can be a Formula, a string parsed by ParseFormula, an
+// Expr giving a Bool column, a ColExpr copying a column, a Series, or a
+// single value repeated over every row.
+func (df DataFrame) Mutate(name string, expr interface{}) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	f := toFormula(expr)
+	if str, ok := expr.(string); ok {
+		var err error
+		if f, err = ParseFormula(str); err != nil {
+			return DataFrame{Err: fmt.Errorf("mutate: %v", err)}
+		}
+	}
+	s, err := f.Eval(df)
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("mutate: %v", err)}
+	}
+	if s.Len() == 1 && df.nrows != 1 {
+		s = s.Subset(make([]int, df.nrows))
+	} else {
+		s = s.Copy()
+	}
+	s.Name = name
+	return df.Update(s)
+}
+
+// ParseFormula parses a Formula from a string. Formulas are made of:
+//
+//	column names, or any name quoted in backticks: revenue, `unit cost`
+//	numbers, strings in single or double quotes, true and false
+//	arithmetic: + - * / % ^ and unary -
+//	comparisons: == != < <= > >=
+//	logical operators: && || ! (or and, or, not)
+//	function calls: abs, sqrt, exp, log, floor, ceil, round, isna, notna
+//	parentheses
+//
+// with the usual precedence, from lowest to highest: ||, &&, !, comparisons,
+// + and -, * / and %, unary -, and ^.
+func ParseFormula(str string) (Formula, error) {
+	tokens, err := tokenizeFormula(str)
+	if err != nil {
+		return Formula{}, fmt.Errorf("formula: %v", err)
+	}
+	p := formulaParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return Formula{}, fmt.Errorf("formula: %v", err)
+	}
+	return f, nil
+}
+
+type formulaToken struct {
+	kind byte // 'n' number, 's' string, 'i' identifier, 'c' quoted column, 'o' operator
+	text string
+}
+
+// tokenizeFormula splits a formula into its tokens
+func tokenizeFormula(str string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	runes := []rune(str)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				j++
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				for j < len(runes) && unicode.IsDigit(runes[j]) {
+					j++
+				}
+			}
+			tokens = append(tokens, formulaToken{'n', string(runes[i:j])})
+			i = j
+		case isFormulaIdentRune(r, true):
+			j := i
+			for j < len(runes) && isFormulaIdentRune(runes[j], false) {
+				j++
+			}
+			tokens = append(tokens, formulaToken{'i', string(runes[i:j])})
+			i = j
+		case r == '\'' || r == '"' || r == '`':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				sb.WriteRune(runes[j])
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated %c at %d", r, i)
+			}
+			kind := byte('s')
+			if r == '`' {
+				kind = 'c'
+			}
+			tokens = append(tokens, formulaToken{kind, sb.String()})
+			i = j + 1
+		default:
+			op := ""
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "" && strings.ContainsRune("+-*/%^()<>!", r) {
+				op = string(r)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", r, i)
+			}
+			tokens = append(tokens, formulaToken{'o', op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+func isFormulaIdentRune(r rune, first bool) bool {
+	return unicode.IsLetter(r) || r == '_' || !first && (unicode.IsDigit(r) || r == '.')
+}
+
+// isFormulaIdent tells whether a column name can be written without quotes
+func isFormulaIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	switch name {
+	case "true", "false", "and", "or", "not":
+		return false
+	}
+	for i, r := range name {
+		if !isFormulaIdentRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// formulaParser is a recursive descent parser over the tokens of a formula
+type formulaParser struct {
+	tokens []formulaToken
+	pos    int
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords
+func (p *formulaParser) accept(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	t := p.tokens[p.pos]
+	if t.kind != 'o' && t.kind != 'i' {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *formulaParser) parseOr() (Formula, error) {
+	f, err := p.parseAnd()
+	for err == nil {
+		if _, ok := p.accept("||", "or"); !ok {
+			break
+		}
+		var g Formula
+		if g, err = p.parseAnd(); err == nil {
+			f = f.Or(g)
+		}
+	}
+	return f, err
+}
+
+func (p *formulaParser) parseAnd() (Formula, error) {
+	f, err := p.parseNot()
+	for err == nil {
+		if _, ok := p.accept("&&", "and"); !ok {
+			break
+		}
+		var g Formula
+		if g, err = p.parseNot(); err == nil {
+			f = f.And(g)
+		}
+	}
+	return f, err
+}
+
+func (p *formulaParser) parseNot() (Formula, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		f, err := p.parseNot()
+		return f.Not(), err
+	}
+	return p.parseComparison()
+}
+
+func (p *formulaParser) parseComparison() (Formula, error) {
+	f, err := p.parseSum()
+	if err != nil {
+		return f, err
+	}
+	ops := map[string]formulaOp{
+		"==": formulaEq, "!=": formulaNeq, ">": formulaGt, ">=": formulaGtEq, "<": formulaLt, "<=": formulaLtEq,
+	}
+	if op, ok := p.accept("==", "!=", ">", ">=", "<", "<="); ok {
+		g, err := p.parseSum()
+		return f.binary(ops[op], g), err
+	}
+	return f, nil
+}
+
+func (p *formulaParser) parseSum() (Formula, error) {
+	f, err := p.parseProduct()
+	for err == nil {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			break
+		}
+		var g Formula
+		if g, err = p.parseProduct(); err == nil {
+			if op == "+" {
+				f = f.Add(g)
+			} else {
+				f = f.Sub(g)
+			}
+		}
+	}
+	return f, err
+}
+
+func (p *formulaParser) parseProduct() (Formula, error) {
+	ops := map[string]formulaOp{"*": formulaMul, "/": formulaDiv, "%": formulaMod}
+	f, err := p.parseUnary()
+	for err == nil {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			break
+		}
+		var g Formula
+		if g, err = p.parseUnary(); err == nil {
+			f = f.binary(ops[op], g)
+		}
+	}
+	return f, err
+}
+
+func (p *formulaParser) parseUnary() (Formula, error) {
+	if _, ok := p.accept("-"); ok {
+		f, err := p.parseUnary()
+		return f.Neg(), err
+	}
+	return p.parsePower()
+}
+
+func (p *formulaParser) parsePower() (Formula, error) {
+	f, err := p.parsePrimary()
+	if err != nil {
+		return f, err
+	}
+	if _, ok := p.accept("^"); ok {
+		g, err := p.parseUnary()
+		return f.Pow(g), err
+	}
+	return f, nil
+}
+
+func (p *formulaParser) parsePrimary() (Formula, error) {
+	if p.pos >= len(p.tokens) {
+		return Formula{}, fmt.Errorf("unexpected end of formula")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	switch t.kind {
+	case 'n':
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return Lit(int(i)), nil
+		}
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return Formula{}, fmt.Errorf("invalid number %q", t.text)
+		}
+		return Lit(v), nil
+	case 's':
+		return Lit(t.text), nil
+	case 'c':
+		return Col(t.text).Formula(), nil
+	case 'i':
+		switch t.text {
+		case "true", "false":
+			return Lit(t.text == "true"), nil
+		}
+		if _, ok := p.accept("("); ok {
+			arg, err := p.parseOr()
+			if err != nil {
+				return arg, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return Formula{}, fmt.Errorf("expecting ) after the argument of %s", t.text)
+			}
+			if _, ok := formulaFuncs[t.text]; !ok {
+				return Formula{}, fmt.Errorf("unknown function %q", t.text)
+			}
+			return Call(t.text, arg), nil
+		}
+		return Col(t.text).Formula(), nil
+	case 'o':
+		if t.text == "(" {
+			f, err := p.parseOr()
+			if err != nil {
+				return f, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return Formula{}, fmt.Errorf("expecting )")
+			}
+			return f, nil
+		}
+	}
+	return Formula{}, fmt.Errorf("unexpected %q", t.text)
+}
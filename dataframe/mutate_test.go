@@ -0,0 +1,107 @@
+package dataframe
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+func TestDataFrame_Mutate(t *testing.T) {
+	df := New(
+		series.New([]string{"a", "b", "c", "d"}, series.String, "name"),
+		series.New([]interface{}{10, 20, nil, 40}, series.Int, "revenue"),
+		series.New([]float64{2.5, 25, 1, 10}, series.Float, "unit cost"),
+		series.New([]int{1, 2, 3, 4}, series.Int, "qty"),
+	)
+	table := []struct {
+		expr     interface{}
+		expType  series.Type
+		expected []string
+	}{
+		{"revenue - qty", series.Int, []string{"9", "18", "", "36"}},
+		{"revenue - `unit cost` * qty", series.Float, []string{"7.500000", "-30.000000", "", "0.000000"}},
+		{"-qty ^ 2 + 1", series.Float, []string{"0.000000", "-3.000000", "-8.000000", "-15.000000"}},
+		{"(revenue + 2) % 4", series.Int, []string{"0", "2", "", "2"}},
+		{"revenue / qty", series.Float, []string{"10.000000", "10.000000", "", "10.000000"}},
+		{"revenue > 15 && name != 'd'", series.Bool, []string{"false", "true", "false", "false"}},
+		{"not (qty <= 2) or name == \"a\"", series.Bool, []string{"true", "false", "true", "true"}},
+		{"`unit cost` > qty", series.Bool, []string{"true", "true", "false", "true"}},
+		{"sqrt(qty * 4)", series.Float, []string{"2.000000", "2.828427", "3.464102", "4.000000"}},
+		{"abs(qty - 3)", series.Int, []string{"2", "1", "0", "1"}},
+		{"isna(revenue)", series.Bool, []string{"false", "false", "true", "false"}},
+		{"1.5", series.Float, []string{"1.500000", "1.500000", "1.500000", "1.500000"}},
+		{"2 * 3 - qty", series.Int, []string{"5", "4", "3", "2"}},
+		{Col("revenue").Sub(Col("qty")).Mul(2), series.Int, []string{"18", "36", "", "72"}},
+		{Col("qty").Formula().Gt(2).Or(Col("name").Eq("a")), series.Bool, []string{"true", "false", "true", "true"}},
+		{Col("qty").Gt(2), series.Bool, []string{"false", "false", "true", "true"}},
+		{Call("round", Col("unit cost").Div(3)), series.Float, []string{"1.000000", "8.000000", "0.000000", "3.000000"}},
+		{Col("name"), series.String, []string{"a", "b", "c", "d"}},
+		{Lit("x"), series.String, []string{"x", "x", "x", "x"}},
+		{7, series.Int, []string{"7", "7", "7", "7"}},
+		{series.New([]bool{true, false, true, false}, series.Bool, "other"), series.Bool, []string{"true", "false", "true", "false"}},
+	}
+	for i, tc := range table {
+		received := df.Mutate("new", tc.expr)
+		if err := received.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if expNames := []string{"name", "revenue", "unit cost", "qty", "new"}; !reflect.DeepEqual(expNames, received.Names()) {
+			t.Errorf("Test: %d\nDifferent colnames:\nA:%v\nB:%v", i, expNames, received.Names())
+		}
+		col := received.Col("new")
+		if col.Type() != tc.expType {
+			t.Errorf("Test: %d\nExpected type %v, received %v", i, tc.expType, col.Type())
+		}
+		values, _ := col.Records(true)
+		if !reflect.DeepEqual(tc.expected, values) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, values)
+		}
+	}
+
+	// Existing columns are replaced, and the original DataFrame is unchanged
+	received := df.Mutate("qty", "qty * 10")
+	if values, _ := received.Col("qty").Records(true); !reflect.DeepEqual([]string{"10", "20", "30", "40"}, values) {
+		t.Errorf("Different values:\n%v", values)
+	}
+	if received.Ncol() != 4 {
+		t.Errorf("Expected 4 columns, received %d", received.Ncol())
+	}
+	if values, _ := df.Col("qty").Records(true); !reflect.DeepEqual([]string{"1", "2", "3", "4"}, values) {
+		t.Errorf("Expected the DataFrame to be unchanged, received:\n%v", values)
+	}
+
+	// Formulas print back in a form ParseFormula reads
+	for _, str := range []string{"(`unit cost` * -(qty)) >= 2.5", "!(isna(revenue)) || name == 'a'"} {
+		f, err := ParseFormula(str)
+		if err != nil {
+			t.Fatalf("Error parsing %q: %v", str, err)
+		}
+		g, err := ParseFormula(f.String())
+		if err != nil {
+			t.Fatalf("Error parsing %q: %v", f.String(), err)
+		}
+		if f.String() != g.String() {
+			t.Errorf("Different formulas:\nA:%v\nB:%v", f, g)
+		}
+	}
+
+	errors := []interface{}{
+		"revenue +",
+		"revenue + (qty",
+		"missing * 2",
+		"name * 2",
+		"qty && true",
+		"foo(qty)",
+		"qty # 2",
+		"'abc",
+		"qty < 2 < 3",
+		Call("foo", Col("qty")),
+		Formula{},
+	}
+	for i, expr := range errors {
+		if received := df.Mutate("new", expr); received.Err == nil {
+			t.Errorf("Test: %d\nExpected error, got:\n%v", i, received)
+		}
+	}
+}
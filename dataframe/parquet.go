@@ -0,0 +1,1064 @@
+// Paradigm4
+
+package dataframe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+// ParquetCodec is the compression codec of the pages of a Parquet file
+type ParquetCodec int
+
+const (
+	Parquet_UNCOMPRESSED ParquetCodec = 0
+	Parquet_SNAPPY       ParquetCodec = 1
+	Parquet_GZIP         ParquetCodec = 2
+	Parquet_ZSTD         ParquetCodec = 6
+	Parquet_LZ4_RAW      ParquetCodec = 7
+)
+
+func (codec ParquetCodec) String() string {
+	switch codec {
+	case Parquet_UNCOMPRESSED:
+		return "UNCOMPRESSED"
+	case Parquet_SNAPPY:
+		return "SNAPPY"
+	case Parquet_GZIP:
+		return "GZIP"
+	case Parquet_ZSTD:
+		return "ZSTD"
+	case Parquet_LZ4_RAW:
+		return "LZ4_RAW"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// defaultParquetRowGroupRows is the default maximum number of rows of the row
+// groups written by WriteParquet
+const defaultParquetRowGroupRows = 1 << 20
+
+// parquetPageRows is the maximum number of rows of the pages written by
+// WriteParquet
+const parquetPageRows = 1 << 16
+
+// parquetMaxExpansion bounds the ratio of the uncompressed and compressed
+// sizes of a page, well above what the codecs reach on constant data, so that
+// a corrupted page header can't cause huge allocations
+const parquetMaxExpansion = 1 << 15
+
+// WithColumns sets the columns read by ReadParquet, in the given order. By
+// default all the columns are read.
+func WithColumns(names ...string) LoadOption {
+	return func(c *loadOptions) {
+		c.columns = names
+	}
+}
+
+// WithRowGroups sets the indexes of the row groups read by ReadParquet, in the
+// given order. By default all the row groups are read.
+func WithRowGroups(groups ...int) LoadOption {
+	return func(c *loadOptions) {
+		c.rowGroups = groups
+	}
+}
+
+// WriteCompression sets the compression codec used by WriteParquet, which
+// defaults to Parquet_SNAPPY.
+func WriteCompression(codec ParquetCodec) WriteOption {
+	return func(c *writeOptions) {
+		c.codec = codec
+	}
+}
+
+// WriteRowGroupRows sets the maximum number of rows of each row group written
+// by WriteParquet, which defaults to 1048576.
+func WriteRowGroupRows(n int) WriteOption {
+	return func(c *writeOptions) {
+		c.rowGroupRows = n
+	}
+}
+
+// ReadParquet reads a DataFrame from a Parquet file. r must either have a Size
+// method, as bytes.Reader and io.SectionReader do, or be an io.Seeker, as
+// os.File is.
+//
+// The columns are converted through the Arrow types returned by
+// ReadParquetSchema, as in TableToDataframe: integers of any width give Int
+// or Uint columns, FLOAT and DOUBLE give Float, strings give String, and
+// dates and timestamps (including the INT96 timestamps of Spark) give Time.
+// Decimals give Float, and byte arrays that aren't strings give String. Only
+// flat columns are supported: nested columns can be left out with
+// WithColumns. Each row group is read as a chunk of the Arrow columns.
+func ReadParquet(r io.ReaderAt, options ...LoadOption) DataFrame {
+	cfg := loadOptions{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	pf, err := openParquet(r)
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("[ReadParquet] %v", err)}
+	}
+	tbl, err := pf.table(cfg.columns, cfg.rowGroups)
+	if err != nil {
+		return DataFrame{Err: fmt.Errorf("[ReadParquet] %v", err)}
+	}
+	defer tbl.Release()
+	return TableToDataframe(tbl)
+}
+
+// ReadParquetSchema returns the Arrow schema of the columns of a Parquet file,
+// which ReadParquet converts to Series. Passing it to WriteParquet with
+// WriteSchema keeps the types that the Series don't, such as the width of
+// integers.
+func ReadParquetSchema(r io.ReaderAt) (*arrow.Schema, error) {
+	pf, err := openParquet(r)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadParquetSchema] %v", err)
+	}
+	cols, err := pf.selectColumns(nil)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadParquetSchema] %v", err)
+	}
+	fields := make([]arrow.Field, len(cols))
+	for i, col := range cols {
+		fields[i] = col.field
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// WriteParquet writes the DataFrame to w as a Parquet file.
+//
+// The schema is inferred from the column types as in WriteArrowIPC, except for
+// Time columns which are written as microsecond timestamps, the finest unit
+// Spark reads. Another schema can be given with WriteSchema, to write
+// integers of other widths or nanosecond timestamps for example. Arrow types
+// are written with the matching Parquet logical types, FLOAT16 being widened
+// to FLOAT and DATE64 written as DATE. The time zones of timestamps are not
+// kept: timestamps with a time zone are written as adjusted to UTC.
+//
+// The rows are split into row groups of WriteRowGroupRows rows, and the
+// values of the columns are PLAIN encoded and compressed with the codec set
+// by WriteCompression.
+func (df DataFrame) WriteParquet(w io.Writer, options ...WriteOption) error {
+	if df.Err != nil {
+		return fmt.Errorf("[WriteParquet] %v", df.Err)
+	}
+	cfg := writeOptions{
+		codec:        Parquet_SNAPPY,
+		rowGroupRows: defaultParquetRowGroupRows,
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.rowGroupRows <= 0 {
+		return fmt.Errorf("[WriteParquet] invalid row group size %d", cfg.rowGroupRows)
+	}
+	if _, err := compress(cfg.codec, nil); err != nil {
+		return fmt.Errorf("[WriteParquet] %v", err)
+	}
+	schema := cfg.schema
+	if schema == nil {
+		var err error
+		if schema, err = inferParquetSchema(df); err != nil {
+			return fmt.Errorf("[WriteParquet] %v", err)
+		}
+	}
+	elements, err := parquetSchema(schema)
+	if err != nil {
+		return fmt.Errorf("[WriteParquet] %v", err)
+	}
+
+	pw := &parquetWriter{w: w, columns: elements[1:], codec: cfg.codec}
+	if err := pw.write([]byte("PAR1")); err != nil {
+		return fmt.Errorf("[WriteParquet] %v", err)
+	}
+	if err := df.writeRecords(pw, schema, cfg.rowGroupRows); err != nil {
+		return fmt.Errorf("[WriteParquet] %v", err)
+	}
+	meta := parquetFileMetaData{
+		version:   1,
+		schema:    elements,
+		numRows:   int64(df.nrows),
+		rowGroups: pw.rowGroups,
+		createdBy: "github.com/Paradigm4/gota",
+	}
+	tw := thriftWriter{}
+	meta.write(&tw)
+	footer := appendUint32(tw.buf, uint32(len(tw.buf)))
+	footer = append(footer, "PAR1"...)
+	if err := pw.write(footer); err != nil {
+		return fmt.Errorf("[WriteParquet] %v", err)
+	}
+	return nil
+}
+
+// inferParquetSchema returns the schema inferred by DataframeSchema, with
+// microsecond timestamps
+func inferParquetSchema(df DataFrame) (*arrow.Schema, error) {
+	schema, err := DataframeSchema(df)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]arrow.Field, len(schema.Fields()))
+	for i, f := range schema.Fields() {
+		if f.Type.ID() == arrow.TIMESTAMP {
+			f.Type = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		}
+		fields[i] = f
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// parquetSchema returns the Parquet schema elements matching an Arrow schema:
+// the root followed by one element for each field
+func parquetSchema(schema *arrow.Schema) ([]parquetSchemaElement, error) {
+	if len(schema.Fields()) == 0 {
+		return nil, fmt.Errorf("no fields/columns given")
+	}
+	elements := []parquetSchemaElement{{
+		typ:           -1,
+		repetition:    -1,
+		convertedType: -1,
+		name:          "schema",
+		numChildren:   int32(len(schema.Fields())),
+	}}
+	for _, f := range schema.Fields() {
+		e := parquetSchemaElement{name: f.Name, convertedType: -1}
+		if f.Nullable {
+			e.repetition = repetitionOptional
+		}
+		integer := func(typ int32, width int8, signed bool, converted int32) {
+			e.typ = typ
+			e.convertedType = converted
+			e.logicalType = &parquetLogicalType{kind: logicalInteger, bitWidth: width, signed: signed}
+		}
+		switch f.Type.ID() {
+		case arrow.BOOL:
+			e.typ = parquetBoolean
+		case arrow.INT8:
+			integer(parquetInt32, 8, true, convertedInt8)
+		case arrow.INT16:
+			integer(parquetInt32, 16, true, convertedInt16)
+		case arrow.INT32:
+			e.typ = parquetInt32
+		case arrow.INT64:
+			e.typ = parquetInt64
+		case arrow.UINT8:
+			integer(parquetInt32, 8, false, convertedUint8)
+		case arrow.UINT16:
+			integer(parquetInt32, 16, false, convertedUint16)
+		case arrow.UINT32:
+			integer(parquetInt32, 32, false, convertedUint32)
+		case arrow.UINT64:
+			integer(parquetInt64, 64, false, convertedUint64)
+		case arrow.FLOAT16, arrow.FLOAT32:
+			e.typ = parquetFloat
+		case arrow.FLOAT64:
+			e.typ = parquetDouble
+		case arrow.STRING:
+			e.typ = parquetByteArray
+			e.convertedType = convertedUTF8
+			e.logicalType = &parquetLogicalType{kind: logicalString}
+		case arrow.TIMESTAMP:
+			dt := f.Type.(*arrow.TimestampType)
+			lt := &parquetLogicalType{kind: logicalTimestamp, utc: dt.TimeZone != ""}
+			switch dt.Unit {
+			case arrow.Second, arrow.Millisecond:
+				lt.unit = unitMillis
+				if lt.utc {
+					e.convertedType = convertedTimestampMillis
+				}
+			case arrow.Microsecond:
+				lt.unit = unitMicros
+				if lt.utc {
+					e.convertedType = convertedTimestampMicros
+				}
+			default:
+				lt.unit = unitNanos
+			}
+			e.typ = parquetInt64
+			e.logicalType = lt
+		case arrow.DATE32, arrow.DATE64:
+			e.typ = parquetInt32
+			e.convertedType = convertedDate
+			e.logicalType = &parquetLogicalType{kind: logicalDate}
+		default:
+			return nil, fmt.Errorf("no Parquet type for column %q of Arrow type %v", f.Name, f.Type)
+		}
+		elements = append(elements, e)
+	}
+	return elements, nil
+}
+
+// parquetWriter writes the row groups of a Parquet file, keeping track of
+// their offsets in the file
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []parquetSchemaElement
+	codec     ParquetCodec
+	rowGroups []parquetRowGroup
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// Write writes a record as a row group
+func (pw *parquetWriter) Write(rec array.Record) error {
+	rg := parquetRowGroup{numRows: rec.NumRows()}
+	for i, e := range pw.columns {
+		chunk, err := pw.writeColumn(e, rec.Column(i))
+		if err != nil {
+			return fmt.Errorf("column %q: %v", e.name, err)
+		}
+		rg.columns = append(rg.columns, chunk)
+		rg.totalByteSize += chunk.meta.totalUncompressedSize
+	}
+	pw.rowGroups = append(pw.rowGroups, rg)
+	return nil
+}
+
+// writeColumn writes an array as a column chunk of data pages
+func (pw *parquetWriter) writeColumn(e parquetSchemaElement, arr array.Interface) (parquetColumnChunk, error) {
+	if e.repetition == repetitionRequired && arr.NullN() > 0 {
+		return parquetColumnChunk{}, fmt.Errorf("missing values in a field that is not nullable")
+	}
+	start := pw.offset
+	size := int64(0)
+	for lo := 0; lo < arr.Len(); lo += parquetPageRows {
+		hi := lo + parquetPageRows
+		if hi > arr.Len() {
+			hi = arr.Len()
+		}
+		var page []byte
+		if e.repetition == repetitionOptional {
+			levels := make([]int32, hi-lo)
+			for i := range levels {
+				if arr.IsValid(lo + i) {
+					levels[i] = 1
+				}
+			}
+			encoded := appendHybrid(nil, levels, 1)
+			page = appendUint32(page, uint32(len(encoded)))
+			page = append(page, encoded...)
+		}
+		page = appendPlain(page, arr, lo, hi)
+		data, err := compress(pw.codec, page)
+		if err != nil {
+			return parquetColumnChunk{}, err
+		}
+		h := parquetPageHeader{
+			typ:              pageData,
+			uncompressedSize: int32(len(page)),
+			compressedSize:   int32(len(data)),
+			numValues:        int32(hi - lo),
+			encoding:         encodingPlain,
+		}
+		tw := thriftWriter{}
+		h.write(&tw)
+		if err := pw.write(tw.buf); err != nil {
+			return parquetColumnChunk{}, err
+		}
+		if err := pw.write(data); err != nil {
+			return parquetColumnChunk{}, err
+		}
+		size += int64(len(tw.buf) + len(page))
+	}
+	return parquetColumnChunk{
+		fileOffset: start,
+		meta: parquetColumnMetaData{
+			typ:                   e.typ,
+			encodings:             []int32{encodingPlain, encodingRLE},
+			path:                  []string{e.name},
+			codec:                 int32(pw.codec),
+			numValues:             int64(arr.Len()),
+			totalUncompressedSize: size,
+			totalCompressedSize:   pw.offset - start,
+			dataPageOffset:        start,
+		},
+	}, nil
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(v)), uint32(v>>32))
+}
+
+// appendPlain appends the valid values of the rows lo to hi of an array, PLAIN
+// encoded with the physical type given by parquetSchema
+func appendPlain(buf []byte, arr array.Interface, lo, hi int) []byte {
+	switch a := arr.(type) {
+	case *array.Boolean:
+		packed := make([]byte, (hi-lo+7)/8)
+		n := 0
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				if a.Value(i) {
+					packed[n/8] |= 1 << (n % 8)
+				}
+				n++
+			}
+		}
+		return append(buf, packed[:(n+7)/8]...)
+	case *array.Int8:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, uint32(a.Value(i)))
+			}
+		}
+	case *array.Int16:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, uint32(a.Value(i)))
+			}
+		}
+	case *array.Int32:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, uint32(a.Value(i)))
+			}
+		}
+	case *array.Int64:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint64(buf, uint64(a.Value(i)))
+			}
+		}
+	case *array.Uint8:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, uint32(a.Value(i)))
+			}
+		}
+	case *array.Uint16:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, uint32(a.Value(i)))
+			}
+		}
+	case *array.Uint32:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, a.Value(i))
+			}
+		}
+	case *array.Uint64:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint64(buf, a.Value(i))
+			}
+		}
+	case *array.Float16:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, math.Float32bits(a.Value(i).Float32()))
+			}
+		}
+	case *array.Float32:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, math.Float32bits(a.Value(i)))
+			}
+		}
+	case *array.Float64:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint64(buf, math.Float64bits(a.Value(i)))
+			}
+		}
+	case *array.String:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				v := a.Value(i)
+				buf = appendUint32(buf, uint32(len(v)))
+				buf = append(buf, v...)
+			}
+		}
+	case *array.Timestamp:
+		scale := int64(1)
+		if a.DataType().(*arrow.TimestampType).Unit == arrow.Second {
+			scale = 1000
+		}
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint64(buf, uint64(int64(a.Value(i))*scale))
+			}
+		}
+	case *array.Date32:
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				buf = appendUint32(buf, uint32(a.Value(i)))
+			}
+		}
+	case *array.Date64:
+		const millisPerDay = secondsPerDay * 1000
+		for i := lo; i < hi; i++ {
+			if a.IsValid(i) {
+				ms := int64(a.Value(i))
+				days := ms / millisPerDay
+				if ms < 0 && ms%millisPerDay != 0 {
+					days--
+				}
+				buf = appendUint32(buf, uint32(days))
+			}
+		}
+	}
+	return buf
+}
+
+// parquetFile is a Parquet file whose metadata has been read
+type parquetFile struct {
+	r       io.ReaderAt
+	size    int64
+	meta    parquetFileMetaData
+	columns []parquetColumn
+}
+
+// parquetColumn is a top level column of a Parquet file
+type parquetColumn struct {
+	element parquetSchemaElement
+	// The index of the column chunks of the column in the row groups
+	index int
+	field arrow.Field
+	// Set if the column can't be read.
+	err error
+}
+
+// readerSize returns the size of r, which must have a Size method or be an
+// io.Seeker
+func readerSize(r io.ReaderAt) (int64, error) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), nil
+	case io.Seeker:
+		return r.Seek(0, io.SeekEnd)
+	}
+	return 0, fmt.Errorf("can't find the size of a %T", r)
+}
+
+// openParquet reads the metadata of a Parquet file, stored at its end
+// followed by its length and the PAR1 magic number
+func openParquet(r io.ReaderAt) (*parquetFile, error) {
+	size, err := readerSize(r)
+	if err != nil {
+		return nil, err
+	}
+	tail := make([]byte, 8)
+	if size < 12 {
+		return nil, fmt.Errorf("not a Parquet file")
+	}
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	if string(tail[4:]) != "PAR1" {
+		return nil, fmt.Errorf("not a Parquet file")
+	}
+	n := int64(binary.LittleEndian.Uint32(tail))
+	if n > size-12 {
+		return nil, fmt.Errorf("invalid metadata length %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, size-8-n); err != nil {
+		return nil, err
+	}
+	pf := &parquetFile{r: r, size: size}
+	tr := thriftReader{buf: buf}
+	pf.meta.read(&tr)
+	if tr.err != nil {
+		return nil, fmt.Errorf("invalid metadata: %v", tr.err)
+	}
+
+	schema := pf.meta.schema
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("invalid metadata: no schema")
+	}
+	// The schema is a tree stored depth first, whose leaves are the columns
+	// of the row groups.
+	var leaves func(pos int) (int, int, error)
+	leaves = func(pos int) (int, int, error) {
+		if pos >= len(schema) {
+			return 0, 0, fmt.Errorf("invalid metadata: truncated schema")
+		}
+		if schema[pos].numChildren <= 0 {
+			return 1, pos + 1, nil
+		}
+		n, next := 0, pos+1
+		for i := 0; i < int(schema[pos].numChildren); i++ {
+			m, end, err := leaves(next)
+			if err != nil {
+				return 0, 0, err
+			}
+			n, next = n+m, end
+		}
+		return n, next, nil
+	}
+	pos, index := 1, 0
+	for i := 0; i < int(schema[0].numChildren); i++ {
+		n, next, err := leaves(pos)
+		if err != nil {
+			return nil, err
+		}
+		col := parquetColumn{element: schema[pos], index: index}
+		if col.element.numChildren > 0 || col.element.repetition == repetitionRepeated {
+			col.err = fmt.Errorf("column %q: nested columns are not supported", col.element.name)
+		} else {
+			col.field, col.err = parquetArrowField(col.element)
+		}
+		pf.columns = append(pf.columns, col)
+		pos, index = next, index+n
+	}
+	for i, rg := range pf.meta.rowGroups {
+		if len(rg.columns) != index {
+			return nil, fmt.Errorf("invalid metadata: row group %d has %d columns instead of %d", i, len(rg.columns), index)
+		}
+	}
+	return pf, nil
+}
+
+// parquetArrowField returns the Arrow field of a column
+func parquetArrowField(e parquetSchemaElement) (arrow.Field, error) {
+	f := arrow.Field{Name: e.name, Nullable: e.repetition == repetitionOptional}
+	lt, converted := e.logicalType, e.convertedType
+	if lt == nil {
+		lt = &parquetLogicalType{}
+	}
+	unsupported := fmt.Errorf("column %q: unsupported Parquet type %d with logical type %d and converted type %d", e.name, e.typ, lt.kind, converted)
+	if e.typ == parquetFixedLenByteArray && e.typeLength <= 0 {
+		return f, fmt.Errorf("column %q: invalid FIXED_LEN_BYTE_ARRAY length %d", e.name, e.typeLength)
+	}
+
+	// Decimals are approximated by a float64.
+	if lt.kind == logicalDecimal || converted == convertedDecimal {
+		switch e.typ {
+		case parquetInt32, parquetInt64, parquetByteArray, parquetFixedLenByteArray:
+			f.Type = arrow.PrimitiveTypes.Float64
+			return f, nil
+		}
+		return f, unsupported
+	}
+
+	switch e.typ {
+	case parquetBoolean:
+		f.Type = arrow.FixedWidthTypes.Boolean
+	case parquetInt32, parquetInt64:
+		width, signed := 32, true
+		if e.typ == parquetInt64 {
+			width = 64
+		}
+		switch {
+		case lt.kind == logicalInteger:
+			width, signed = int(lt.bitWidth), lt.signed
+		case lt.kind == logicalDate || converted == convertedDate:
+			f.Type = arrow.FixedWidthTypes.Date32
+		case lt.kind == logicalTimestamp:
+			dt := &arrow.TimestampType{Unit: arrow.Nanosecond}
+			switch lt.unit {
+			case unitMillis:
+				dt.Unit = arrow.Millisecond
+			case unitMicros:
+				dt.Unit = arrow.Microsecond
+			}
+			if lt.utc {
+				dt.TimeZone = "UTC"
+			}
+			f.Type = dt
+		case converted == convertedTimestampMillis:
+			f.Type = &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}
+		case converted == convertedTimestampMicros:
+			f.Type = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		case converted >= convertedUint8 && converted <= convertedUint64:
+			width, signed = 8<<(converted-convertedUint8), false
+		case converted >= convertedInt8 && converted <= convertedInt64:
+			width = 8 << (converted - convertedInt8)
+		case lt.kind != 0 || converted >= 0:
+			return f, unsupported
+		}
+		if f.Type != nil {
+			if e.typ == parquetInt64 && f.Type.ID() == arrow.DATE32 {
+				return f, unsupported
+			}
+			return f, nil
+		}
+		switch {
+		case width == 8 && signed:
+			f.Type = arrow.PrimitiveTypes.Int8
+		case width == 16 && signed:
+			f.Type = arrow.PrimitiveTypes.Int16
+		case width == 32 && signed:
+			f.Type = arrow.PrimitiveTypes.Int32
+		case width == 64 && signed:
+			f.Type = arrow.PrimitiveTypes.Int64
+		case width == 8:
+			f.Type = arrow.PrimitiveTypes.Uint8
+		case width == 16:
+			f.Type = arrow.PrimitiveTypes.Uint16
+		case width == 32:
+			f.Type = arrow.PrimitiveTypes.Uint32
+		case width == 64:
+			f.Type = arrow.PrimitiveTypes.Uint64
+		default:
+			return f, unsupported
+		}
+	case parquetInt96:
+		f.Type = &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}
+	case parquetFloat:
+		f.Type = arrow.PrimitiveTypes.Float32
+	case parquetDouble:
+		f.Type = arrow.PrimitiveTypes.Float64
+	case parquetByteArray, parquetFixedLenByteArray:
+		// Strings, and byte arrays without a native Series type
+		f.Type = arrow.BinaryTypes.String
+	default:
+		return f, unsupported
+	}
+	return f, nil
+}
+
+// selectColumns returns the named columns, or all of them
+func (pf *parquetFile) selectColumns(names []string) ([]*parquetColumn, error) {
+	var cols []*parquetColumn
+	if names == nil {
+		for i := range pf.columns {
+			cols = append(cols, &pf.columns[i])
+		}
+	}
+	for _, name := range names {
+		var col *parquetColumn
+		for i := range pf.columns {
+			if pf.columns[i].element.name == name {
+				col = &pf.columns[i]
+				break
+			}
+		}
+		if col == nil {
+			return nil, fmt.Errorf("can't find column %q", name)
+		}
+		cols = append(cols, col)
+	}
+	for _, col := range cols {
+		if col.err != nil {
+			return nil, col.err
+		}
+	}
+	return cols, nil
+}
+
+// table reads the named columns of the given row groups (or all of them) into
+// a table with a chunk for each row group. The caller must release it.
+func (pf *parquetFile) table(names []string, groups []int) (array.Table, error) {
+	cols, err := pf.selectColumns(names)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		groups = make([]int, len(pf.meta.rowGroups))
+		for i := range groups {
+			groups[i] = i
+		}
+	}
+	rows := int64(0)
+	for _, g := range groups {
+		if g < 0 || g >= len(pf.meta.rowGroups) {
+			return nil, fmt.Errorf("row group %d out of range [0, %d)", g, len(pf.meta.rowGroups))
+		}
+		rows += pf.meta.rowGroups[g].numRows
+	}
+
+	mem := memory.NewGoAllocator()
+	fields := make([]arrow.Field, len(cols))
+	columns := make([]array.Column, 0, len(cols))
+	defer func() {
+		for i := range columns {
+			columns[i].Release()
+		}
+	}()
+	for i, col := range cols {
+		fields[i] = col.field
+		chunked, err := pf.readColumn(col, groups, mem)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %v", col.element.name, err)
+		}
+		columns = append(columns, *array.NewColumn(col.field, chunked))
+		chunked.Release()
+	}
+	return array.NewTable(arrow.NewSchema(fields, nil), columns, rows), nil
+}
+
+// readColumn reads the column chunks of a column in the given row groups
+func (pf *parquetFile) readColumn(col *parquetColumn, groups []int, mem memory.Allocator) (*array.Chunked, error) {
+	chunks := make([]array.Interface, 0, len(groups))
+	defer func() {
+		for _, c := range chunks {
+			c.Release()
+		}
+	}()
+	for _, g := range groups {
+		rg := pf.meta.rowGroups[g]
+		b := array.NewBuilder(mem, col.field.Type)
+		err := col.readChunk(pf, rg.columns[col.index], b)
+		arr := b.NewArray()
+		b.Release()
+		chunks = append(chunks, arr)
+		if err != nil {
+			return nil, err
+		}
+		if int64(arr.Len()) != rg.numRows {
+			return nil, fmt.Errorf("%d values in row group %d of %d rows", arr.Len(), g, rg.numRows)
+		}
+	}
+	return array.NewChunked(col.field.Type, chunks), nil
+}
+
+// readChunk appends the values of a column chunk to the builder
+func (col *parquetColumn) readChunk(pf *parquetFile, chunk parquetColumnChunk, b array.Builder) error {
+	if chunk.filePath != "" {
+		return fmt.Errorf("column chunk in file %q: external files are not supported", chunk.filePath)
+	}
+	m := chunk.meta
+	start := m.dataPageOffset
+	if m.dictionaryPageOffset > 0 && m.dictionaryPageOffset < start {
+		start = m.dictionaryPageOffset
+	}
+	if start < 0 || m.totalCompressedSize < 0 || start+m.totalCompressedSize > pf.size {
+		return fmt.Errorf("column chunk out of the file")
+	}
+	buf := make([]byte, m.totalCompressedSize)
+	if _, err := pf.r.ReadAt(buf, start); err != nil {
+		return err
+	}
+	codec := ParquetCodec(m.codec)
+	optional := col.element.repetition == repetitionOptional
+	add := col.appender(b)
+	var dict *parquetValues
+	for read := int64(0); read < m.numValues; {
+		var h parquetPageHeader
+		tr := thriftReader{buf: buf}
+		h.read(&tr)
+		if tr.err != nil {
+			return fmt.Errorf("invalid page header: %v", tr.err)
+		}
+		buf = buf[tr.pos:]
+		if h.compressedSize < 0 || int(h.compressedSize) > len(buf) || h.uncompressedSize < 0 || h.numValues < 0 {
+			return fmt.Errorf("invalid page header")
+		}
+		if int64(h.uncompressedSize) > parquetMaxExpansion*(int64(h.compressedSize)+1) {
+			return fmt.Errorf("invalid page header: page of %d bytes compressed to %d", h.uncompressedSize, h.compressedSize)
+		}
+		// The values of the chunk bound those of a page, and the entries of
+		// its dictionary
+		left := m.numValues - read
+		if h.typ == pageDictionary {
+			left = m.numValues
+		}
+		if int64(h.numValues) > left {
+			return fmt.Errorf("invalid page header: %d values, %d left in the column chunk", h.numValues, left)
+		}
+		page := buf[:h.compressedSize]
+		buf = buf[h.compressedSize:]
+		n := int(h.numValues)
+
+		var data []byte
+		var defs []int32
+		var err error
+		switch h.typ {
+		case pageDictionary:
+			if data, err = decompress(codec, page, int(h.uncompressedSize)); err != nil {
+				return err
+			}
+			values, err := decodePlain(data, col.element.typ, int(col.element.typeLength), n)
+			if err != nil {
+				return fmt.Errorf("dictionary page: %v", err)
+			}
+			dict = &values
+			continue
+		case pageData:
+			if data, err = decompress(codec, page, int(h.uncompressedSize)); err != nil {
+				return err
+			}
+			if optional {
+				if len(data) < 4 || int64(binary.LittleEndian.Uint32(data)) > int64(len(data)-4) {
+					return fmt.Errorf("invalid definition levels")
+				}
+				l := 4 + int(binary.LittleEndian.Uint32(data))
+				if defs, err = decodeHybrid(data[4:l], 1, n); err != nil {
+					return fmt.Errorf("definition levels: %v", err)
+				}
+				data = data[l:]
+			}
+		case pageDataV2:
+			// Flat columns have no repetition levels, and the definition
+			// levels aren't compressed.
+			levels := int(h.repLength) + int(h.defLength)
+			if h.repLength < 0 || h.defLength < 0 || levels > len(page) || levels > int(h.uncompressedSize) {
+				return fmt.Errorf("invalid page header")
+			}
+			if optional {
+				if defs, err = decodeHybrid(page[h.repLength:levels], 1, n); err != nil {
+					return fmt.Errorf("definition levels: %v", err)
+				}
+			}
+			data = page[levels:]
+			if h.compressed {
+				if data, err = decompress(codec, data, int(h.uncompressedSize)-levels); err != nil {
+					return err
+				}
+			}
+		default:
+			// Index pages
+			continue
+		}
+
+		count := n
+		if defs != nil {
+			count = 0
+			for _, d := range defs {
+				if d == 1 {
+					count++
+				}
+			}
+		}
+		values, err := col.decode(data, h.encoding, count, dict)
+		if err != nil {
+			return err
+		}
+		k := 0
+		for i := 0; i < n; i++ {
+			if defs != nil && defs[i] != 1 {
+				b.AppendNull()
+				continue
+			}
+			add(&values, k)
+			k++
+		}
+		read += int64(n)
+	}
+	return nil
+}
+
+// decode decodes n values of a data page
+func (col *parquetColumn) decode(data []byte, encoding int32, n int, dict *parquetValues) (parquetValues, error) {
+	typ, typeLength := col.element.typ, int(col.element.typeLength)
+	var values parquetValues
+	var err error
+	switch {
+	case encoding == encodingPlain:
+		values, err = decodePlain(data, typ, typeLength, n)
+	case encoding == encodingPlainDictionary || encoding == encodingRLEDictionary:
+		if dict == nil {
+			return values, fmt.Errorf("dictionary encoded page without dictionary")
+		}
+		if len(data) == 0 {
+			if n > 0 {
+				return values, fmt.Errorf("not enough data for %d values", n)
+			}
+			return values, nil
+		}
+		var idx []int32
+		if idx, err = decodeHybrid(data[1:], int(data[0]), n); err == nil {
+			values, err = dict.gather(idx)
+		}
+	case encoding == encodingRLE && typ == parquetBoolean:
+		if len(data) < 4 || int64(binary.LittleEndian.Uint32(data)) > int64(len(data)-4) {
+			return values, fmt.Errorf("not enough data for %d values", n)
+		}
+		var bits []int32
+		if bits, err = decodeHybrid(data[4:4+binary.LittleEndian.Uint32(data)], 1, n); err == nil {
+			values.bools = make([]bool, n)
+			for i, b := range bits {
+				values.bools[i] = b != 0
+			}
+		}
+	case encoding == encodingDeltaBinaryPacked && (typ == parquetInt32 || typ == parquetInt64):
+		values.ints, _, err = decodeDeltaBinaryPacked(data, n)
+		if typ == parquetInt32 {
+			for i, v := range values.ints {
+				values.ints[i] = int64(int32(v))
+			}
+		}
+	case encoding == encodingDeltaLengthByteArray && typ == parquetByteArray:
+		values.bytes, err = decodeDeltaLengthByteArray(data, n)
+	case encoding == encodingDeltaByteArray && (typ == parquetByteArray || typ == parquetFixedLenByteArray):
+		values.bytes, err = decodeDeltaByteArray(data, n)
+	case encoding == encodingByteStreamSplit:
+		values, err = decodeByteStreamSplit(data, typ, typeLength, n)
+	default:
+		return values, fmt.Errorf("unsupported encoding %d of physical type %d", encoding, typ)
+	}
+	if err == nil && values.len() != n {
+		err = fmt.Errorf("%d values decoded instead of %d", values.len(), n)
+	}
+	return values, err
+}
+
+// appender returns a function appending the k-th of the values decoded from a
+// page to the builder of the column
+func (col *parquetColumn) appender(b array.Builder) func(v *parquetValues, k int) {
+	e := col.element
+	if e.convertedType == convertedDecimal || (e.logicalType != nil && e.logicalType.kind == logicalDecimal) {
+		scale := e.scale
+		if e.logicalType != nil && e.logicalType.kind == logicalDecimal {
+			scale = e.logicalType.scale
+		}
+		div := math.Pow10(int(scale))
+		b := b.(*array.Float64Builder)
+		return func(v *parquetValues, k int) {
+			if v.ints != nil {
+				b.Append(float64(v.ints[k]) / div)
+				return
+			}
+			// Big-endian two's complement
+			x := new(big.Int).SetBytes(v.bytes[k])
+			if len(v.bytes[k]) > 0 && v.bytes[k][0]&0x80 != 0 {
+				x.Sub(x, new(big.Int).Lsh(big.NewInt(1), uint(8*len(v.bytes[k]))))
+			}
+			f, _ := new(big.Float).SetInt(x).Float64()
+			b.Append(f / div)
+		}
+	}
+	switch b := b.(type) {
+	case *array.BooleanBuilder:
+		return func(v *parquetValues, k int) { b.Append(v.bools[k]) }
+	case *array.Int8Builder:
+		return func(v *parquetValues, k int) { b.Append(int8(v.ints[k])) }
+	case *array.Int16Builder:
+		return func(v *parquetValues, k int) { b.Append(int16(v.ints[k])) }
+	case *array.Int32Builder:
+		return func(v *parquetValues, k int) { b.Append(int32(v.ints[k])) }
+	case *array.Int64Builder:
+		return func(v *parquetValues, k int) { b.Append(v.ints[k]) }
+	case *array.Uint8Builder:
+		return func(v *parquetValues, k int) { b.Append(uint8(v.ints[k])) }
+	case *array.Uint16Builder:
+		return func(v *parquetValues, k int) { b.Append(uint16(v.ints[k])) }
+	case *array.Uint32Builder:
+		return func(v *parquetValues, k int) { b.Append(uint32(v.ints[k])) }
+	case *array.Uint64Builder:
+		return func(v *parquetValues, k int) { b.Append(uint64(v.ints[k])) }
+	case *array.Float32Builder:
+		return func(v *parquetValues, k int) { b.Append(float32(v.floats[k])) }
+	case *array.Float64Builder:
+		return func(v *parquetValues, k int) { b.Append(v.floats[k]) }
+	case *array.StringBuilder:
+		return func(v *parquetValues, k int) { b.Append(string(v.bytes[k])) }
+	case *array.TimestampBuilder:
+		return func(v *parquetValues, k int) { b.Append(arrow.Timestamp(v.ints[k])) }
+	case *array.Date32Builder:
+		return func(v *parquetValues, k int) { b.Append(arrow.Date32(v.ints[k])) }
+	}
+	panic(fmt.Errorf("no Parquet values for a %T", b))
+}
@@ -0,0 +1,486 @@
+// Paradigm4
+
+package dataframe
+
+// Encodings and compression codecs of the pages of Parquet column chunks, see
+// https://github.com/apache/parquet-format/blob/master/Encodings.md
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// parquetValues holds the values decoded from a page by physical type:
+// BOOLEAN values, INT32 and INT64 values (and INT96 values as nanoseconds
+// since the epoch), FLOAT and DOUBLE values, and byte arrays
+type parquetValues struct {
+	bools  []bool
+	ints   []int64
+	floats []float64
+	bytes  [][]byte
+}
+
+func (v parquetValues) len() int {
+	return len(v.bools) + len(v.ints) + len(v.floats) + len(v.bytes)
+}
+
+// gather returns the values at the given indexes, as when decoding
+// dictionary encoded pages
+func (v parquetValues) gather(idx []int32) (parquetValues, error) {
+	n := v.len()
+	for _, i := range idx {
+		if i < 0 || int(i) >= n {
+			return parquetValues{}, fmt.Errorf("dictionary index %d out of range", i)
+		}
+	}
+	var ret parquetValues
+	switch {
+	case v.bools != nil:
+		ret.bools = make([]bool, len(idx))
+		for k, i := range idx {
+			ret.bools[k] = v.bools[i]
+		}
+	case v.ints != nil:
+		ret.ints = make([]int64, len(idx))
+		for k, i := range idx {
+			ret.ints[k] = v.ints[i]
+		}
+	case v.floats != nil:
+		ret.floats = make([]float64, len(idx))
+		for k, i := range idx {
+			ret.floats[k] = v.floats[i]
+		}
+	case v.bytes != nil:
+		ret.bytes = make([][]byte, len(idx))
+		for k, i := range idx {
+			ret.bytes[k] = v.bytes[i]
+		}
+	}
+	return ret, nil
+}
+
+// julianEpoch is the Julian day of the Unix epoch, used by INT96 timestamps
+const julianEpoch = 2440588
+
+// decodePlain decodes n PLAIN encoded values of the physical type typ.
+// typeLength is the length of FIXED_LEN_BYTE_ARRAY values.
+func decodePlain(buf []byte, typ int32, typeLength int, n int) (parquetValues, error) {
+	var v parquetValues
+	size := 0
+	switch typ {
+	case parquetBoolean:
+		size = (n + 7) / 8
+	case parquetInt32, parquetFloat:
+		size = 4 * n
+	case parquetInt64, parquetDouble:
+		size = 8 * n
+	case parquetInt96:
+		size = 12 * n
+	case parquetByteArray:
+		// The length of every value
+		size = 4 * n
+	case parquetFixedLenByteArray:
+		if typeLength <= 0 {
+			return v, fmt.Errorf("invalid FIXED_LEN_BYTE_ARRAY length %d", typeLength)
+		}
+		size = typeLength * n
+	}
+	if size > len(buf) {
+		return v, fmt.Errorf("not enough data for %d values", n)
+	}
+	switch typ {
+	case parquetBoolean:
+		v.bools = make([]bool, n)
+		for i := range v.bools {
+			v.bools[i] = buf[i/8]&(1<<(i%8)) != 0
+		}
+	case parquetInt32:
+		v.ints = make([]int64, n)
+		for i := range v.ints {
+			v.ints[i] = int64(int32(binary.LittleEndian.Uint32(buf[4*i:])))
+		}
+	case parquetInt64:
+		v.ints = make([]int64, n)
+		for i := range v.ints {
+			v.ints[i] = int64(binary.LittleEndian.Uint64(buf[8*i:]))
+		}
+	case parquetInt96:
+		// Nanoseconds within the day followed by the Julian day
+		v.ints = make([]int64, n)
+		for i := range v.ints {
+			nanos := int64(binary.LittleEndian.Uint64(buf[12*i:]))
+			day := int64(binary.LittleEndian.Uint32(buf[12*i+8:]))
+			v.ints[i] = (day-julianEpoch)*secondsPerDay*1e9 + nanos
+		}
+	case parquetFloat:
+		v.floats = make([]float64, n)
+		for i := range v.floats {
+			v.floats[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:])))
+		}
+	case parquetDouble:
+		v.floats = make([]float64, n)
+		for i := range v.floats {
+			v.floats[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[8*i:]))
+		}
+	case parquetByteArray:
+		v.bytes = make([][]byte, n)
+		for i := range v.bytes {
+			if len(buf) < 4 {
+				return v, fmt.Errorf("not enough data for %d values", n)
+			}
+			l := binary.LittleEndian.Uint32(buf)
+			if uint64(l) > uint64(len(buf)-4) {
+				return v, fmt.Errorf("not enough data for %d values", n)
+			}
+			v.bytes[i] = buf[4 : 4+l]
+			buf = buf[4+l:]
+		}
+	case parquetFixedLenByteArray:
+		v.bytes = make([][]byte, n)
+		for i := range v.bytes {
+			v.bytes[i] = buf[typeLength*i : typeLength*(i+1)]
+		}
+	default:
+		return v, fmt.Errorf("unknown physical type %d", typ)
+	}
+	return v, nil
+}
+
+// decodeByteStreamSplit decodes n BYTE_STREAM_SPLIT encoded values, which
+// store the k-th bytes of all the values one after the other
+func decodeByteStreamSplit(buf []byte, typ int32, typeLength int, n int) (parquetValues, error) {
+	width := typeLength
+	switch typ {
+	case parquetInt32, parquetFloat:
+		width = 4
+	case parquetInt64, parquetDouble:
+		width = 8
+	case parquetFixedLenByteArray:
+	default:
+		return parquetValues{}, fmt.Errorf("BYTE_STREAM_SPLIT encoding of physical type %d", typ)
+	}
+	if width*n > len(buf) {
+		return parquetValues{}, fmt.Errorf("not enough data for %d values", n)
+	}
+	plain := make([]byte, width*n)
+	for i := 0; i < n; i++ {
+		for k := 0; k < width; k++ {
+			plain[width*i+k] = buf[k*n+i]
+		}
+	}
+	return decodePlain(plain, typ, typeLength, n)
+}
+
+// bitsAt returns the width bits at bit offset off of buf, packed from the
+// least significant bit of each byte
+func bitsAt(buf []byte, off, width int) uint64 {
+	var v uint64
+	for k := 0; k < width; {
+		shift := (off + k) % 8
+		take := 8 - shift
+		if take > width-k {
+			take = width - k
+		}
+		b := buf[(off+k)/8] >> shift & (byte(1)<<take - 1)
+		v |= uint64(b) << k
+		k += take
+	}
+	return v
+}
+
+// setBits stores the width lowest bits of v at bit offset off of buf, see
+// bitsAt
+func setBits(buf []byte, off, width int, v uint64) {
+	for k := 0; k < width; k++ {
+		if v&(1<<k) != 0 {
+			buf[(off+k)/8] |= 1 << ((off + k) % 8)
+		}
+	}
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+// decodeHybrid decodes n values of width bits encoded with the RLE/bit-packing
+// hybrid encoding, used by levels, dictionary indexes and RLE booleans
+func decodeHybrid(buf []byte, width int, n int) ([]int32, error) {
+	if width > 32 {
+		return nil, fmt.Errorf("invalid bit width %d", width)
+	}
+	values := make([]int32, 0, n)
+	for len(values) < n {
+		header, k := binary.Uvarint(buf)
+		if k <= 0 {
+			return nil, fmt.Errorf("not enough data for %d values", n)
+		}
+		buf = buf[k:]
+		if header&1 == 1 {
+			// Bit-packed groups of 8 values
+			count := (header >> 1) * 8
+			size := (header >> 1) * uint64(width)
+			if size > uint64(len(buf)) {
+				return nil, fmt.Errorf("not enough data for %d values", n)
+			}
+			for j := 0; j < int(count) && len(values) < n; j++ {
+				values = append(values, int32(bitsAt(buf, j*width, width)))
+			}
+			buf = buf[size:]
+		} else {
+			// Run of a value stored in whole bytes
+			count := header >> 1
+			size := (width + 7) / 8
+			if size > len(buf) {
+				return nil, fmt.Errorf("not enough data for %d values", n)
+			}
+			var v int32
+			for k := 0; k < size; k++ {
+				v |= int32(buf[k]) << (8 * k)
+			}
+			buf = buf[size:]
+			for j := uint64(0); j < count && len(values) < n; j++ {
+				values = append(values, v)
+			}
+		}
+	}
+	return values, nil
+}
+
+// appendHybrid appends the values encoded with the RLE/bit-packing hybrid
+// encoding. Runs of at least 8 values are run-length encoded, and the values
+// in between bit-packed.
+func appendHybrid(buf []byte, values []int32, width int) []byte {
+	run := func(i int) int {
+		j := i + 1
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		return j - i
+	}
+	for i := 0; i < len(values); {
+		if n := run(i); n >= 8 {
+			buf = appendUvarint(buf, uint64(n)<<1)
+			for k := 0; k < (width+7)/8; k++ {
+				buf = append(buf, byte(values[i]>>(8*k)))
+			}
+			i += n
+			continue
+		}
+		start := i
+		for i < len(values) && (i == start || run(i) < 8) {
+			i += 8
+		}
+		if i > len(values) {
+			i = len(values)
+		}
+		groups := (i - start + 7) / 8
+		buf = appendUvarint(buf, uint64(groups)<<1|1)
+		packed := make([]byte, groups*width)
+		for k := start; k < i; k++ {
+			setBits(packed, (k-start)*width, width, uint64(values[k]))
+		}
+		buf = append(buf, packed...)
+	}
+	return buf
+}
+
+// decodeDeltaBinaryPacked decodes n DELTA_BINARY_PACKED integers, returning
+// the number of bytes read
+func decodeDeltaBinaryPacked(buf []byte, n int) ([]int64, int, error) {
+	r := thriftReader{buf: buf}
+	blockSize := r.uvarint()
+	miniblocks := r.uvarint()
+	total := r.uvarint()
+	last := r.varint()
+	if r.err != nil {
+		return nil, 0, fmt.Errorf("invalid DELTA_BINARY_PACKED header")
+	}
+	if blockSize == 0 || blockSize > 1<<20 || miniblocks == 0 || blockSize%miniblocks != 0 || blockSize/miniblocks%8 != 0 {
+		return nil, 0, fmt.Errorf("invalid DELTA_BINARY_PACKED block size %d with %d miniblocks", blockSize, miniblocks)
+	}
+	if total < uint64(n) {
+		return nil, 0, fmt.Errorf("not enough data for %d values", n)
+	}
+	perMiniblock := int(blockSize / miniblocks)
+	values := make([]int64, 0, n)
+	if n > 0 {
+		values = append(values, last)
+	}
+	for len(values) < n {
+		minDelta := r.varint()
+		if r.pos+int(miniblocks) > len(r.buf) {
+			return nil, 0, fmt.Errorf("not enough data for %d values", n)
+		}
+		widths := r.buf[r.pos : r.pos+int(miniblocks)]
+		r.pos += int(miniblocks)
+		for _, width := range widths {
+			if len(values) == n {
+				break
+			}
+			if width > 64 {
+				return nil, 0, fmt.Errorf("invalid bit width %d", width)
+			}
+			size := perMiniblock * int(width) / 8
+			if r.pos+size > len(r.buf) {
+				return nil, 0, fmt.Errorf("not enough data for %d values", n)
+			}
+			miniblock := r.buf[r.pos : r.pos+size]
+			r.pos += size
+			for j := 0; j < perMiniblock && len(values) < n; j++ {
+				// Overflows wrap around, as they do when encoding
+				last += minDelta + int64(bitsAt(miniblock, j*int(width), int(width)))
+				values = append(values, last)
+			}
+		}
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("not enough data for %d values", n)
+		}
+	}
+	return values, r.pos, nil
+}
+
+// decodeDeltaLengthByteArray decodes n DELTA_LENGTH_BYTE_ARRAY values: their
+// DELTA_BINARY_PACKED lengths followed by their data
+func decodeDeltaLengthByteArray(buf []byte, n int) ([][]byte, error) {
+	lengths, k, err := decodeDeltaBinaryPacked(buf, n)
+	if err != nil {
+		return nil, err
+	}
+	buf = buf[k:]
+	values := make([][]byte, n)
+	for i, l := range lengths {
+		if l < 0 || l > int64(len(buf)) {
+			return nil, fmt.Errorf("not enough data for %d values", n)
+		}
+		values[i] = buf[:l]
+		buf = buf[l:]
+	}
+	return values, nil
+}
+
+// decodeDeltaByteArray decodes n DELTA_BYTE_ARRAY values, each one stored as
+// the length of the prefix it shares with the previous one and the rest of it
+func decodeDeltaByteArray(buf []byte, n int) ([][]byte, error) {
+	prefixes, k, err := decodeDeltaBinaryPacked(buf, n)
+	if err != nil {
+		return nil, err
+	}
+	suffixes, err := decodeDeltaLengthByteArray(buf[k:], n)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, n)
+	var prev []byte
+	for i, p := range prefixes {
+		if p < 0 || p > int64(len(prev)) {
+			return nil, fmt.Errorf("invalid DELTA_BYTE_ARRAY prefix length %d", p)
+		}
+		v := make([]byte, int(p)+len(suffixes[i]))
+		copy(v, prev[:p])
+		copy(v[p:], suffixes[i])
+		values[i] = v
+		prev = v
+	}
+	return values, nil
+}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdCodec returns the zstd encoder and decoder shared by all the pages
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr == nil {
+			zstdDecoder, zstdErr = zstd.NewReader(nil)
+		}
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// compress compresses a page with the codec
+func compress(codec ParquetCodec, src []byte) ([]byte, error) {
+	switch codec {
+	case Parquet_UNCOMPRESSED:
+		return src, nil
+	case Parquet_SNAPPY:
+		return snappy.Encode(nil, src), nil
+	case Parquet_GZIP:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(src); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Parquet_ZSTD:
+		enc, _, err := zstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		return enc.EncodeAll(src, nil), nil
+	case Parquet_LZ4_RAW:
+		var c lz4.Compressor
+		dst := make([]byte, lz4.CompressBlockBound(len(src)))
+		n, err := c.CompressBlock(src, dst)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %v", codec)
+	}
+}
+
+// decompress decompresses a page of size bytes compressed with the codec
+func decompress(codec ParquetCodec, src []byte, size int) ([]byte, error) {
+	var dst []byte
+	var err error
+	switch codec {
+	case Parquet_UNCOMPRESSED:
+		dst = src
+	case Parquet_SNAPPY:
+		dst, err = snappy.Decode(nil, src)
+	case Parquet_GZIP:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(src)); err == nil {
+			dst, err = io.ReadAll(r)
+		}
+	case Parquet_ZSTD:
+		var dec *zstd.Decoder
+		if _, dec, err = zstdCodec(); err == nil {
+			dst, err = dec.DecodeAll(src, make([]byte, 0, size))
+		}
+	case Parquet_LZ4_RAW:
+		dst = make([]byte, size)
+		var n int
+		n, err = lz4.UncompressBlock(src, dst)
+		dst = dst[:n]
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %v", codec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", codec, err)
+	}
+	if len(dst) != size {
+		return nil, fmt.Errorf("%v: page of %d bytes instead of %d", codec, len(dst), size)
+	}
+	return dst, nil
+}
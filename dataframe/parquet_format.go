@@ -0,0 +1,723 @@
+// Paradigm4
+
+package dataframe
+
+// Parquet file metadata and page headers, encoded with the Thrift compact
+// protocol as described in
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+// Only the fields used by ReadParquet and WriteParquet are kept, the others
+// are skipped when reading.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Parquet physical types
+const (
+	parquetBoolean           int32 = 0
+	parquetInt32             int32 = 1
+	parquetInt64             int32 = 2
+	parquetInt96             int32 = 3
+	parquetFloat             int32 = 4
+	parquetDouble            int32 = 5
+	parquetByteArray         int32 = 6
+	parquetFixedLenByteArray int32 = 7
+)
+
+// Parquet converted types, superseded by logical types but still written for
+// older readers
+const (
+	convertedUTF8            int32 = 0
+	convertedEnum            int32 = 4
+	convertedDecimal         int32 = 5
+	convertedDate            int32 = 6
+	convertedTimestampMillis int32 = 9
+	convertedTimestampMicros int32 = 10
+	convertedUint8           int32 = 11
+	convertedUint16          int32 = 12
+	convertedUint32          int32 = 13
+	convertedUint64          int32 = 14
+	convertedInt8            int32 = 15
+	convertedInt16           int32 = 16
+	convertedInt32           int32 = 17
+	convertedInt64           int32 = 18
+	convertedJSON            int32 = 19
+)
+
+// Ids of the fields of the LogicalType union
+const (
+	logicalString    int16 = 1
+	logicalEnum      int16 = 4
+	logicalDecimal   int16 = 5
+	logicalDate      int16 = 6
+	logicalTimestamp int16 = 8
+	logicalInteger   int16 = 10
+	logicalJSON      int16 = 12
+)
+
+// Ids of the fields of the TimeUnit union
+const (
+	unitMillis int16 = 1
+	unitMicros int16 = 2
+	unitNanos  int16 = 3
+)
+
+// Parquet field repetitions
+const (
+	repetitionRequired int32 = 0
+	repetitionOptional int32 = 1
+	repetitionRepeated int32 = 2
+)
+
+// Parquet encodings
+const (
+	encodingPlain                int32 = 0
+	encodingPlainDictionary      int32 = 2
+	encodingRLE                  int32 = 3
+	encodingDeltaBinaryPacked    int32 = 5
+	encodingDeltaLengthByteArray int32 = 6
+	encodingDeltaByteArray       int32 = 7
+	encodingRLEDictionary        int32 = 8
+	encodingByteStreamSplit      int32 = 9
+)
+
+// Parquet page types
+const (
+	pageData       int32 = 0
+	pageDictionary int32 = 2
+	pageDataV2     int32 = 3
+)
+
+type parquetFileMetaData struct {
+	version   int32
+	schema    []parquetSchemaElement
+	numRows   int64
+	rowGroups []parquetRowGroup
+	createdBy string
+}
+
+// parquetSchemaElement is a node of the schema tree, stored depth first. The
+// type and converted type of groups are -1, as is the repetition of the root
+// when writing.
+type parquetSchemaElement struct {
+	typ           int32
+	typeLength    int32
+	repetition    int32
+	name          string
+	numChildren   int32
+	convertedType int32
+	scale         int32
+	precision     int32
+	logicalType   *parquetLogicalType
+}
+
+// parquetLogicalType holds the LogicalType union, kind being the id of its
+// set field
+type parquetLogicalType struct {
+	kind      int16
+	bitWidth  int8
+	signed    bool
+	utc       bool
+	unit      int16
+	scale     int32
+	precision int32
+}
+
+type parquetRowGroup struct {
+	columns       []parquetColumnChunk
+	totalByteSize int64
+	numRows       int64
+}
+
+type parquetColumnChunk struct {
+	// Set if the column chunk is stored in another file.
+	filePath   string
+	fileOffset int64
+	meta       parquetColumnMetaData
+}
+
+type parquetColumnMetaData struct {
+	typ                   int32
+	encodings             []int32
+	path                  []string
+	codec                 int32
+	numValues             int64
+	totalUncompressedSize int64
+	totalCompressedSize   int64
+	dataPageOffset        int64
+	dictionaryPageOffset  int64
+}
+
+// parquetPageHeader flattens the PageHeader struct with the header of its
+// data, dictionary or data v2 page
+type parquetPageHeader struct {
+	typ              int32
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+	encoding         int32
+	// Data v2 pages only: the lengths of the uncompressed levels, and whether
+	// the values are compressed.
+	defLength  int32
+	repLength  int32
+	compressed bool
+}
+
+// Thrift compact protocol types
+const (
+	thriftStop   byte = 0
+	thriftTrue   byte = 1
+	thriftFalse  byte = 2
+	thriftByte   byte = 3
+	thriftI16    byte = 4
+	thriftI32    byte = 5
+	thriftI64    byte = 6
+	thriftDouble byte = 7
+	thriftBinary byte = 8
+	thriftList   byte = 9
+	thriftSet    byte = 10
+	thriftMap    byte = 11
+	thriftStruct byte = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol
+type thriftWriter struct {
+	buf []byte
+	// The id of the last field written in each of the open structs.
+	last []int16
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	w.buf = appendUvarint(w.buf, v)
+}
+
+func (w *thriftWriter) varint(v int64) {
+	w.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) byte(id int16, v int8) {
+	w.field(id, thriftByte)
+	w.buf = append(w.buf, byte(v))
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	if v {
+		w.field(id, thriftTrue)
+	} else {
+		w.field(id, thriftFalse)
+	}
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.field(id, thriftBinary)
+	w.uvarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// list writes the header of a list field of n elements of type typ, which
+// must be written next
+func (w *thriftWriter) list(id int16, typ byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|typ)
+	} else {
+		w.buf = append(w.buf, 0xf0|typ)
+		w.uvarint(uint64(n))
+	}
+}
+
+// begin starts a struct field
+func (w *thriftWriter) begin(id int16) {
+	w.field(id, thriftStruct)
+	w.open()
+}
+
+// open starts a struct element of a list, or the top level struct
+func (w *thriftWriter) open() {
+	w.last = append(w.last, 0)
+}
+
+// end closes the last struct opened
+func (w *thriftWriter) end() {
+	w.buf = append(w.buf, thriftStop)
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (m *parquetFileMetaData) write(w *thriftWriter) {
+	w.open()
+	w.i32(1, m.version)
+	w.list(2, thriftStruct, len(m.schema))
+	for i := range m.schema {
+		m.schema[i].write(w)
+	}
+	w.i64(3, m.numRows)
+	w.list(4, thriftStruct, len(m.rowGroups))
+	for i := range m.rowGroups {
+		m.rowGroups[i].write(w)
+	}
+	if m.createdBy != "" {
+		w.string(6, m.createdBy)
+	}
+	w.end()
+}
+
+func (e *parquetSchemaElement) write(w *thriftWriter) {
+	w.open()
+	if e.typ >= 0 {
+		w.i32(1, e.typ)
+	}
+	if e.typ == parquetFixedLenByteArray {
+		w.i32(2, e.typeLength)
+	}
+	if e.repetition >= 0 {
+		w.i32(3, e.repetition)
+	}
+	w.string(4, e.name)
+	if e.numChildren > 0 {
+		w.i32(5, e.numChildren)
+	}
+	if e.convertedType >= 0 {
+		w.i32(6, e.convertedType)
+	}
+	if e.convertedType == convertedDecimal {
+		w.i32(7, e.scale)
+		w.i32(8, e.precision)
+	}
+	if lt := e.logicalType; lt != nil {
+		w.begin(10)
+		w.begin(lt.kind)
+		switch lt.kind {
+		case logicalDecimal:
+			w.i32(1, lt.scale)
+			w.i32(2, lt.precision)
+		case logicalTimestamp:
+			w.bool(1, lt.utc)
+			w.begin(2)
+			w.begin(lt.unit)
+			w.end()
+			w.end()
+		case logicalInteger:
+			w.byte(1, lt.bitWidth)
+			w.bool(2, lt.signed)
+		}
+		w.end()
+		w.end()
+	}
+	w.end()
+}
+
+func (rg *parquetRowGroup) write(w *thriftWriter) {
+	w.open()
+	w.list(1, thriftStruct, len(rg.columns))
+	for _, c := range rg.columns {
+		w.open()
+		w.i64(2, c.fileOffset)
+		w.begin(3)
+		m := c.meta
+		w.i32(1, m.typ)
+		w.list(2, thriftI32, len(m.encodings))
+		for _, e := range m.encodings {
+			w.varint(int64(e))
+		}
+		w.list(3, thriftBinary, len(m.path))
+		for _, p := range m.path {
+			w.uvarint(uint64(len(p)))
+			w.buf = append(w.buf, p...)
+		}
+		w.i32(4, m.codec)
+		w.i64(5, m.numValues)
+		w.i64(6, m.totalUncompressedSize)
+		w.i64(7, m.totalCompressedSize)
+		w.i64(9, m.dataPageOffset)
+		w.end()
+		w.end()
+	}
+	w.i64(2, rg.totalByteSize)
+	w.i64(3, rg.numRows)
+	w.end()
+}
+
+// write encodes the header of a data page (v1), with RLE levels
+func (h *parquetPageHeader) write(w *thriftWriter) {
+	w.open()
+	w.i32(1, h.typ)
+	w.i32(2, h.uncompressedSize)
+	w.i32(3, h.compressedSize)
+	w.begin(5)
+	w.i32(1, h.numValues)
+	w.i32(2, h.encoding)
+	w.i32(3, encodingRLE)
+	w.i32(4, encodingRLE)
+	w.end()
+	w.end()
+}
+
+// thriftReader decodes structs encoded with the Thrift compact protocol. The
+// first error is kept in err, after which every read returns zero values.
+type thriftReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *thriftReader) fail(format string, a ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("invalid Thrift data: "+format, a...)
+	}
+	r.pos = len(r.buf)
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.buf) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) i32() int32 {
+	v := r.varint()
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		r.fail("i32 out of range")
+		return 0
+	}
+	return int32(v)
+}
+
+func (r *thriftReader) binary() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.buf)-r.pos) {
+		r.fail("binary longer than the data")
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+// list reads the header of a list, returning the type and number of its
+// elements
+func (r *thriftReader) list() (byte, int) {
+	b := r.byte()
+	n := uint64(b >> 4)
+	if n == 15 {
+		n = r.uvarint()
+	}
+	// Every element takes at least one byte.
+	if n > uint64(len(r.buf)-r.pos) {
+		r.fail("list longer than the data")
+		return 0, 0
+	}
+	return b & 0x0f, int(n)
+}
+
+// fields calls f with the id and type of every field of a struct up to its
+// end. f reads the value of the fields it knows, and returns false to skip the
+// others.
+func (r *thriftReader) fields(f func(id int16, typ byte) bool) {
+	var last int16
+	for r.err == nil {
+		b := r.byte()
+		typ := b & 0x0f
+		if typ == thriftStop {
+			return
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.varint())
+		}
+		if !f(last, typ) {
+			r.skip(typ, false)
+		}
+	}
+}
+
+// skip skips a value of type typ. Booleans are part of the field header,
+// unless they are list elements.
+func (r *thriftReader) skip(typ byte, elem bool) {
+	switch typ {
+	case thriftTrue, thriftFalse:
+		if elem {
+			r.byte()
+		}
+	case thriftByte:
+		r.byte()
+	case thriftI16, thriftI32, thriftI64:
+		r.varint()
+	case thriftDouble:
+		for i := 0; i < 8; i++ {
+			r.byte()
+		}
+	case thriftBinary:
+		r.binary()
+	case thriftList, thriftSet:
+		typ, n := r.list()
+		for i := 0; i < n && r.err == nil; i++ {
+			r.skip(typ, true)
+		}
+	case thriftMap:
+		n := r.uvarint()
+		if n > 0 {
+			kv := r.byte()
+			for i := uint64(0); i < n && r.err == nil; i++ {
+				r.skip(kv>>4, true)
+				r.skip(kv&0x0f, true)
+			}
+		}
+	case thriftStruct:
+		r.fields(func(int16, byte) bool { return false })
+	default:
+		r.fail("unknown type %d", typ)
+	}
+}
+
+func (m *parquetFileMetaData) read(r *thriftReader) {
+	r.fields(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI32:
+			m.version = r.i32()
+		case id == 2 && typ == thriftList:
+			_, n := r.list()
+			m.schema = make([]parquetSchemaElement, n)
+			for i := range m.schema {
+				m.schema[i].read(r)
+			}
+		case id == 3 && typ == thriftI64:
+			m.numRows = r.varint()
+		case id == 4 && typ == thriftList:
+			_, n := r.list()
+			m.rowGroups = make([]parquetRowGroup, n)
+			for i := range m.rowGroups {
+				m.rowGroups[i].read(r)
+			}
+		case id == 6 && typ == thriftBinary:
+			m.createdBy = string(r.binary())
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func (e *parquetSchemaElement) read(r *thriftReader) {
+	e.typ, e.convertedType = -1, -1
+	r.fields(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI32:
+			e.typ = r.i32()
+		case id == 2 && typ == thriftI32:
+			e.typeLength = r.i32()
+		case id == 3 && typ == thriftI32:
+			e.repetition = r.i32()
+		case id == 4 && typ == thriftBinary:
+			e.name = string(r.binary())
+		case id == 5 && typ == thriftI32:
+			e.numChildren = r.i32()
+		case id == 6 && typ == thriftI32:
+			e.convertedType = r.i32()
+		case id == 7 && typ == thriftI32:
+			e.scale = r.i32()
+		case id == 8 && typ == thriftI32:
+			e.precision = r.i32()
+		case id == 10 && typ == thriftStruct:
+			e.logicalType = &parquetLogicalType{}
+			e.logicalType.read(r)
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func (lt *parquetLogicalType) read(r *thriftReader) {
+	r.fields(func(kind int16, typ byte) bool {
+		if typ != thriftStruct {
+			return false
+		}
+		lt.kind = kind
+		r.fields(func(id int16, typ byte) bool {
+			switch {
+			case kind == logicalDecimal && id == 1 && typ == thriftI32:
+				lt.scale = r.i32()
+			case kind == logicalDecimal && id == 2 && typ == thriftI32:
+				lt.precision = r.i32()
+			case kind == logicalTimestamp && id == 1:
+				lt.utc = typ == thriftTrue
+			case kind == logicalTimestamp && id == 2 && typ == thriftStruct:
+				r.fields(func(unit int16, typ byte) bool {
+					lt.unit = unit
+					return false
+				})
+			case kind == logicalInteger && id == 1 && typ == thriftByte:
+				lt.bitWidth = int8(r.byte())
+			case kind == logicalInteger && id == 2:
+				lt.signed = typ == thriftTrue
+			default:
+				return false
+			}
+			return true
+		})
+		return true
+	})
+}
+
+func (rg *parquetRowGroup) read(r *thriftReader) {
+	r.fields(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftList:
+			_, n := r.list()
+			rg.columns = make([]parquetColumnChunk, n)
+			for i := range rg.columns {
+				rg.columns[i].read(r)
+			}
+		case id == 2 && typ == thriftI64:
+			rg.totalByteSize = r.varint()
+		case id == 3 && typ == thriftI64:
+			rg.numRows = r.varint()
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func (c *parquetColumnChunk) read(r *thriftReader) {
+	r.fields(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftBinary:
+			c.filePath = string(r.binary())
+		case id == 2 && typ == thriftI64:
+			c.fileOffset = r.varint()
+		case id == 3 && typ == thriftStruct:
+			c.meta.read(r)
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func (m *parquetColumnMetaData) read(r *thriftReader) {
+	r.fields(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI32:
+			m.typ = r.i32()
+		case id == 2 && typ == thriftList:
+			_, n := r.list()
+			m.encodings = make([]int32, n)
+			for i := range m.encodings {
+				m.encodings[i] = r.i32()
+			}
+		case id == 3 && typ == thriftList:
+			_, n := r.list()
+			m.path = make([]string, n)
+			for i := range m.path {
+				m.path[i] = string(r.binary())
+			}
+		case id == 4 && typ == thriftI32:
+			m.codec = r.i32()
+		case id == 5 && typ == thriftI64:
+			m.numValues = r.varint()
+		case id == 6 && typ == thriftI64:
+			m.totalUncompressedSize = r.varint()
+		case id == 7 && typ == thriftI64:
+			m.totalCompressedSize = r.varint()
+		case id == 9 && typ == thriftI64:
+			m.dataPageOffset = r.varint()
+		case id == 11 && typ == thriftI64:
+			m.dictionaryPageOffset = r.varint()
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func (h *parquetPageHeader) read(r *thriftReader) {
+	h.compressed = true
+	r.fields(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI32:
+			h.typ = r.i32()
+		case id == 2 && typ == thriftI32:
+			h.uncompressedSize = r.i32()
+		case id == 3 && typ == thriftI32:
+			h.compressedSize = r.i32()
+		case (id == 5 || id == 7) && typ == thriftStruct:
+			// Data page and dictionary page headers
+			r.fields(func(id int16, typ byte) bool {
+				switch {
+				case id == 1 && typ == thriftI32:
+					h.numValues = r.i32()
+				case id == 2 && typ == thriftI32:
+					h.encoding = r.i32()
+				default:
+					return false
+				}
+				return true
+			})
+		case id == 8 && typ == thriftStruct:
+			r.fields(func(id int16, typ byte) bool {
+				switch {
+				case id == 1 && typ == thriftI32:
+					h.numValues = r.i32()
+				case id == 4 && typ == thriftI32:
+					h.encoding = r.i32()
+				case id == 5 && typ == thriftI32:
+					h.defLength = r.i32()
+				case id == 6 && typ == thriftI32:
+					h.repLength = r.i32()
+				case id == 7:
+					h.compressed = typ == thriftTrue
+				default:
+					return false
+				}
+				return true
+			})
+		default:
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,454 @@
+package dataframe
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Paradigm4/gota/series"
+	"github.com/apache/arrow/go/arrow"
+)
+
+func TestParquet(t *testing.T) {
+	ts := time.Date(2021, 6, 1, 10, 30, 0, 123456000, time.UTC)
+	df := New(
+		series.New([]interface{}{1, nil, 3, 4, 5}, series.Int, "int"),
+		series.New([]interface{}{uint(1), uint(2), nil, uint(4), uint(5)}, series.Uint, "uint"),
+		series.New([]interface{}{1.5, "NaN", 3.5, nil, 5.5}, series.Float, "float"),
+		series.New([]interface{}{"a", "b", nil, "", "e"}, series.String, "string"),
+		series.New([]interface{}{true, false, nil, true, false}, series.Bool, "bool"),
+		series.New([]interface{}{ts, nil, ts.Add(time.Hour), ts, ts}, series.Time, "time"),
+	)
+	expected, _ := df.Records(true)
+
+	codecs := []ParquetCodec{Parquet_UNCOMPRESSED, Parquet_SNAPPY, Parquet_GZIP, Parquet_ZSTD, Parquet_LZ4_RAW}
+	for _, codec := range codecs {
+		for _, rowGroupRows := range []int{2, 5, defaultParquetRowGroupRows} {
+			var buf bytes.Buffer
+			if err := df.WriteParquet(&buf, WriteCompression(codec), WriteRowGroupRows(rowGroupRows)); err != nil {
+				t.Fatalf("Codec %v, row group rows %d\nError:%v", codec, rowGroupRows, err)
+			}
+			pf, err := openParquet(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("Codec %v, row group rows %d\nError:%v", codec, rowGroupRows, err)
+			}
+			expGroups := 1
+			if rowGroupRows == 2 {
+				expGroups = 3
+			}
+			if len(pf.meta.rowGroups) != expGroups {
+				t.Errorf("Codec %v, row group rows %d\nExpected %d row groups, received %d", codec, rowGroupRows, expGroups, len(pf.meta.rowGroups))
+			}
+			received := ReadParquet(bytes.NewReader(buf.Bytes()))
+			if received.Err != nil {
+				t.Fatalf("Codec %v, row group rows %d\nError:%v", codec, rowGroupRows, received.Err)
+			}
+			if !reflect.DeepEqual(df.Types(), received.Types()) {
+				t.Errorf("Codec %v, row group rows %d\nDifferent types:\nA:%v\nB:%v", codec, rowGroupRows, df.Types(), received.Types())
+			}
+			rr, _ := received.Records(true)
+			if !reflect.DeepEqual(expected, rr) {
+				t.Errorf("Codec %v, row group rows %d\nDifferent values:\nA:%v\nB:%v", codec, rowGroupRows, expected, rr)
+			}
+		}
+	}
+
+	// Files, with projection and row group selection
+	path := filepath.Join(t.TempDir(), "df.parquet")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := df.WriteParquet(f, WriteRowGroupRows(2)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	table := []struct {
+		options []LoadOption
+		exp     DataFrame
+	}{
+		{
+			nil,
+			df,
+		},
+		{
+			[]LoadOption{WithColumns("string", "int")},
+			df.Select([]string{"string", "int"}),
+		},
+		{
+			[]LoadOption{WithRowGroups(2, 0)},
+			df.Subset([]int{4, 0, 1}),
+		},
+		{
+			[]LoadOption{WithColumns("bool"), WithRowGroups(1)},
+			df.Select("bool").Subset([]int{2, 3}),
+		},
+	}
+	for i, tc := range table {
+		received := ReadParquet(f, tc.options...)
+		if received.Err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, received.Err)
+		}
+		exp, _ := tc.exp.Records(true)
+		rr, _ := received.Records(true)
+		if !reflect.DeepEqual(exp, rr) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, exp, rr)
+		}
+	}
+
+	// The schema of the file keeps the widths of the integers
+	schema := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "int", Type: arrow.PrimitiveTypes.Int8, Nullable: true},
+			{Name: "uint", Type: arrow.PrimitiveTypes.Uint16, Nullable: true},
+			{Name: "float", Type: arrow.PrimitiveTypes.Float32, Nullable: true},
+			{Name: "string", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "bool", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+			{Name: "time", Type: &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}, Nullable: true},
+			{Name: "date", Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+		},
+		nil,
+	)
+	dates := df.Mutate("date", df.Col("time"))
+	var buf bytes.Buffer
+	if err := dates.WriteParquet(&buf, WriteSchema(schema)); err != nil {
+		t.Fatal(err)
+	}
+	received, err := ReadParquetSchema(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !received.Equal(schema) {
+		t.Errorf("Different schemas:\nA:%v\nB:%v", schema, received)
+	}
+	rdf := ReadParquet(bytes.NewReader(buf.Bytes()))
+	if rdf.Err != nil {
+		t.Fatal(rdf.Err)
+	}
+	day := ts.Truncate(24 * time.Hour)
+	exp := df.Mutate("date", series.New([]interface{}{day, nil, day, day, day}, series.Time, "date"))
+	expRecords, _ := exp.Records(true)
+	rr, _ := rdf.Records(true)
+	if !reflect.DeepEqual(expRecords, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", expRecords, rr)
+	}
+
+	// Required fields
+	required := arrow.NewSchema([]arrow.Field{{Name: "int", Type: arrow.PrimitiveTypes.Int64}}, nil)
+	buf.Reset()
+	if err := df.Subset([]int{0, 2}).WriteParquet(&buf, WriteSchema(required)); err != nil {
+		t.Fatal(err)
+	}
+	rdf = ReadParquet(bytes.NewReader(buf.Bytes()))
+	if rdf.Err != nil {
+		t.Fatal(rdf.Err)
+	}
+	exp = df.Subset([]int{0, 2}).Select("int")
+	expRecords, _ = exp.Records(true)
+	rr, _ = rdf.Records(true)
+	if !reflect.DeepEqual(expRecords, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", expRecords, rr)
+	}
+	if err := df.WriteParquet(&buf, WriteSchema(required)); err == nil {
+		t.Errorf("Expected error for missing values in a required field")
+	}
+
+	// Empty DataFrames keep their columns
+	buf.Reset()
+	if err := df.Subset([]int{}).WriteParquet(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rdf = ReadParquet(bytes.NewReader(buf.Bytes()))
+	if rdf.Err != nil {
+		t.Fatal(rdf.Err)
+	}
+	if rdf.Nrow() != 0 || !reflect.DeepEqual(df.Names(), rdf.Names()) {
+		t.Errorf("Expected no rows and columns %v, received:\n%v", df.Names(), rdf)
+	}
+
+	// Errors
+	buf.Reset()
+	df.WriteParquet(&buf)
+	if received := ReadParquet(bytes.NewReader([]byte("not parquet"))); received.Err == nil {
+		t.Errorf("Expected error, got:\n%v", received)
+	}
+	if received := ReadParquet(bytes.NewReader(buf.Bytes()), WithColumns("missing")); received.Err == nil {
+		t.Errorf("Expected error, got:\n%v", received)
+	}
+	if received := ReadParquet(bytes.NewReader(buf.Bytes()), WithRowGroups(1)); received.Err == nil {
+		t.Errorf("Expected error, got:\n%v", received)
+	}
+	if received := ReadParquet(bytes.NewReader(buf.Bytes()[:buf.Len()-20])); received.Err == nil {
+		t.Errorf("Expected error, got:\n%v", received)
+	}
+	if err := df.WriteParquet(&buf, WriteRowGroupRows(0)); err == nil {
+		t.Errorf("Expected error for an empty row group size")
+	}
+	if err := df.WriteParquet(&buf, WriteCompression(ParquetCodec(3))); err == nil {
+		t.Errorf("Expected error for an unsupported codec")
+	}
+	if err := (DataFrame{Err: os.ErrInvalid}).WriteParquet(&buf); err == nil {
+		t.Errorf("Expected error for a DataFrame with errors")
+	}
+}
+
+func TestParquet_Pages(t *testing.T) {
+	// Several pages, with long runs of definition levels and bit-packed ones
+	n := parquetPageRows + 100
+	values := make([]interface{}, n)
+	for i := range values {
+		if i < 1000 || i%3 != 0 {
+			values[i] = i
+		}
+	}
+	df := New(series.New(values, series.Int, "int"))
+	var buf bytes.Buffer
+	if err := df.WriteParquet(&buf); err != nil {
+		t.Fatal(err)
+	}
+	received := ReadParquet(bytes.NewReader(buf.Bytes()))
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	exp, _ := df.Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(exp, rr) {
+		t.Errorf("Different values")
+	}
+}
+
+// testParquetPage returns a page with its header
+func testParquetPage(typ int32, numValues int32, encoding int32, defLength int32, data []byte) []byte {
+	w := thriftWriter{}
+	w.open()
+	w.i32(1, typ)
+	w.i32(2, int32(len(data)))
+	w.i32(3, int32(len(data)))
+	switch typ {
+	case pageDictionary:
+		w.begin(7)
+		w.i32(1, numValues)
+		w.i32(2, encoding)
+	case pageData:
+		w.begin(5)
+		w.i32(1, numValues)
+		w.i32(2, encoding)
+		w.i32(3, encodingRLE)
+		w.i32(4, encodingRLE)
+	case pageDataV2:
+		w.begin(8)
+		w.i32(1, numValues)
+		w.i32(2, 0)
+		w.i32(3, numValues)
+		w.i32(4, encoding)
+		w.i32(5, defLength)
+		w.i32(6, 0)
+	}
+	w.end()
+	w.end()
+	return append(w.buf, data...)
+}
+
+// testParquetFile returns a file of a row group of numRows rows, holding a
+// chunk per leaf of the schema given by elements
+func testParquetFile(elements, leaves []parquetSchemaElement, chunks [][]byte, numRows int64, codec ParquetCodec) []byte {
+	file := []byte("PAR1")
+	rg := parquetRowGroup{numRows: numRows}
+	for i, chunk := range chunks {
+		rg.columns = append(rg.columns, parquetColumnChunk{
+			fileOffset: int64(len(file)),
+			meta: parquetColumnMetaData{
+				typ:                 leaves[i].typ,
+				path:                []string{leaves[i].name},
+				codec:               int32(codec),
+				numValues:           numRows,
+				totalCompressedSize: int64(len(chunk)),
+				dataPageOffset:      int64(len(file)),
+			},
+		})
+		file = append(file, chunk...)
+	}
+	meta := parquetFileMetaData{version: 1, schema: elements, numRows: numRows, rowGroups: []parquetRowGroup{rg}}
+	w := thriftWriter{}
+	meta.write(&w)
+	file = append(file, w.buf...)
+	file = appendUint32(file, uint32(len(w.buf)))
+	return append(file, "PAR1"...)
+}
+
+func TestParquet_Read(t *testing.T) {
+	// A file with the encodings, pages and types of other writers
+	optional := func(name string, typ int32, converted int32) parquetSchemaElement {
+		return parquetSchemaElement{name: name, typ: typ, repetition: repetitionOptional, convertedType: converted}
+	}
+	required := func(name string, typ int32, converted int32) parquetSchemaElement {
+		return parquetSchemaElement{name: name, typ: typ, repetition: repetitionRequired, convertedType: converted}
+	}
+	dec := required("decimal", parquetFixedLenByteArray, convertedDecimal)
+	dec.typeLength, dec.scale, dec.precision = 2, 2, 4
+	nested := parquetSchemaElement{name: "nested", typ: -1, repetition: repetitionOptional, convertedType: -1, numChildren: 1}
+	elements := []parquetSchemaElement{
+		{name: "schema", typ: -1, repetition: -1, convertedType: -1, numChildren: 7},
+		optional("dictionary", parquetByteArray, convertedUTF8),
+		optional("v2", parquetInt64, -1),
+		required("int96", parquetInt96, -1),
+		dec,
+		required("split", parquetFloat, -1),
+		nested,
+		required("child", parquetInt32, -1),
+		required("bools", parquetBoolean, -1),
+	}
+
+	split := make([]byte, 16)
+	for i, v := range []float32{1.5, -2, 0, 3.25} {
+		bits := math.Float32bits(v)
+		for k := 0; k < 4; k++ {
+			split[k*4+i] = byte(bits >> (8 * k))
+		}
+	}
+	int96 := func(day uint32, nanos uint64) []byte {
+		return appendUint32(appendUint64(nil, nanos), day)
+	}
+	chunks := [][]byte{
+		append(
+			testParquetPage(pageDictionary, 2, encodingPlain, 0, []byte("\x01\x00\x00\x00x\x01\x00\x00\x00y")),
+			// Definition levels 1, 0, 1, 1 and indexes 1, 0, 1
+			testParquetPage(pageData, 4, encodingRLEDictionary, 0, []byte("\x02\x00\x00\x00\x03\x0d\x01\x03\x05"))...,
+		),
+		// Definition levels 1, 1, 0, 1 and values 7, 5, 3
+		testParquetPage(pageDataV2, 4, encodingDeltaBinaryPacked, 2, []byte("\x03\x0b\x80\x01\x04\x03\x0e\x03\x00\x00\x00\x00")),
+		testParquetPage(pageData, 4, encodingPlain, 0, bytes.Join([][]byte{
+			int96(julianEpoch, 0), int96(julianEpoch+1, uint64(time.Hour)), int96(julianEpoch-1, 0), int96(julianEpoch+18779, uint64(time.Minute)),
+		}, nil)),
+		testParquetPage(pageData, 4, encodingPlain, 0, []byte("\x04\xd2\xff\x9c\x00\x00\x00\x01")),
+		testParquetPage(pageData, 4, encodingByteStreamSplit, 0, split),
+		testParquetPage(pageData, 4, encodingPlain, 0, []byte("\x01\x00\x00\x00\x02\x00\x00\x00\x03\x00\x00\x00\x04\x00\x00\x00")),
+		// RLE booleans of a data page v2: true, false, true, true
+		testParquetPage(pageDataV2, 4, encodingRLE, 0, []byte("\x02\x00\x00\x00\x03\x0d")),
+	}
+
+	leaves := append(elements[1:6:6], elements[7:]...)
+	file := testParquetFile(elements, leaves, chunks, 4, Parquet_UNCOMPRESSED)
+
+	received := ReadParquet(bytes.NewReader(file), WithColumns("dictionary", "v2", "int96", "decimal", "split", "bools"))
+	if received.Err != nil {
+		t.Fatal(received.Err)
+	}
+	day := 24 * time.Hour
+	expected := New(
+		series.New([]interface{}{"y", nil, "x", "y"}, series.String, "dictionary"),
+		series.New([]interface{}{7, 5, nil, 3}, series.Int, "v2"),
+		series.New([]time.Time{
+			time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC().Add(day + time.Hour), time.Unix(0, 0).UTC().Add(-day), time.Date(2021, 6, 1, 0, 1, 0, 0, time.UTC),
+		}, series.Time, "int96"),
+		series.New([]float64{12.34, -1, 0, 0.01}, series.Float, "decimal"),
+		series.New([]float64{1.5, -2, 0, 3.25}, series.Float, "split"),
+		series.New([]bool{true, false, true, true}, series.Bool, "bools"),
+	)
+	exp, _ := expected.Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(exp, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", exp, rr)
+	}
+
+	// Nested columns can't be read
+	if received := ReadParquet(bytes.NewReader(file)); received.Err == nil || !strings.Contains(received.Err.Error(), "nested") {
+		t.Errorf("Expected error for a nested column, got:\n%v", received)
+	}
+	if _, err := ReadParquetSchema(bytes.NewReader(file)); err == nil {
+		t.Errorf("Expected error for a nested column")
+	}
+}
+
+func TestParquet_Corrupted(t *testing.T) {
+	// Corrupted files give errors rather than panics or huge allocations
+	column := func(typ int32, typeLength int32) []parquetSchemaElement {
+		return []parquetSchemaElement{
+			{name: "schema", typ: -1, repetition: -1, convertedType: -1, numChildren: 1},
+			{name: "a", typ: typ, typeLength: typeLength, repetition: repetitionRequired, convertedType: -1},
+		}
+	}
+	// A page header claiming a large uncompressed size
+	w := thriftWriter{}
+	w.open()
+	w.i32(1, pageData)
+	w.i32(2, 1<<30)
+	w.i32(3, 4)
+	w.begin(5)
+	w.i32(1, 1)
+	w.i32(2, encodingPlain)
+	w.i32(3, encodingRLE)
+	w.i32(4, encodingRLE)
+	w.end()
+	w.end()
+	expanded := append(w.buf, 0, 0, 0, 0)
+
+	tests := []struct {
+		elements []parquetSchemaElement
+		chunk    []byte
+		codec    ParquetCodec
+		expected string
+	}{
+		{column(parquetFixedLenByteArray, 0), testParquetPage(pageData, 1, encodingPlain, 0, []byte("x")), Parquet_UNCOMPRESSED, "FIXED_LEN_BYTE_ARRAY length"},
+		{column(parquetFixedLenByteArray, -2), testParquetPage(pageData, 1, encodingPlain, 0, []byte("x")), Parquet_UNCOMPRESSED, "FIXED_LEN_BYTE_ARRAY length"},
+		{column(parquetByteArray, 0), testParquetPage(pageData, 1<<30, encodingPlain, 0, []byte("\x01\x00\x00\x00x")), Parquet_UNCOMPRESSED, "values"},
+		{column(parquetInt32, 0), testParquetPage(pageDictionary, 1<<30, encodingPlain, 0, nil), Parquet_UNCOMPRESSED, "values"},
+		{column(parquetInt32, 0), expanded, Parquet_LZ4_RAW, "bytes compressed"},
+		{column(parquetInt32, 0), expanded, Parquet_ZSTD, "bytes compressed"},
+	}
+	for i, test := range tests {
+		file := testParquetFile(test.elements, test.elements[1:], [][]byte{test.chunk}, 1, test.codec)
+		received := ReadParquet(bytes.NewReader(file))
+		if received.Err == nil || !strings.Contains(received.Err.Error(), test.expected) {
+			t.Errorf("Test: %d\nExpected error containing %q, received %v", i, test.expected, received.Err)
+		}
+	}
+}
+
+func TestParquet_Encodings(t *testing.T) {
+	// Examples of https://github.com/apache/parquet-format/blob/master/Encodings.md
+	ints, _, err := decodeDeltaBinaryPacked([]byte("\x80\x01\x04\x05\x02\x02\x00\x00\x00\x00"), 5)
+	if err != nil || !reflect.DeepEqual(ints, []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected 1, 2, 3, 4, 5, received %v (%v)", ints, err)
+	}
+	ints, _, err = decodeDeltaBinaryPacked([]byte("\x80\x01\x04\x08\x0e\x03\x02\x00\x00\x00\xc0\x3f\x00\x00\x00\x00\x00\x00"), 8)
+	if err != nil || !reflect.DeepEqual(ints, []int64{7, 5, 3, 1, 2, 3, 4, 5}) {
+		t.Errorf("Expected 7, 5, 3, 1, 2, 3, 4, 5, received %v (%v)", ints, err)
+	}
+	values, err := decodeDeltaLengthByteArray([]byte("\x80\x01\x04\x04\x0a\x00\x01\x00\x00\x00\x02\x00\x00\x00HelloWorldFoobarABCDEF"), 4)
+	if err != nil || !reflect.DeepEqual(values, [][]byte{[]byte("Hello"), []byte("World"), []byte("Foobar"), []byte("ABCDEF")}) {
+		t.Errorf("Expected Hello, World, Foobar, ABCDEF, received %q (%v)", values, err)
+	}
+	prefixes := "\x80\x01\x04\x04\x00\x03\x03\x00\x00\x00\x44\x01" + strings.Repeat("\x00", 10)
+	suffixes := "\x80\x01\x04\x04\x08\x03\x03\x00\x00\x00\x70" + strings.Repeat("\x00", 11) + "axislebabbleyhood"
+	values, err = decodeDeltaByteArray([]byte(prefixes+suffixes), 4)
+	if err != nil || !reflect.DeepEqual(values, [][]byte{[]byte("axis"), []byte("axle"), []byte("babble"), []byte("babyhood")}) {
+		t.Errorf("Expected axis, axle, babble, babyhood, received %q (%v)", values, err)
+	}
+	if _, _, err := decodeDeltaBinaryPacked([]byte("\x80\x01\x04\x08\x0e\x03\x02"), 8); err == nil {
+		t.Errorf("Expected error for truncated data")
+	}
+
+	// The hybrid encoding round trips with runs and bit-packed values
+	levels := []int32{0, 1, 2, 3, 3, 3, 3, 3, 3, 3, 3, 3, 1, 0, 7, 7}
+	for width := 3; width <= 12; width += 9 {
+		encoded := appendHybrid(nil, levels, width)
+		decoded, err := decodeHybrid(encoded, width, len(levels))
+		if err != nil || !reflect.DeepEqual(decoded, levels) {
+			t.Errorf("Width %d\nExpected %v, received %v (%v)", width, levels, decoded, err)
+		}
+	}
+	if _, err := decodeHybrid([]byte{0x03}, 1, 8); err == nil {
+		t.Errorf("Expected error for truncated data")
+	}
+}
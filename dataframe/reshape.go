@@ -0,0 +1,178 @@
+package dataframe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+// Pivot reshapes the DataFrame from long to wide format. The result has a row
+// per distinct value of the index columns and a column per distinct value of
+// the columns columns, holding the aggregation of the values column for the
+// rows matching both. With several values columns, the name of each new
+// column is prefixed by the values column it comes from. Rows and columns keep
+// the order in which their keys first appear, and missing combinations are
+// non-valid.
+func (df DataFrame) Pivot(index, columns, values []string, agg AggregationType) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	if len(index) == 0 || len(columns) == 0 || len(values) == 0 {
+		return DataFrame{Err: fmt.Errorf("pivot: index, columns and values can't be empty")}
+	}
+	rowGroups := df.GroupBy(index...)
+	colGroups := df.GroupBy(columns...)
+	cells := df.GroupBy(append(append([]string{}, index...), columns...)...)
+	for _, gps := range []*Groups{rowGroups, colGroups, cells} {
+		if gps.Err != nil {
+			return DataFrame{Err: fmt.Errorf("pivot: %v", gps.Err)}
+		}
+	}
+	aggs := make([]Agg, len(values))
+	for i, v := range values {
+		aggs[i] = NewAgg(v, agg)
+	}
+	aggregated := cells.Aggregate(aggs...)
+	if aggregated.Err != nil {
+		return DataFrame{Err: fmt.Errorf("pivot: %v", aggregated.Err)}
+	}
+
+	rowOf := groupOfRows(rowGroups.indices, df.nrows)
+	colOf := groupOfRows(colGroups.indices, df.nrows)
+	colnames := groupNames(colGroups.keys)
+
+	ret := rowGroups.Keys().columns
+	nkeys := len(index) + len(columns)
+	for v, value := range values {
+		src := aggregated.columns[nkeys+v]
+		spread := make([]series.Series, len(colnames))
+		for c, name := range colnames {
+			if len(values) > 1 {
+				name = value + "_" + name
+			}
+			spread[c] = series.New(nil, src.Type(), name, len(rowGroups.indices))
+		}
+		for g, rows := range cells.indices {
+			r, c := rowOf[rows[0]], colOf[rows[0]]
+			spread[c].Set(r, src.Elem(g))
+		}
+		ret = append(ret, spread...)
+	}
+	return New(ret...)
+}
+
+// Melt reshapes the DataFrame from wide to long format. For every one of the
+// valueVars columns, the rows of the idVars columns are repeated next to a
+// varName column holding the name of the column and a valueName column holding
+// its values. If valueVars is empty, all the columns not in idVars are used.
+// The values keep their type if all valueVars share it, otherwise they are
+// converted to String. Empty names default to "variable" and "value".
+func (df DataFrame) Melt(idVars, valueVars []string, varName, valueName string) DataFrame {
+	if df.Err != nil {
+		return df
+	}
+	for _, c := range idVars {
+		if findInStringSlice(c, df.Names()) < 0 {
+			return DataFrame{Err: fmt.Errorf("melt: can't find column name: %s", c)}
+		}
+	}
+	if len(valueVars) == 0 {
+		for _, c := range df.Names() {
+			if findInStringSlice(c, idVars) < 0 {
+				valueVars = append(valueVars, c)
+			}
+		}
+	}
+	if len(valueVars) == 0 {
+		return DataFrame{Err: fmt.Errorf("melt: no value columns")}
+	}
+	if varName == "" {
+		varName = "variable"
+	}
+	if valueName == "" {
+		valueName = "value"
+	}
+
+	t := df.Col(valueVars[0]).Type()
+	for _, c := range valueVars {
+		idx := findInStringSlice(c, df.Names())
+		if idx < 0 {
+			return DataFrame{Err: fmt.Errorf("melt: can't find column name: %s", c)}
+		}
+		if df.columns[idx].Type() != t {
+			t = series.String
+		}
+	}
+
+	rows := make([]int, 0, df.nrows*len(valueVars))
+	names := make([]string, 0, df.nrows*len(valueVars))
+	value := series.New([]string{}, t, valueName)
+	for _, c := range valueVars {
+		for i := 0; i < df.nrows; i++ {
+			rows = append(rows, i)
+			names = append(names, c)
+		}
+		value.Append(df.Col(c))
+		if value.Err != nil {
+			return DataFrame{Err: fmt.Errorf("melt: %v", value.Err)}
+		}
+	}
+	var ret []series.Series
+	if len(idVars) > 0 {
+		ret = df.Select(idVars).Subset(rows).columns
+	}
+	ret = append(ret, series.New(names, series.String, varName), value)
+	return New(ret...)
+}
+
+// Stack moves all the columns but the idVars into rows, as Melt does with the
+// default "variable" and "value" column names
+func (df DataFrame) Stack(idVars ...string) DataFrame {
+	return df.Melt(idVars, nil, "", "")
+}
+
+// Unstack is the inverse of Stack. The values of the valueName column are
+// spread into a column per distinct value of the varName column, with a row per
+// distinct value of the index columns. If several rows match, the first valid
+// value is kept.
+func (df DataFrame) Unstack(index []string, varName, valueName string) DataFrame {
+	if varName == "" {
+		varName = "variable"
+	}
+	if valueName == "" {
+		valueName = "value"
+	}
+	return df.Pivot(index, []string{varName}, []string{valueName}, Aggregation_FIRST)
+}
+
+// groupOfRows returns the group of each row, given the rows of each group
+func groupOfRows(indices [][]int, nrows int) []int {
+	ret := make([]int, nrows)
+	for g, rows := range indices {
+		for _, i := range rows {
+			ret[i] = g
+		}
+	}
+	return ret
+}
+
+// groupNames builds a column name for every group key, joining the values of
+// the key columns with "_". Non-valid values are written as NaN.
+func groupNames(keys DataFrame) []string {
+	ret := make([]string, keys.nrows)
+	for g := range ret {
+		parts := make([]string, keys.ncols)
+		for j := range parts {
+			e := keys.columns[j].Elem(g)
+			parts[j] = series.NaN
+			if e.IsValid() {
+				if s, err := e.String(); err == nil {
+					parts[j] = s
+				}
+			}
+		}
+		ret[g] = strings.Join(parts, "_")
+	}
+	return ret
+}
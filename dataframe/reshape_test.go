@@ -0,0 +1,143 @@
+package dataframe
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+func TestDataFrame_Pivot(t *testing.T) {
+	a := New(
+		series.New([]string{"x", "x", "y", "y", "x"}, series.String, "id"),
+		series.New([]string{"a", "b", "a", "a", "a"}, series.String, "var"),
+		series.New([]int{1, 2, 3, 4, 5}, series.Int, "v1"),
+		series.New([]float64{0.5, 1.5, 2.5, 3.5, 4.5}, series.Float, "v2"),
+	)
+	table := []struct {
+		df       DataFrame
+		expTypes []series.Type
+		expected [][]string
+	}{
+		{
+			a.Pivot([]string{"id"}, []string{"var"}, []string{"v1"}, Aggregation_SUM),
+			[]series.Type{series.String, series.Float, series.Float},
+			[][]string{
+				{"id", "a", "b"},
+				{"x", "6.000000", "2.000000"},
+				{"y", "7.000000", ""},
+			},
+		},
+		{
+			a.Pivot([]string{"id"}, []string{"var"}, []string{"v1", "v2"}, Aggregation_FIRST),
+			[]series.Type{series.String, series.Int, series.Int, series.Float, series.Float},
+			[][]string{
+				{"id", "v1_a", "v1_b", "v2_a", "v2_b"},
+				{"x", "1", "2", "0.500000", "1.500000"},
+				{"y", "3", "", "2.500000", ""},
+			},
+		},
+		{
+			a.Pivot([]string{"var"}, []string{"id"}, []string{"v1"}, Aggregation_COUNT),
+			[]series.Type{series.String, series.Int, series.Int},
+			[][]string{
+				{"var", "x", "y"},
+				{"a", "2", "2"},
+				{"b", "1", ""},
+			},
+		},
+	}
+	for i, tc := range table {
+		if tc.df.Err != nil {
+			t.Errorf("Test: %d\nError:%v", i, tc.df.Err)
+			continue
+		}
+		if !reflect.DeepEqual(tc.expTypes, tc.df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expTypes, tc.df.Types())
+		}
+		received, _ := tc.df.Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, received)
+		}
+	}
+
+	if b := a.Pivot([]string{"id"}, []string{"missing"}, []string{"v1"}, Aggregation_SUM); b.Err == nil {
+		t.Error("Expected error for a missing column")
+	}
+}
+
+func TestDataFrame_Melt(t *testing.T) {
+	a := New(
+		series.New([]string{"x", "y"}, series.String, "id"),
+		series.New([]int{1, 2}, series.Int, "a"),
+		series.New([]int{3, 4}, series.Int, "b"),
+		series.New([]float64{0.5, 1.5}, series.Float, "c"),
+	)
+	table := []struct {
+		df       DataFrame
+		expTypes []series.Type
+		expected [][]string
+	}{
+		{
+			a.Melt([]string{"id"}, []string{"a", "b"}, "", ""),
+			[]series.Type{series.String, series.String, series.Int},
+			[][]string{
+				{"id", "variable", "value"},
+				{"x", "a", "1"},
+				{"y", "a", "2"},
+				{"x", "b", "3"},
+				{"y", "b", "4"},
+			},
+		},
+		{
+			a.Melt([]string{"id"}, nil, "col", "val"),
+			[]series.Type{series.String, series.String, series.String},
+			[][]string{
+				{"id", "col", "val"},
+				{"x", "a", "1"},
+				{"y", "a", "2"},
+				{"x", "b", "3"},
+				{"y", "b", "4"},
+				{"x", "c", "0.500000"},
+				{"y", "c", "1.500000"},
+			},
+		},
+		{
+			a.Select([]string{"a", "b"}).Stack(),
+			[]series.Type{series.String, series.Int},
+			[][]string{
+				{"variable", "value"},
+				{"a", "1"},
+				{"a", "2"},
+				{"b", "3"},
+				{"b", "4"},
+			},
+		},
+		{
+			a.Select([]string{"id", "a", "b"}).Stack("id").Unstack([]string{"id"}, "", ""),
+			[]series.Type{series.String, series.Int, series.Int},
+			[][]string{
+				{"id", "a", "b"},
+				{"x", "1", "3"},
+				{"y", "2", "4"},
+			},
+		},
+	}
+	for i, tc := range table {
+		if tc.df.Err != nil {
+			t.Errorf("Test: %d\nError:%v", i, tc.df.Err)
+			continue
+		}
+		if !reflect.DeepEqual(tc.expTypes, tc.df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, tc.expTypes, tc.df.Types())
+		}
+		received, _ := tc.df.Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, received)
+		}
+	}
+
+	if b := a.Melt([]string{"missing"}, nil, "", ""); b.Err == nil {
+		t.Error("Expected error for a missing column")
+	}
+}
@@ -0,0 +1,222 @@
+package dataframe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+// RollingWindow is used for rolling window calculations over every numeric
+// column of a DataFrame, see DataFrame.Rolling and Groups.Rolling.
+//
+// Windows are taken over the rows ordered by the on column, or in their
+// current order if on is empty, and within each group for Groups. Every
+// calculation returns the DataFrame with its Int, Uint and Float columns
+// replaced by the Float results, aligned to the original rows. The on column,
+// the group keys and the non-numeric columns are left as they are.
+type RollingWindow struct {
+	df         DataFrame
+	indices    [][]int // the rows of every group, or nil for a single group
+	keys       []string
+	on         string
+	window     int
+	byTime     bool
+	duration   time.Duration
+	minPeriods int
+	setMin     bool
+	center     bool
+	err        error
+}
+
+// Rolling creates a new RollingWindow of window rows, ordered by the on
+// column. As in series.Series.Rolling, MinPeriods defaults to window.
+func (df DataFrame) Rolling(window int, on string) RollingWindow {
+	return RollingWindow{
+		df:     df,
+		on:     on,
+		window: window,
+		err:    df.Err,
+	}
+}
+
+// RollingTime creates a new RollingWindow holding the rows with a time in
+// (t-window, t], t being the time of each row on the on column, which must be
+// a Time column with no non-valid elements. As in
+// series.Series.RollingTime, MinPeriods defaults to 1.
+func (df DataFrame) RollingTime(window time.Duration, on string) RollingWindow {
+	r := df.Rolling(0, on)
+	r.byTime = true
+	r.duration = window
+	if r.err == nil && on == "" {
+		r.err = fmt.Errorf("rolling: time windows need an on column")
+	}
+	return r
+}
+
+// Rolling creates a new RollingWindow of window rows within every group,
+// see DataFrame.Rolling.
+func (gps Groups) Rolling(window int, on string) RollingWindow {
+	r := gps.df.Rolling(window, on)
+	r.indices = gps.indices
+	r.keys = gps.colnames
+	if gps.Err != nil {
+		r.err = fmt.Errorf("rolling: %v", gps.Err)
+	}
+	return r
+}
+
+// RollingTime creates a new RollingWindow of a duration within every group,
+// see DataFrame.RollingTime.
+func (gps Groups) RollingTime(window time.Duration, on string) RollingWindow {
+	r := gps.df.RollingTime(window, on)
+	r.indices = gps.indices
+	r.keys = gps.colnames
+	if gps.Err != nil {
+		r.err = fmt.Errorf("rolling: %v", gps.Err)
+	}
+	return r
+}
+
+// MinPeriods sets the minimum number of valid elements a window must hold
+// to yield a value
+func (r RollingWindow) MinPeriods(n int) RollingWindow {
+	r.minPeriods = n
+	r.setMin = true
+	return r
+}
+
+// Center sets whether the windows are centered on each row, instead of
+// ending on it.
+func (r RollingWindow) Center(center bool) RollingWindow {
+	r.center = center
+	return r
+}
+
+// Mean returns the rolling mean.
+func (r RollingWindow) Mean() DataFrame {
+	return r.calc(series.RollingWindow.Mean)
+}
+
+// StdDev returns the rolling standard deviation.
+func (r RollingWindow) StdDev() DataFrame {
+	return r.calc(series.RollingWindow.StdDev)
+}
+
+// Var returns the rolling unbiased variance.
+func (r RollingWindow) Var() DataFrame {
+	return r.calc(series.RollingWindow.Var)
+}
+
+// Sum returns the rolling sum.
+func (r RollingWindow) Sum() DataFrame {
+	return r.calc(series.RollingWindow.Sum)
+}
+
+// Count returns the number of valid elements of each window.
+func (r RollingWindow) Count() DataFrame {
+	return r.calc(series.RollingWindow.Count)
+}
+
+// Min returns the rolling minimum.
+func (r RollingWindow) Min() DataFrame {
+	return r.calc(series.RollingWindow.Min)
+}
+
+// Max returns the rolling maximum.
+func (r RollingWindow) Max() DataFrame {
+	return r.calc(series.RollingWindow.Max)
+}
+
+// Median returns the rolling median.
+func (r RollingWindow) Median() DataFrame {
+	return r.calc(series.RollingWindow.Median)
+}
+
+// Quantile returns the rolling quantile p.
+func (r RollingWindow) Quantile(p float64) DataFrame {
+	return r.calc(func(w series.RollingWindow) series.Series { return w.Quantile(p) })
+}
+
+// Apply returns the result of f over every window of every numeric column.
+func (r RollingWindow) Apply(f func(series.Series) float64) DataFrame {
+	return r.calc(func(w series.RollingWindow) series.Series { return w.Apply(f) })
+}
+
+func (r RollingWindow) calc(f func(series.RollingWindow) series.Series) DataFrame {
+	if r.err != nil {
+		return DataFrame{Err: r.err}
+	}
+	df := r.df
+	iOn := -1
+	if r.on != "" {
+		iOn = findInStringSlice(r.on, df.Names())
+		if iOn < 0 {
+			return DataFrame{Err: fmt.Errorf("rolling: can't find column name: %s", r.on)}
+		}
+		if r.byTime && df.columns[iOn].Type() != series.Time {
+			return DataFrame{Err: fmt.Errorf("rolling: on column %q must be a Time column, got %v", r.on, df.columns[iOn].Type())}
+		}
+	}
+	indices := r.indices
+	if indices == nil {
+		rows := make([]int, df.nrows)
+		for i := range rows {
+			rows[i] = i
+		}
+		indices = [][]int{rows}
+	}
+
+	// Order the rows of every group by the on column
+	sorted := make([][]int, len(indices))
+	for g, rows := range indices {
+		sorted[g] = rows
+		if iOn >= 0 {
+			order := df.columns[iOn].Subset(rows).Order(false)
+			sorted[g] = make([]int, len(rows))
+			for k, j := range order {
+				sorted[g][k] = rows[j]
+			}
+		}
+	}
+
+	ret := df.Copy()
+	for i, col := range df.columns {
+		switch col.Type() {
+		case series.Int, series.Uint, series.Float:
+		default:
+			continue
+		}
+		if i == iOn || findInStringSlice(col.Name, r.keys) >= 0 {
+			continue
+		}
+		vals := make([]float64, df.nrows)
+		for _, rows := range sorted {
+			res := f(r.rolling(col.Subset(rows), df, iOn, rows))
+			if res.Err != nil {
+				return DataFrame{Err: res.Err}
+			}
+			resVals, _ := res.Float(true)
+			for k, j := range rows {
+				vals[j] = resVals[k]
+			}
+		}
+		ret.columns[i] = series.New(vals, series.Float, col.Name)
+	}
+	return ret
+}
+
+// rolling returns the series.RollingWindow over s, the values of a column on
+// the given rows of df
+func (r RollingWindow) rolling(s series.Series, df DataFrame, iOn int, rows []int) series.RollingWindow {
+	var w series.RollingWindow
+	if r.byTime {
+		w = s.RollingTime(r.duration, df.columns[iOn].Subset(rows))
+	} else {
+		w = s.Rolling(r.window)
+	}
+	if r.setMin {
+		w = w.MinPeriods(r.minPeriods)
+	}
+	return w.Center(r.center)
+}
@@ -0,0 +1,91 @@
+package dataframe
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Paradigm4/gota/series"
+)
+
+func TestDataFrame_Rolling(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 6, d, 0, 0, 0, 0, time.UTC) }
+	df := New(
+		series.New([]string{"a", "b", "a", "a", "b", "a"}, series.String, "customer"),
+		series.New([]time.Time{day(3), day(1), day(1), day(2), day(5), day(10)}, series.Time, "day"),
+		series.New([]int{30, 5, 10, 20, 7, 100}, series.Int, "amount"),
+	)
+	nan := math.NaN()
+	table := []struct {
+		df       DataFrame
+		expected []float64
+	}{
+		{
+			df.Rolling(2, "day").Mean(),
+			[]float64{25, nan, 7.5, 15, 18.5, 53.5},
+		},
+		{
+			df.Rolling(2, "").MinPeriods(1).Sum(),
+			[]float64{30, 35, 15, 30, 27, 107},
+		},
+		{
+			df.GroupBy("customer").Rolling(2, "day").Sum(),
+			[]float64{50, nan, nan, 30, 12, 130},
+		},
+		{
+			df.GroupBy("customer").RollingTime(3*24*time.Hour, "day").Mean(),
+			[]float64{20, 5, 10, 15, 7, 100},
+		},
+		{
+			df.GroupBy("customer").Rolling(3, "day").Center(true).MinPeriods(1).Max(),
+			[]float64{100, 7, 20, 30, 7, 100},
+		},
+	}
+	for i, tc := range table {
+		if err := tc.df.Err; err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, err)
+		}
+		if expTypes := []series.Type{series.String, series.Time, series.Float}; !reflect.DeepEqual(expTypes, tc.df.Types()) {
+			t.Errorf("Test: %d\nDifferent types:\nA:%v\nB:%v", i, expTypes, tc.df.Types())
+		}
+		received, _ := tc.df.Col("amount").Float(true)
+		for j := range received {
+			if math.IsNaN(received[j]) != math.IsNaN(tc.expected[j]) ||
+				!math.IsNaN(received[j]) && received[j] != tc.expected[j] {
+				t.Errorf("Test: %d\nDifferent values:\nA:%v\nB:%v", i, tc.expected, received)
+				break
+			}
+		}
+	}
+
+	// The on column and the group keys are left as they are
+	keys := New(
+		series.New([]int{1, 1, 2}, series.Int, "key"),
+		series.New([]float64{1, 2, 3}, series.Float, "x"),
+	)
+	received := keys.GroupBy("key").Rolling(2, "").MinPeriods(1).Sum()
+	expected := New(
+		series.New([]int{1, 1, 2}, series.Int, "key"),
+		series.New([]float64{1, 3, 3}, series.Float, "x"),
+	)
+	er, _ := expected.Records(true)
+	rr, _ := received.Records(true)
+	if !reflect.DeepEqual(er, rr) {
+		t.Errorf("Different values:\nA:%v\nB:%v", expected, received)
+	}
+
+	errors := []DataFrame{
+		df.Rolling(2, "date").Mean(),
+		df.RollingTime(time.Hour, "amount").Mean(),
+		df.RollingTime(time.Hour, "").Mean(),
+		df.Rolling(-1, "day").Mean(),
+		df.Rolling(2, "day").Quantile(2),
+		df.GroupBy("region").Rolling(2, "day").Mean(),
+	}
+	for i, received := range errors {
+		if received.Err == nil {
+			t.Errorf("Test: %d\nExpected error, got:\n%v", i, received)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 module github.com/Paradigm4/gota
 
-go 1.16
+go 1.18
 
 require (
 	github.com/apache/arrow/go/arrow v0.0.0-20210618182047-4743e181596b
-	gonum.org/v1/gonum v0.9.2
+	github.com/klauspost/compress v1.11.13
+	github.com/pierrec/lz4/v4 v4.1.4
 	golang.org/x/net v0.0.0-20210423184538-5f58ad60dda6
+	gonum.org/v1/gonum v0.9.2
+)
 
+require (
+	github.com/google/flatbuffers v1.11.0 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
@@ -0,0 +1,248 @@
+package series
+
+import (
+	"fmt"
+	"math"
+)
+
+// Add returns the element-wise sum of the Series and x.
+//
+// Add, Sub, Mul, Div, Mod and Pow take either a Series or values to be
+// converted to a Series of the type matching their Go type. As in Compare,
+// the operand must hold a single element, which is used for every element of
+// the Series, or as many elements as the Series.
+//
+// Bool elements count as Int 0 and 1. Operations between Int and Uint elements
+// give Int, and any Float element promotes the result to Float, as setting an
+// Int element to a float value would lose precision. String, Categorical and
+// Time Series are not supported. Non-valid elements on either side give
+// non-valid elements, and NaN elements give NaN.
+func (s Series) Add(x interface{}) Series {
+	return s.arithmetic("add", x, false,
+		func(a, b int64) (int64, bool) { return a + b, true },
+		func(a, b uint64) (uint64, bool) { return a + b, true },
+		func(a, b float64) float64 { return a + b },
+	)
+}
+
+// Sub returns the element-wise difference of the Series and x, as in Add.
+func (s Series) Sub(x interface{}) Series {
+	return s.arithmetic("subtract", x, false,
+		func(a, b int64) (int64, bool) { return a - b, true },
+		func(a, b uint64) (uint64, bool) { return a - b, true },
+		func(a, b float64) float64 { return a - b },
+	)
+}
+
+// Mul returns the element-wise product of the Series and x, as in Add.
+func (s Series) Mul(x interface{}) Series {
+	return s.arithmetic("multiply", x, false,
+		func(a, b int64) (int64, bool) { return a * b, true },
+		func(a, b uint64) (uint64, bool) { return a * b, true },
+		func(a, b float64) float64 { return a * b },
+	)
+}
+
+// Div returns the element-wise quotient of the Series and x as a Float
+// Series, as in Add.
+func (s Series) Div(x interface{}) Series {
+	return s.arithmetic("divide", x, true, nil, nil,
+		func(a, b float64) float64 { return a / b },
+	)
+}
+
+// Mod returns the element-wise remainder of the Series divided by x, as in
+// Add. Integer remainders of a division by zero are NaN.
+func (s Series) Mod(x interface{}) Series {
+	return s.arithmetic("mod", x, false,
+		func(a, b int64) (int64, bool) {
+			if b == 0 {
+				return 0, false
+			}
+			return a % b, true
+		},
+		func(a, b uint64) (uint64, bool) {
+			if b == 0 {
+				return 0, false
+			}
+			return a % b, true
+		},
+		math.Mod,
+	)
+}
+
+// Pow returns the Series raised element-wise to the power of x as a Float
+// Series, as in Add.
+func (s Series) Pow(x interface{}) Series {
+	return s.arithmetic("pow", x, true, nil, nil, math.Pow)
+}
+
+// Neg returns the Series with every element negated. Uint and Bool Series
+// give Int.
+func (s Series) Neg() Series {
+	return s.arithmetic("negate", nil, false,
+		func(a, _ int64) (int64, bool) { return -a, true },
+		nil,
+		func(a, _ float64) float64 { return -a },
+	)
+}
+
+// Abs returns the absolute value of every element. Bool Series give Int.
+func (s Series) Abs() Series {
+	return s.arithmetic("abs", nil, false,
+		func(a, _ int64) (int64, bool) {
+			if a < 0 {
+				return -a, true
+			}
+			return a, true
+		},
+		func(a, _ uint64) (uint64, bool) { return a, true },
+		func(a, _ float64) float64 { return math.Abs(a) },
+	)
+}
+
+// arithmetic applies the function matching the type of the result to the
+// elements of s and x. A nil x applies them to s alone, and float forces a
+// Float result.
+func (s Series) arithmetic(name string, x interface{}, float bool, fInt func(a, b int64) (int64, bool), fUint func(a, b uint64) (uint64, bool), fFloat func(a, b float64) float64) Series {
+	if err := s.Err; err != nil {
+		return s
+	}
+	fail := func(err error) Series {
+		s = s.Empty()
+		s.Err = err
+		return s
+	}
+	other := s
+	if x != nil {
+		other = operand(x)
+		if err := other.Err; err != nil {
+			return fail(fmt.Errorf("can't %s: argument has errors: %v", name, err))
+		}
+		if other.Len() != 1 && other.Len() != s.Len() {
+			return fail(fmt.Errorf("can't %s: length mismatch", name))
+		}
+	}
+	t, err := arithmeticType(s.t, other.t)
+	if err != nil {
+		return fail(fmt.Errorf("can't %s: %v", name, err))
+	}
+	if float {
+		t = Float
+	} else if t == Uint && fUint == nil {
+		t = Int
+	}
+
+	n := s.Len()
+	step := 1
+	if other.Len() == 1 && n != 1 {
+		step = 0
+	}
+	validA, validB := validBitmap(s.elements), validBitmap(other.elements)
+	ret := Series{Name: s.Name, t: t}
+	switch t {
+	case Int:
+		a, nanA := intOperand(s)
+		b, nanB := intOperand(other)
+		elements := newIntElements(n)
+		for i := 0; i < n; i++ {
+			j := i * step
+			if !validA.get(i) || !validB.get(j) {
+				continue
+			}
+			v, ok := fInt(a[i], b[j])
+			elements.store(i, IntElement{v, true, !ok || nanA[i] || nanB[j]})
+		}
+		ret.elements = elements
+	case Uint:
+		a, b := native(s.elements).(uintElements), native(other.elements).(uintElements)
+		elements := newUintElements(n)
+		for i := 0; i < n; i++ {
+			j := i * step
+			if !validA.get(i) || !validB.get(j) {
+				continue
+			}
+			v, ok := fUint(a.data[i], b.data[j])
+			elements.store(i, uintElement{v, true, !ok || a.nan.get(i) || b.nan.get(j)})
+		}
+		ret.elements = elements
+	default:
+		a, _ := s.Float(true)
+		b, _ := other.Float(true)
+		elements := newFloatElements(n)
+		for i := 0; i < n; i++ {
+			j := i * step
+			if !validA.get(i) || !validB.get(j) {
+				continue
+			}
+			elements.store(i, floatElement{fFloat(a[i], b[j]), true})
+		}
+		ret.elements = elements
+	}
+	return ret
+}
+
+// operand returns x as a Series, converting values other than a Series to the
+// type matching their Go type
+func operand(x interface{}) Series {
+	if s, ok := x.(Series); ok {
+		return s
+	}
+	t := Float
+	switch x.(type) {
+	case int, int8, int16, int32, int64, []int, []int8, []int16, []int32, []int64:
+		t = Int
+	case uint, uint8, uint16, uint32, uint64, []uint, []uint8, []uint16, []uint32, []uint64:
+		t = Uint
+	case bool, []bool:
+		t = Bool
+	}
+	return New(x, t, "")
+}
+
+// arithmeticType returns the type of the result of an operation between
+// elements of types a and b
+func arithmeticType(a, b Type) (Type, error) {
+	for _, t := range []Type{a, b} {
+		switch t {
+		case Int, Uint, Float, Bool:
+		default:
+			return "", fmt.Errorf("not supported for %v series", t)
+		}
+	}
+	switch {
+	case a == Float || b == Float:
+		return Float, nil
+	case a == Uint && b == Uint:
+		return Uint, nil
+	default:
+		return Int, nil
+	}
+}
+
+// intOperand returns the elements of an Int, Uint or Bool Series as int64,
+// along with their NaN flags
+func intOperand(s Series) ([]int64, []bool) {
+	n := s.Len()
+	data := make([]int64, n)
+	nan := make([]bool, n)
+	switch e := native(s.elements).(type) {
+	case intElements:
+		copy(data, e.data)
+		for i := range nan {
+			nan[i] = e.nan.get(i)
+		}
+	case uintElements:
+		for i, v := range e.data {
+			data[i] = int64(v)
+			nan[i] = e.nan.get(i)
+		}
+	case boolElements:
+		for i, v := range e.data {
+			if v {
+				data[i] = 1
+			}
+		}
+	}
+	return data, nan
+}
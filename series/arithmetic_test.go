@@ -0,0 +1,81 @@
+package series
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSeries_Arithmetic(t *testing.T) {
+	ints := New([]interface{}{1, -2, nil, "NaN", 5}, Int, "A")
+	floats := New([]interface{}{0.5, 2.0, 3.0, 4.0, nil}, Float, "B")
+	uints := New([]uint{7, 2, 3, 4, 5}, Uint, "C")
+	table := []struct {
+		received Series
+		expType  Type
+		expected []string
+	}{
+		{ints.Add(ints), Int, []string{"2", "-4", "", "NaN", "10"}},
+		{ints.Add(1), Int, []string{"2", "-1", "", "NaN", "6"}},
+		{ints.Add(1.5), Float, []string{"2.500000", "-0.500000", "", "NaN", "6.500000"}},
+		{ints.Add(floats), Float, []string{"1.500000", "0.000000", "", "NaN", ""}},
+		{ints.Sub([]int{1, 1, 1, 1, 1}), Int, []string{"0", "-3", "", "NaN", "4"}},
+		{ints.Mul(uints), Int, []string{"7", "-4", "", "NaN", "25"}},
+		{uints.Mul(uint(2)), Uint, []string{"14", "4", "6", "8", "10"}},
+		{uints.Sub(uints), Uint, []string{"0", "0", "0", "0", "0"}},
+		{ints.Div(2), Float, []string{"0.500000", "-1.000000", "", "NaN", "2.500000"}},
+		{floats.Div(0), Float, []string{"+Inf", "+Inf", "+Inf", "+Inf", ""}},
+		{ints.Mod(2), Int, []string{"1", "0", "", "NaN", "1"}},
+		{ints.Mod(0), Int, []string{"NaN", "NaN", "", "NaN", "NaN"}},
+		{uints.Mod(uint(4)), Uint, []string{"3", "2", "3", "0", "1"}},
+		{floats.Mod(1.5), Float, []string{"0.500000", "0.500000", "0.000000", "1.000000", ""}},
+		{ints.Pow(2), Float, []string{"1.000000", "4.000000", "", "NaN", "25.000000"}},
+		{floats.Pow(floats), Float, []string{"0.707107", "4.000000", "27.000000", "256.000000", ""}},
+		{New([]bool{true, false}, Bool, "D").Add(1), Int, []string{"2", "1"}},
+		{ints.Neg(), Int, []string{"-1", "2", "", "NaN", "-5"}},
+		{uints.Neg(), Int, []string{"-7", "-2", "-3", "-4", "-5"}},
+		{floats.Neg(), Float, []string{"-0.500000", "-2.000000", "-3.000000", "-4.000000", ""}},
+		{ints.Abs(), Int, []string{"1", "2", "", "NaN", "5"}},
+		{New([]float64{-1.5, 2}, Float, "E").Abs(), Float, []string{"1.500000", "2.000000"}},
+		{uints.Abs(), Uint, []string{"7", "2", "3", "4", "5"}},
+	}
+	for i, tc := range table {
+		if err := tc.received.Err; err != nil {
+			t.Fatalf("Test:%v\nError:%v", i, err)
+		}
+		if tc.received.Type() != tc.expType {
+			t.Errorf("Test:%v\nExpected type %v, received %v", i, tc.expType, tc.received.Type())
+		}
+		received, _ := tc.received.Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", i, tc.expected, received)
+		}
+	}
+
+	// a*b + c
+	a := New([]int{1, 2, 3}, Int, "a")
+	b := New([]float64{0.5, 1.5, 2.5}, Float, "b")
+	c := New([]int{10, 20, 30}, Int, "c")
+	received, _ := a.Mul(b).Add(c).Float(false)
+	if expected := []float64{10.5, 23, 37.5}; !reflect.DeepEqual(expected, received) {
+		t.Errorf("Expected:\n%v\nReceived:\n%v", expected, received)
+	}
+	if name := a.Mul(b).Name; name != "a" {
+		t.Errorf("Expected name a, received %v", name)
+	}
+
+	broken := New([]int{1}, Int, "F")
+	broken.Err = fmt.Errorf("broken")
+	errors := []Series{
+		ints.Add([]int{1, 2}),
+		ints.Add(New([]string{"a", "b", "c", "d", "e"}, String, "S")),
+		New([]string{"a"}, String, "S").Add(1),
+		New([]string{"a"}, Categorical, "S").Neg(),
+		ints.Add(broken),
+	}
+	for i, received := range errors {
+		if received.Err == nil {
+			t.Errorf("Test:%v\nExpected error, got:\n%v", i, received)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package series
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+)
+
+// FromArrow returns a Series reading its values from the chunks of an Arrow
+// column, without copying them. The Series retains the chunks until Release
+// is called. The operations working on the whole column, such as Subset,
+// aggregations or arithmetic, read a copy of the values in the usual storage,
+// made on first use and kept along with the chunks. Setting or appending
+// elements switches the Series to that copy (copy-on-write).
+//
+// Arrow types are mapped to Series types as follows: integers of any width give
+// Int or Uint, floating point numbers give Float, STRING gives String and BOOL
+// gives Bool. DATE32, DATE64 and TIMESTAMP give Time, in the time zone of the
+// timestamps or in UTC. Types without a matching Series type fall back to the
+// closest one: BINARY and FIXED_SIZE_BINARY give String, DECIMAL gives the
+// nearest Float and NULL gives String with all its elements missing. Other
+// types give a Series with an error.
+func FromArrow(name string, chunks *array.Chunked) Series {
+	ret := Series{Name: name}
+	e := &arrowElements{refs: 1, offsets: []int{0}}
+	switch dt := chunks.DataType().(type) {
+	case *arrow.BooleanType:
+		e.t, e.elem = Bool, func(a array.Interface, j int) Element {
+			return &boolElement{a.(*array.Boolean).Value(j), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data, values := dst.(boolElements).data[off:], a.(*array.Boolean)
+			for j := 0; j < values.Len(); j++ {
+				data[j] = values.Value(j)
+			}
+		}
+	case *arrow.Int8Type:
+		e.t, e.elem = Int, func(a array.Interface, j int) Element {
+			return &IntElement{int64(a.(*array.Int8).Value(j)), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillInts(dst, off, a.(*array.Int8).Int8Values())
+		}
+	case *arrow.Int16Type:
+		e.t, e.elem = Int, func(a array.Interface, j int) Element {
+			return &IntElement{int64(a.(*array.Int16).Value(j)), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillInts(dst, off, a.(*array.Int16).Int16Values())
+		}
+	case *arrow.Int32Type:
+		e.t, e.elem = Int, func(a array.Interface, j int) Element {
+			return &IntElement{int64(a.(*array.Int32).Value(j)), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillInts(dst, off, a.(*array.Int32).Int32Values())
+		}
+	case *arrow.Int64Type:
+		e.t, e.elem = Int, func(a array.Interface, j int) Element {
+			return &IntElement{a.(*array.Int64).Value(j), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			copy(dst.(intElements).data[off:], a.(*array.Int64).Int64Values())
+		}
+	case *arrow.Uint8Type:
+		e.t, e.elem = Uint, func(a array.Interface, j int) Element {
+			return &uintElement{uint64(a.(*array.Uint8).Value(j)), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillUints(dst, off, a.(*array.Uint8).Uint8Values())
+		}
+	case *arrow.Uint16Type:
+		e.t, e.elem = Uint, func(a array.Interface, j int) Element {
+			return &uintElement{uint64(a.(*array.Uint16).Value(j)), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillUints(dst, off, a.(*array.Uint16).Uint16Values())
+		}
+	case *arrow.Uint32Type:
+		e.t, e.elem = Uint, func(a array.Interface, j int) Element {
+			return &uintElement{uint64(a.(*array.Uint32).Value(j)), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillUints(dst, off, a.(*array.Uint32).Uint32Values())
+		}
+	case *arrow.Uint64Type:
+		e.t, e.elem = Uint, func(a array.Interface, j int) Element {
+			return &uintElement{a.(*array.Uint64).Value(j), true, false}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			copy(dst.(uintElements).data[off:], a.(*array.Uint64).Uint64Values())
+		}
+	case *arrow.Float16Type:
+		e.t, e.elem = Float, func(a array.Interface, j int) Element {
+			return &floatElement{float64(a.(*array.Float16).Value(j).Float32()), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data := dst.(floatElements).data[off:]
+			for j, v := range a.(*array.Float16).Values() {
+				data[j] = float64(v.Float32())
+			}
+		}
+	case *arrow.Float32Type:
+		e.t, e.elem = Float, func(a array.Interface, j int) Element {
+			return &floatElement{float64(a.(*array.Float32).Value(j)), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data := dst.(floatElements).data[off:]
+			for j, v := range a.(*array.Float32).Float32Values() {
+				data[j] = float64(v)
+			}
+		}
+	case *arrow.Float64Type:
+		e.t, e.elem = Float, func(a array.Interface, j int) Element {
+			return &floatElement{a.(*array.Float64).Value(j), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			copy(dst.(floatElements).data[off:], a.(*array.Float64).Float64Values())
+		}
+	case *arrow.Decimal128Type:
+		e.t, e.elem = Float, func(a array.Interface, j int) Element {
+			v, _ := DecimalRat(a.(*array.Decimal128).Value(j), dt.Scale).Float64()
+			return &floatElement{v, true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data := dst.(floatElements).data[off:]
+			for j, n := range a.(*array.Decimal128).Values() {
+				if a.IsValid(j) {
+					data[j], _ = DecimalRat(n, dt.Scale).Float64()
+				}
+			}
+		}
+	case *arrow.StringType:
+		e.t, e.elem = String, func(a array.Interface, j int) Element {
+			return &stringElement{a.(*array.String).Value(j), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillStrings(dst, off, a.Len(), a.(*array.String).Value)
+		}
+	case *arrow.BinaryType:
+		e.t, e.elem = String, func(a array.Interface, j int) Element {
+			return &stringElement{string(a.(*array.Binary).Value(j)), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillStrings(dst, off, a.Len(), a.(*array.Binary).Value)
+		}
+	case *arrow.FixedSizeBinaryType:
+		e.t, e.elem = String, func(a array.Interface, j int) Element {
+			return &stringElement{string(a.(*array.FixedSizeBinary).Value(j)), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			fillStrings(dst, off, a.Len(), a.(*array.FixedSizeBinary).Value)
+		}
+	case *arrow.NullType:
+		e.t, e.elem = String, func(a array.Interface, j int) Element {
+			return nil
+		}
+	case *arrow.TimestampType:
+		loc, err := arrowLocation(dt.TimeZone)
+		if err != nil {
+			ret.Err = err
+			return ret
+		}
+		unit := int64(time.Nanosecond)
+		switch dt.Unit {
+		case arrow.Second:
+			unit = int64(time.Second)
+		case arrow.Millisecond:
+			unit = int64(time.Millisecond)
+		case arrow.Microsecond:
+			unit = int64(time.Microsecond)
+		}
+		e.t, e.elem = Time, func(a array.Interface, j int) Element {
+			return &timeElement{time.Unix(0, int64(a.(*array.Timestamp).Value(j))*unit).In(loc), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data := dst.(timeElements).data[off:]
+			for j, v := range a.(*array.Timestamp).TimestampValues() {
+				data[j] = time.Unix(0, int64(v)*unit).In(loc)
+			}
+		}
+	case *arrow.Date32Type:
+		e.t, e.elem = Time, func(a array.Interface, j int) Element {
+			return &timeElement{time.Unix(int64(a.(*array.Date32).Value(j))*24*60*60, 0).UTC(), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data := dst.(timeElements).data[off:]
+			for j, v := range a.(*array.Date32).Date32Values() {
+				data[j] = time.Unix(int64(v)*24*60*60, 0).UTC()
+			}
+		}
+	case *arrow.Date64Type:
+		e.t, e.elem = Time, func(a array.Interface, j int) Element {
+			return &timeElement{time.Unix(0, int64(a.(*array.Date64).Value(j))*int64(time.Millisecond)).UTC(), true}
+		}
+		e.fill = func(dst column, off int, a array.Interface) {
+			data := dst.(timeElements).data[off:]
+			for j, v := range a.(*array.Date64).Date64Values() {
+				data[j] = time.Unix(0, int64(v)*int64(time.Millisecond)).UTC()
+			}
+		}
+	default:
+		ret.Err = fmt.Errorf("unsupported Arrow Type: %v", chunks.DataType())
+		return ret
+	}
+	for _, c := range chunks.Chunks() {
+		c.Retain()
+		e.chunks = append(e.chunks, c)
+		e.offsets = append(e.offsets, e.offsets[len(e.offsets)-1]+c.Len())
+	}
+	ret.t, ret.elements = e.t, e
+	return ret
+}
+
+// arrowLocation returns the location of the time zone of an Arrow timestamp:
+// UTC when there's none, a fixed zone for offsets such as "+01:00", and the
+// named zone from the time zone database otherwise
+func arrowLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	if len(tz) == 6 && (tz[0] == '+' || tz[0] == '-') && tz[3] == ':' {
+		h, errH := strconv.Atoi(tz[1:3])
+		m, errM := strconv.Atoi(tz[4:6])
+		if errH == nil && errM == nil && h < 24 && m < 60 {
+			offset := h*60*60 + m*60
+			if tz[0] == '-' {
+				offset = -offset
+			}
+			return time.FixedZone(tz, offset), nil
+		}
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported Arrow time zone %q: %v", tz, err)
+	}
+	return loc, nil
+}
+
+// Retain increases the reference count of the Arrow arrays backing a Series
+// returned by FromArrow. It does nothing for other Series.
+func (s Series) Retain() {
+	if e, ok := s.elements.(*arrowElements); ok {
+		atomic.AddInt64(&e.refs, 1)
+	}
+}
+
+// Release decreases the reference count of the Arrow arrays backing a Series
+// returned by FromArrow, releasing them when it reaches zero. The Series can't
+// be used after that, unless its elements have been set in the meantime. It
+// does nothing for other Series.
+func (s Series) Release() {
+	if e, ok := s.elements.(*arrowElements); ok {
+		if atomic.AddInt64(&e.refs, -1) == 0 {
+			e.release()
+		}
+	}
+}
+
+// DecimalRat returns the exact value of the Arrow decimal n with the given
+// scale. The Float elements read from DECIMAL arrays hold the nearest float64.
+func DecimalRat(n decimal128.Num, scale int32) *big.Rat {
+	r := new(big.Rat).SetInt(n.BigInt())
+	exp := int64(scale)
+	if exp < 0 {
+		exp = -exp
+	}
+	p := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	if scale < 0 {
+		return r.Mul(r, p)
+	}
+	return r.Quo(r, p)
+}
+
+// fillInts copies integers to the values of an Int column from off
+func fillInts[T int8 | int16 | int32](dst column, off int, values []T) {
+	data := dst.(intElements).data[off:]
+	for j, v := range values {
+		data[j] = int64(v)
+	}
+}
+
+// fillUints copies unsigned integers to the values of a Uint column from off
+func fillUints[T uint8 | uint16 | uint32](dst column, off int, values []T) {
+	data := dst.(uintElements).data[off:]
+	for j, v := range values {
+		data[j] = uint64(v)
+	}
+}
+
+// fillStrings copies n strings to the values of a String column from off.
+// They are copied to a single string, as the Arrow arrays only share their
+// memory while retained.
+func fillStrings[S string | []byte](dst column, off int, n int, value func(j int) S) {
+	size := 0
+	for j := 0; j < n; j++ {
+		size += len(value(j))
+	}
+	buf := make([]byte, 0, size)
+	for j := 0; j < n; j++ {
+		buf = append(buf, value(j)...)
+	}
+	all := string(buf)
+	data := dst.(stringElements).data[off:]
+	for j, k := 0, 0; j < n; j++ {
+		l := len(value(j))
+		data[j], k = all[k:k+l], k+l
+	}
+}
+
+// arrowElements is the implementation of Elements for the Series returned by
+// FromArrow. The elements are read from the chunks of an Arrow column, and the
+// code working on the typed storage reads a copy of the values, made once. As
+// Arrow arrays are immutable, setting an element makes the Series use that copy
+// from then on.
+type arrowElements struct {
+	t      Type
+	chunks []array.Interface
+	// The position of the first element of every chunk, then the length
+	offsets []int
+	// Returns a valid element, or nil for NULL arrays
+	elem func(a array.Interface, j int) Element
+	// Copies the values of a chunk to typed storage from the given position,
+	// leaving their validity alone. Nil for NULL arrays.
+	fill func(dst column, off int, a array.Interface)
+	refs int64
+	// The copy of the values read by the code working on the typed storage
+	once  sync.Once
+	cache column
+	// The copied values, once an element has been set
+	mat column
+}
+
+func (e *arrowElements) Len() int {
+	if e.mat != nil {
+		return e.mat.Len()
+	}
+	return e.offsets[len(e.offsets)-1]
+}
+
+func (e *arrowElements) Elem(i int) Element {
+	if e.mat != nil {
+		return e.mat.Elem(i)
+	}
+	return &arrowElementRef{e.load(i), e, i}
+}
+
+// load returns a copy of the i-th element
+func (e *arrowElements) load(i int) Element {
+	k := sort.Search(len(e.chunks), func(k int) bool { return e.offsets[k+1] > i })
+	if k == len(e.chunks) {
+		panic(fmt.Sprintf("index out of range [%d] with length %d", i, e.Len()))
+	}
+	a, j := e.chunks[k], i-e.offsets[k]
+	if a.IsValid(j) {
+		if v := e.elem(a, j); v != nil {
+			return v
+		}
+	}
+	return newElements(e.t, 1).Elem(0)
+}
+
+// build returns a copy of the values in the storage of the type
+func (e *arrowElements) build() column {
+	if e.mat != nil {
+		return e.mat.copy()
+	}
+	ret := newElements(e.t, e.Len())
+	if e.fill == nil {
+		return ret
+	}
+	valid := validBitmap(ret)
+	for k, a := range e.chunks {
+		e.fill(ret, e.offsets[k], a)
+		// The values under nulls are undefined, and the missing elements
+		// hold the zero value
+		for j, i := 0, e.offsets[k]; j < a.Len(); j, i = j+1, i+1 {
+			if a.IsValid(j) {
+				valid.set(i, true)
+			} else {
+				ret.set(i, nil)
+			}
+		}
+	}
+	return ret
+}
+
+// typed returns the values in the storage of the type, copying them on the
+// first call. The copy is kept along with the Arrow arrays, and must not be
+// modified.
+func (e *arrowElements) typed() column {
+	if e.mat != nil {
+		return e.mat
+	}
+	e.once.Do(func() {
+		e.cache = e.build()
+	})
+	return e.cache
+}
+
+// native returns the storage the values have been copied to, to set elements,
+// releasing the Arrow arrays on the first call
+func (e *arrowElements) native() column {
+	if e.mat == nil {
+		e.mat = e.typed()
+		e.release()
+	}
+	return e.mat
+}
+
+func (e *arrowElements) release() {
+	for _, a := range e.chunks {
+		a.Release()
+	}
+	e.chunks = nil
+	e.cache = nil
+}
+
+func (e *arrowElements) set(i int, value interface{}) error {
+	return e.native().set(i, value)
+}
+
+func (e *arrowElements) assign(i int, x column, j int) {
+	e.native().assign(i, x, j)
+}
+
+func (e *arrowElements) subset(idx []int) column {
+	return e.typed().subset(idx)
+}
+
+func (e *arrowElements) append(x column) column {
+	return e.native().append(native(x))
+}
+
+func (e *arrowElements) copy() column {
+	return e.build()
+}
+
+// native returns the typed storage of c: c itself, or the copy of the values
+// of a Series returned by FromArrow, for the code working on the typed
+// storage directly
+func native(c column) column {
+	if e, ok := c.(*arrowElements); ok {
+		return e.typed()
+	}
+	return c
+}
+
+// arrowElementRef is the Element returned by arrowElements.Elem. Setting it
+// copies the values of the Series before writing the value through.
+type arrowElementRef struct {
+	Element
+	col *arrowElements
+	i   int
+}
+
+func (r *arrowElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.Element = r.col.mat.Elem(r.i)
+	return err
+}
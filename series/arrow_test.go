@@ -0,0 +1,270 @@
+package series
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+// arrowInts returns a chunked Int64 array, one chunk per slice, with nil values
+// being null
+func arrowInts(mem memory.Allocator, chunks ...[]interface{}) *array.Chunked {
+	b := array.NewInt64Builder(mem)
+	defer b.Release()
+	var arrs []array.Interface
+	for _, values := range chunks {
+		for _, v := range values {
+			if v == nil {
+				b.AppendNull()
+			} else {
+				b.Append(int64(v.(int)))
+			}
+		}
+		arrs = append(arrs, b.NewArray())
+	}
+	ret := array.NewChunked(arrow.PrimitiveTypes.Int64, arrs)
+	for _, a := range arrs {
+		a.Release()
+	}
+	return ret
+}
+
+func TestFromArrow(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	chunks := arrowInts(mem, []interface{}{1, nil}, []interface{}{}, []interface{}{3, 4, nil})
+	s := FromArrow("a", chunks)
+	chunks.Release()
+	if s.Err != nil {
+		t.Fatal(s.Err)
+	}
+	defer s.Release()
+	if _, ok := s.elements.(*arrowElements); !ok {
+		t.Fatalf("Expected Arrow elements, received %T", s.elements)
+	}
+	expected := []string{"1", "", "3", "4", ""}
+	if s.Type() != Int || s.Name != "a" || s.Len() != 5 {
+		t.Errorf("Expected Int Series a of length 5, received %v %v %v", s.Type(), s.Name, s.Len())
+	}
+	if received, _ := s.Records(true); !reflect.DeepEqual(expected, received) {
+		t.Errorf("Expected:\n%v\nReceived:\n%v", expected, received)
+	}
+	// Reading elements doesn't copy the values
+	if e := s.elements.(*arrowElements); e.cache != nil || e.mat != nil {
+		t.Errorf("Expected the values not to be copied")
+	}
+	if received, _ := s.Subset([]int{4, 2, 0}).Records(true); !reflect.DeepEqual([]string{"", "3", "1"}, received) {
+		t.Errorf("Expected:\n%v\nReceived:\n%v", []string{"", "3", "1"}, received)
+	}
+	if max, err := s.Max(); err != nil || max != 4 {
+		t.Errorf("Expected max 4, received %v (%v)", max, err)
+	}
+	if codes, n := s.GroupCodes(); n != 4 || !reflect.DeepEqual([]int{0, 1, 2, 3, 1}, codes) {
+		t.Errorf("Unexpected group codes %v, %d groups", codes, n)
+	}
+	if received, _ := s.Diff(1).Records(true); !reflect.DeepEqual([]string{"", "", "", "1", ""}, received) {
+		t.Errorf("Unexpected diff %v", received)
+	}
+	if received, _ := Ints([]int{1, 0, 3, 0, 5}).Compare(Eq, s).Records(true); !reflect.DeepEqual([]string{"true", "false", "true", "false", "false"}, received) {
+		t.Errorf("Unexpected comparison %v", received)
+	}
+	if values, _, _ := Values[int64](s); !reflect.DeepEqual([]int64{1, 0, 3, 4, 0}, values) {
+		t.Errorf("Unexpected values %v", values)
+	}
+	// The operations on the whole column read a single copy of the values,
+	// kept along with the arrays
+	if e := s.elements.(*arrowElements); e.cache == nil || e.mat != nil || e.chunks == nil {
+		t.Errorf("Expected the values to be copied once, and the arrays to be kept")
+	}
+}
+
+func TestFromArrow_CopyOnWrite(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	chunks := arrowInts(mem, []interface{}{1, nil}, []interface{}{3})
+	defer chunks.Release()
+
+	s := FromArrow("a", chunks)
+	c := s.Copy()
+	s.Set(1, 2)
+	s.Elem(2).Set(5)
+	if received, _ := s.Records(true); !reflect.DeepEqual([]string{"1", "2", "5"}, received) {
+		t.Errorf("Unexpected values after Set: %v", received)
+	}
+	if received, _ := c.Records(true); !reflect.DeepEqual([]string{"1", "", "3"}, received) {
+		t.Errorf("Copy modified by Set: %v", received)
+	}
+	if v := chunks.Chunk(1).(*array.Int64).Value(0); v != 3 {
+		t.Errorf("Arrow array modified by Set: %v", v)
+	}
+	// The arrays are released once copied
+	s.Release()
+
+	s = FromArrow("a", chunks)
+	s.Append([]int{6})
+	if received, _ := s.Records(true); !reflect.DeepEqual([]string{"1", "", "3", "6"}, received) {
+		t.Errorf("Unexpected values after Append: %v", received)
+	}
+
+	s = FromArrow("a", chunks)
+	s.Retain()
+	s.Release()
+	u := Ints([]int{0, 0, 0}).Update([]int{2, 1, 0}, s)
+	if received, _ := u.Records(true); !reflect.DeepEqual([]string{"3", "", "1"}, received) {
+		t.Errorf("Unexpected values after Update: %v", received)
+	}
+	s.Release()
+}
+
+func TestFromArrow_Types(t *testing.T) {
+	mem := memory.NewGoAllocator()
+	ts := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		build    func() array.Interface
+		expType  Type
+		expected []string
+	}{
+		{func() array.Interface {
+			b := array.NewBooleanBuilder(mem)
+			defer b.Release()
+			b.AppendValues([]bool{true, false}, []bool{true, false})
+			return b.NewArray()
+		}, Bool, []string{"true", ""}},
+		{func() array.Interface {
+			b := array.NewInt8Builder(mem)
+			defer b.Release()
+			b.AppendValues([]int8{-8, 0}, []bool{true, false})
+			return b.NewArray()
+		}, Int, []string{"-8", ""}},
+		{func() array.Interface {
+			b := array.NewUint16Builder(mem)
+			defer b.Release()
+			b.AppendValues([]uint16{16, 0}, []bool{true, false})
+			return b.NewArray()
+		}, Uint, []string{"16", ""}},
+		{func() array.Interface {
+			b := array.NewFloat32Builder(mem)
+			defer b.Release()
+			b.AppendValues([]float32{1.5, 0}, []bool{true, false})
+			return b.NewArray()
+		}, Float, []string{"1.500000", ""}},
+		{func() array.Interface {
+			b := array.NewDecimal128Builder(mem, &arrow.Decimal128Type{Precision: 5, Scale: 2})
+			defer b.Release()
+			b.AppendValues([]decimal128.Num{decimal128.FromI64(-125), {}}, []bool{true, false})
+			return b.NewArray()
+		}, Float, []string{"-1.250000", ""}},
+		{func() array.Interface {
+			b := array.NewBinaryBuilder(mem, arrow.BinaryTypes.Binary)
+			defer b.Release()
+			b.AppendValues([][]byte{[]byte("ab"), nil}, []bool{true, false})
+			return b.NewArray()
+		}, String, []string{"ab", ""}},
+		{func() array.Interface {
+			b := array.NewFixedSizeBinaryBuilder(mem, &arrow.FixedSizeBinaryType{ByteWidth: 1})
+			defer b.Release()
+			b.AppendValues([][]byte{[]byte("c"), nil}, []bool{true, false})
+			return b.NewArray()
+		}, String, []string{"c", ""}},
+		{func() array.Interface {
+			return array.NewNull(2)
+		}, String, []string{"", ""}},
+		{func() array.Interface {
+			b := array.NewTimestampBuilder(mem, &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "Europe/Paris"})
+			defer b.Release()
+			b.AppendValues([]arrow.Timestamp{arrow.Timestamp(ts.UnixNano() / 1e6), 0}, []bool{true, false})
+			return b.NewArray()
+		}, Time, []string{ts.In(paris).Format(time.RFC3339), ""}},
+		{func() array.Interface {
+			b := array.NewTimestampBuilder(mem, &arrow.TimestampType{Unit: arrow.Second, TimeZone: "+01:00"})
+			defer b.Release()
+			b.AppendValues([]arrow.Timestamp{arrow.Timestamp(ts.Unix())}, nil)
+			return b.NewArray()
+		}, Time, []string{"2021-06-01T11:30:00+01:00"}},
+		{func() array.Interface {
+			b := array.NewTimestampBuilder(mem, &arrow.TimestampType{Unit: arrow.Second, TimeZone: "-05:30"})
+			defer b.Release()
+			b.AppendValues([]arrow.Timestamp{arrow.Timestamp(ts.Unix())}, nil)
+			return b.NewArray()
+		}, Time, []string{"2021-06-01T05:00:00-05:30"}},
+		{func() array.Interface {
+			b := array.NewDate32Builder(mem)
+			defer b.Release()
+			b.AppendValues([]arrow.Date32{1, 0}, []bool{true, false})
+			return b.NewArray()
+		}, Time, []string{"1970-01-02T00:00:00Z", ""}},
+	}
+	for i, test := range tests {
+		arr := test.build()
+		chunks := array.NewChunked(arr.DataType(), []array.Interface{arr})
+		arr.Release()
+		s := FromArrow("a", chunks)
+		chunks.Release()
+		if s.Err != nil {
+			t.Fatalf("Test: %d\nError:%v", i, s.Err)
+		}
+		if s.Type() != test.expType {
+			t.Errorf("Test: %d\nExpected type %v, received %v", i, test.expType, s.Type())
+		}
+		received, _ := s.Records(true)
+		if !reflect.DeepEqual(test.expected, received) {
+			t.Errorf("Test: %d\nExpected:\n%v\nReceived:\n%v", i, test.expected, received)
+		}
+		received, _ = s.Copy().Records(true)
+		if !reflect.DeepEqual(test.expected, received) {
+			t.Errorf("Test: %d\nExpected copy:\n%v\nReceived:\n%v", i, test.expected, received)
+		}
+		s.Release()
+	}
+
+	chunks := array.NewChunked(arrow.FixedWidthTypes.Time32s, nil)
+	defer chunks.Release()
+	if s := FromArrow("a", chunks); s.Err == nil {
+		t.Errorf("Expected error for an unsupported type")
+	}
+	// Missing elements hold the zero value whatever is under the nulls
+	b := array.NewInt8Builder(mem)
+	b.AppendValues([]int8{-8, 5}, []bool{true, false})
+	arr := b.NewArray()
+	b.Release()
+	chunks = array.NewChunked(arr.DataType(), []array.Interface{arr})
+	arr.Release()
+	s := FromArrow("a", chunks)
+	chunks.Release()
+	if values := s.elements.(*arrowElements).build().(intElements).data; !reflect.DeepEqual([]int64{-8, 0}, values) {
+		t.Errorf("Expected [-8 0], received %v", values)
+	}
+
+	for _, tz := range []string{"Mars/Olympus", "+25:00", "+01:60"} {
+		chunks := array.NewChunked(&arrow.TimestampType{Unit: arrow.Second, TimeZone: tz}, nil)
+		if s := FromArrow("a", chunks); s.Err == nil {
+			t.Errorf("Expected error for the time zone %q", tz)
+		}
+		chunks.Release()
+	}
+}
+
+func TestDecimalRat(t *testing.T) {
+	for _, test := range []struct {
+		n        int64
+		scale    int32
+		expected string
+	}{
+		{-125, 2, "-5/4"},
+		{3, -2, "300/1"},
+		{0, 5, "0/1"},
+	} {
+		if received := DecimalRat(decimal128.FromI64(test.n), test.scale).String(); received != test.expected {
+			t.Errorf("Decimal %d with scale %d\nExpected %v, received %v", test.n, test.scale, test.expected, received)
+		}
+	}
+}
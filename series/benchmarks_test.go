@@ -256,3 +256,109 @@ func BenchmarkSeries_Set(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkSeries_Sum(b *testing.B) {
+	rand.Seed(100)
+	table := []struct {
+		name   string
+		series series.Series
+	}{
+		{
+			"[]int(100000)_Int",
+			series.Ints(generateInts(100000)),
+		},
+		{
+			"[]float64(100000)_Float",
+			series.Floats(generateFloats(100000)),
+		},
+	}
+	for _, test := range table {
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test.series.Sum(false)
+			}
+		})
+	}
+}
+
+func BenchmarkSeries_Mean(b *testing.B) {
+	rand.Seed(100)
+	table := []struct {
+		name   string
+		series series.Series
+	}{
+		{
+			"[]int(100000)_Int",
+			series.Ints(generateInts(100000)),
+		},
+		{
+			"[]float64(100000)_Float",
+			series.Floats(generateFloats(100000)),
+		},
+	}
+	for _, test := range table {
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test.series.Mean()
+			}
+		})
+	}
+}
+
+func BenchmarkSeries_Compare(b *testing.B) {
+	rand.Seed(100)
+	table := []struct {
+		name       string
+		comparando interface{}
+		series     series.Series
+	}{
+		{
+			"[]int(100000)_Int_Single",
+			rand.Int(),
+			series.Ints(generateInts(100000)),
+		},
+		{
+			"[]int(100000)_Int_Multiple",
+			generateInts(100000),
+			series.Ints(generateInts(100000)),
+		},
+		{
+			"[]float64(100000)_Float_Single",
+			0.5,
+			series.Floats(generateFloats(100000)),
+		},
+		{
+			"[]string(100000)_String_Single",
+			"1234",
+			series.Strings(generateStrings(100000)),
+		},
+	}
+	for _, test := range table {
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test.series.Compare(series.Less, test.comparando)
+			}
+		})
+	}
+}
+
+func BenchmarkSeries_Rolling(b *testing.B) {
+	rand.Seed(100)
+	s := series.Floats(generateFloats(100000))
+	table := []struct {
+		name string
+		f    func(r series.RollingWindow) series.Series
+	}{
+		{"Mean_100", func(r series.RollingWindow) series.Series { return r.Mean() }},
+		{"StdDev_100", func(r series.RollingWindow) series.Series { return r.StdDev() }},
+		{"Max_100", func(r series.RollingWindow) series.Series { return r.Max() }},
+		{"Median_100", func(r series.RollingWindow) series.Series { return r.Median() }},
+	}
+	for _, test := range table {
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test.f(s.Rolling(100))
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package series
+
+import "math/bits"
+
+// bitmap is a packed slice of bits. The columnar Elements use it to keep track
+// of which of their values are valid (and NaN for the integer types). The length
+// of a bitmap is always given by the length of the values it goes along with.
+type bitmap []uint64
+
+func newBitmap(n int) bitmap {
+	return make(bitmap, bitmapWords(n))
+}
+
+func bitmapWords(n int) int {
+	return (n + 63) / 64
+}
+
+func (b bitmap) get(i int) bool {
+	return b[i>>6]&(1<<uint(i&63)) != 0
+}
+
+func (b bitmap) set(i int, v bool) {
+	if v {
+		b[i>>6] |= 1 << uint(i&63)
+	} else {
+		b[i>>6] &^= 1 << uint(i&63)
+	}
+}
+
+// setAll sets the first n bits to v
+func (b bitmap) setAll(n int, v bool) {
+	full := n / 64
+	for w := 0; w < full; w++ {
+		if v {
+			b[w] = ^uint64(0)
+		} else {
+			b[w] = 0
+		}
+	}
+	for i := full * 64; i < n; i++ {
+		b.set(i, v)
+	}
+}
+
+// count returns the number of set bits among the first n
+func (b bitmap) count(n int) int {
+	c := 0
+	full := n / 64
+	for w := 0; w < full; w++ {
+		c += bits.OnesCount64(b[w])
+	}
+	for i := full * 64; i < n; i++ {
+		if b.get(i) {
+			c++
+		}
+	}
+	return c
+}
+
+// all returns true if the first n bits are set
+func (b bitmap) all(n int) bool {
+	return b.count(n) == n
+}
+
+// any returns true if any of the first n bits is set
+func (b bitmap) any(n int) bool {
+	return b.count(n) > 0
+}
+
+// copy returns a copy of the first n bits
+func (b bitmap) copy(n int) bitmap {
+	ret := newBitmap(n)
+	copy(ret, b[:bitmapWords(n)])
+	return ret
+}
+
+// subset returns a new bitmap with the bits at the given positions
+func (b bitmap) subset(idx []int) bitmap {
+	ret := newBitmap(len(idx))
+	for k, i := range idx {
+		if b.get(i) {
+			ret[k>>6] |= 1 << uint(k&63)
+		}
+	}
+	return ret
+}
+
+// append returns a new bitmap holding the first n bits of b followed by the
+// first m bits of x. It never shares its storage with b, like the values the
+// bitmap goes along with.
+func (b bitmap) append(n int, x bitmap, m int) bitmap {
+	ret := newBitmap(n + m)
+	copy(ret, b[:bitmapWords(n)])
+	for i := 0; i < m; i++ {
+		ret.set(n+i, x.get(i))
+	}
+	return ret
+}
+
+// validBitmap returns the validity of the elements of a column, or nil for
+// the Categorical columns, which have no bitmap
+func validBitmap(c column) bitmap {
+	switch e := native(c).(type) {
+	case intElements:
+		return e.valid
+	case uintElements:
+		return e.valid
+	case floatElements:
+		return e.valid
+	case stringElements:
+		return e.valid
+	case boolElements:
+		return e.valid
+	case timeElements:
+		return e.valid
+	}
+	return nil
+}
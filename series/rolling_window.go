@@ -1,54 +1,612 @@
 package series
 
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
 // RollingWindow is used for rolling window calculations.
+//
+// Windows hold either a fixed number of elements, see Series.Rolling, or the
+// elements within a duration of a sorted Time Series, see Series.RollingTime.
+// Non-valid and NaN elements are skipped, and a window yields NaN unless it
+// holds at least MinPeriods valid elements. Every calculation but Median,
+// Quantile and Apply runs in O(n) by updating the previous window.
 type RollingWindow struct {
-	window int
-	series Series
+	window     int
+	series     Series
+	minPeriods int
+	center     bool
+	byTime     bool
+	duration   time.Duration
+	times      Series
+	err        error
 }
 
 // Rolling creates new RollingWindow
 func (s Series) Rolling(window int) RollingWindow {
-	return RollingWindow{
-		window: window,
-		series: s,
+	r := RollingWindow{
+		window:     window,
+		series:     s,
+		minPeriods: window,
+	}
+	if window < 0 {
+		r.err = fmt.Errorf("rolling: negative window %d", window)
+	}
+	return r
+}
+
+// RollingTime creates a new RollingWindow whose window at every element holds
+// the elements with a time in (t-window, t], t being the time of the element.
+// times has to be a sorted Time Series of the same length, with no non-valid
+// elements. MinPeriods defaults to 1.
+func (s Series) RollingTime(window time.Duration, times Series) RollingWindow {
+	r := RollingWindow{
+		series:     s,
+		minPeriods: 1,
+		byTime:     true,
+		duration:   window,
+		times:      times,
 	}
+	switch {
+	case window < 0:
+		r.err = fmt.Errorf("rolling: negative window %v", window)
+	case times.Type() != Time:
+		r.err = fmt.Errorf("rolling: times must be a Time series, got %v", times.Type())
+	case times.Len() != s.Len():
+		r.err = fmt.Errorf("rolling: times length mismatch")
+	}
+	return r
+}
+
+// MinPeriods sets the minimum number of valid elements a window must hold
+// to yield a value
+func (r RollingWindow) MinPeriods(n int) RollingWindow {
+	r.minPeriods = n
+	return r
+}
+
+// Center sets whether the windows are centered on each element, instead of
+// ending on it. Time windows then span (t-window/2, t+window/2].
+func (r RollingWindow) Center(center bool) RollingWindow {
+	r.center = center
+	return r
 }
 
 // Mean returns the rolling mean.
-func (r RollingWindow) Mean() (s Series) {
-	s = New([]float64{}, Float, "Mean")
-	for _, block := range r.getBlocks() {
-		mean, _ := block.Mean()
-		s.Append(mean)
+func (r RollingWindow) Mean() Series {
+	return r.aggregate("Mean", &meanAgg{})
+}
+
+// StdDev returns the rolling standard deviation.
+func (r RollingWindow) StdDev() Series {
+	return r.aggregate("StdDev", &varAgg{std: true})
+}
+
+// Var returns the rolling unbiased variance.
+func (r RollingWindow) Var() Series {
+	return r.aggregate("Var", &varAgg{})
+}
+
+// Sum returns the rolling sum.
+func (r RollingWindow) Sum() Series {
+	return r.aggregate("Sum", &meanAgg{sum: true})
+}
+
+// Count returns the number of valid elements of each window.
+func (r RollingWindow) Count() Series {
+	return r.aggregate("Count", &countAgg{})
+}
+
+// Min returns the rolling minimum.
+func (r RollingWindow) Min() Series {
+	return r.aggregate("Min", &extremeAgg{less: func(x, y float64) bool { return x < y }})
+}
+
+// Max returns the rolling maximum.
+func (r RollingWindow) Max() Series {
+	return r.aggregate("Max", &extremeAgg{less: func(x, y float64) bool { return x > y }})
+}
+
+// Median returns the rolling median.
+func (r RollingWindow) Median() Series {
+	return r.aggregate("Median", &sortedAgg{median: true})
+}
+
+// Quantile returns the rolling quantile p, as in Series.Quantile.
+func (r RollingWindow) Quantile(p float64) Series {
+	if !(p >= 0 && p <= 1) {
+		ret := New([]float64{}, Float, "Quantile")
+		ret.Err = fmt.Errorf("rolling: quantile %v out of bounds", p)
+		return ret
+	}
+	return r.aggregate("Quantile", &sortedAgg{p: p})
+}
+
+// Apply returns the result of f over every window, given as a Series of the
+// elements of the window, including the non-valid and NaN ones.
+func (r RollingWindow) Apply(f func(Series) float64) Series {
+	var idx []int
+	return r.slide("Apply", &countAgg{}, func(start, end int, agg windowAgg) float64 {
+		idx = idx[:0]
+		for j := start; j < end; j++ {
+			idx = append(idx, j)
+		}
+		return f(r.series.Subset(idx))
+	})
+}
+
+// windowAgg is a calculation updated as elements enter and leave the window.
+// Elements leave in the same order as they entered.
+type windowAgg interface {
+	add(x float64, i int)
+	remove(x float64, i int)
+	value() float64
+}
+
+// aggregate returns the value of agg for every window
+func (r RollingWindow) aggregate(name string, agg windowAgg) Series {
+	return r.slide(name, agg, func(start, end int, agg windowAgg) float64 {
+		return agg.value()
+	})
+}
+
+// slide moves the windows over the series, feeding agg with the valid
+// elements entering and leaving them. The result for every window with at
+// least minPeriods valid elements is given by f, the rest are NaN.
+func (r RollingWindow) slide(name string, agg windowAgg, f func(start, end int, agg windowAgg) float64) Series {
+	start, end, err := r.bounds()
+	if err != nil {
+		ret := New([]float64{}, Float, name)
+		ret.Err = err
+		return ret
+	}
+	vals, _ := r.series.Float(true)
+	ret := make([]float64, len(vals))
+	lo, hi, count := 0, 0, 0
+	for i := range ret {
+		for ; hi < end[i]; hi++ {
+			if !math.IsNaN(vals[hi]) {
+				agg.add(vals[hi], hi)
+				count++
+			}
+		}
+		for ; lo < start[i]; lo++ {
+			if !math.IsNaN(vals[lo]) {
+				agg.remove(vals[lo], lo)
+				count--
+			}
+		}
+		ret[i] = math.NaN()
+		if count >= r.minPeriods {
+			ret[i] = f(start[i], end[i], agg)
+		}
+	}
+	return New(ret, Float, name)
+}
+
+// bounds returns the window of every element as the range [start, end) of
+// elements. Both start and end never decrease.
+func (r RollingWindow) bounds() (start, end []int, err error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if err := r.series.Err; err != nil {
+		return nil, nil, err
+	}
+	n := r.series.Len()
+	start, end = make([]int, n), make([]int, n)
+	if !r.byTime {
+		offset := 0
+		if r.center {
+			offset = (r.window - 1) / 2
+		}
+		for i := range start {
+			end[i] = imin(n, i+1+offset)
+			start[i] = imax(0, i+1+offset-r.window)
+		}
+		return start, end, nil
+	}
+
+	times, valid, err := Values[time.Time](r.times)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rolling: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if !valid[i] {
+			return nil, nil, fmt.Errorf("rolling: times has non-valid elements")
+		}
+		if i > 0 && times[i].Before(times[i-1]) {
+			return nil, nil, fmt.Errorf("rolling: times are not sorted")
+		}
+	}
+	before, after := r.duration, time.Duration(0)
+	if r.center {
+		before, after = r.duration/2, r.duration-r.duration/2
+	}
+	lo, hi := 0, 0
+	for i, t := range times {
+		for lo < n && !times[lo].After(t.Add(-before)) {
+			lo++
+		}
+		for hi < n && !times[hi].After(t.Add(after)) {
+			hi++
+		}
+		start[i], end[i] = lo, hi
+	}
+	return start, end, nil
+}
+
+// meanAgg keeps the running sum
+type meanAgg struct {
+	sum   bool
+	n     int
+	total compensatedSum
+}
+
+func (a *meanAgg) add(x float64, i int) {
+	a.n++
+	a.total.add(x)
+}
+
+func (a *meanAgg) remove(x float64, i int) {
+	a.n--
+	a.total.add(-x)
+	if a.n == 0 {
+		a.total = compensatedSum{}
+	}
+}
+
+func (a *meanAgg) value() float64 {
+	if a.sum {
+		return a.total.value()
+	}
+	return a.total.value() / float64(a.n)
+}
+
+// varAgg keeps the running sums of the values and their squares, shifted by
+// the first value to reduce cancellation. The number of equal values at the
+// end of the window is tracked so that constant windows have no variance.
+type varAgg struct {
+	std    bool
+	n      int
+	shift  float64
+	s1, s2 compensatedSum
+	last   float64
+	same   int
+}
+
+func (a *varAgg) add(x float64, i int) {
+	if a.n == 0 {
+		a.shift = x
+	}
+	a.n++
+	a.s1.add(x - a.shift)
+	a.s2.add((x - a.shift) * (x - a.shift))
+	if a.same > 0 && x == a.last {
+		a.same++
+	} else {
+		a.same = 1
+	}
+	a.last = x
+}
+
+func (a *varAgg) remove(x float64, i int) {
+	a.n--
+	if a.n == 0 {
+		*a = varAgg{std: a.std}
+		return
+	}
+	a.s1.add(-(x - a.shift))
+	a.s2.add(-(x - a.shift) * (x - a.shift))
+}
+
+func (a *varAgg) value() float64 {
+	if a.n < 2 {
+		return math.NaN()
+	}
+	v := 0.0
+	if a.same < a.n {
+		s1 := a.s1.value()
+		v = (a.s2.value() - s1*s1/float64(a.n)) / float64(a.n-1)
+	}
+	if v < 0 {
+		v = 0
 	}
+	if a.std {
+		return math.Sqrt(v)
+	}
+	return v
+}
+
+// compensatedSum is a running sum using Neumaier's compensation, which keeps
+// the error low when adding and removing many values
+type compensatedSum struct {
+	sum, c float64
+}
+
+func (k *compensatedSum) add(x float64) {
+	t := k.sum + x
+	if math.Abs(k.sum) >= math.Abs(x) {
+		k.c += (k.sum - t) + x
+	} else {
+		k.c += (x - t) + k.sum
+	}
+	k.sum = t
+}
+
+func (k compensatedSum) value() float64 { return k.sum + k.c }
+
+// countAgg counts the valid elements
+type countAgg struct {
+	n int
+}
 
-	return
+func (a *countAgg) add(x float64, i int)    { a.n++ }
+func (a *countAgg) remove(x float64, i int) { a.n-- }
+func (a *countAgg) value() float64          { return float64(a.n) }
+
+// extremeAgg keeps a deque of the elements that can still become the
+// extreme of the window, from the current extreme to the last element
+type extremeAgg struct {
+	less func(x, y float64) bool
+	idx  []int
+	vals []float64
+}
+
+func (a *extremeAgg) add(x float64, i int) {
+	n := len(a.idx)
+	for n > 0 && !a.less(a.vals[n-1], x) {
+		n--
+	}
+	a.idx, a.vals = append(a.idx[:n], i), append(a.vals[:n], x)
+}
+
+func (a *extremeAgg) remove(x float64, i int) {
+	if len(a.idx) > 0 && a.idx[0] == i {
+		a.idx, a.vals = a.idx[1:], a.vals[1:]
+	}
 }
 
-// StdDev returns the rolling mean.
-func (r RollingWindow) StdDev() (s Series) {
-	s = New([]float64{}, Float, "StdDev")
-	for _, block := range r.getBlocks() {
-		stddev, _ := block.StdDev()
-		s.Append(stddev)
+func (a *extremeAgg) value() float64 {
+	if len(a.vals) == 0 {
+		return math.NaN()
 	}
+	return a.vals[0]
+}
+
+// sortedAgg keeps the values of the window sorted
+type sortedAgg struct {
+	median bool
+	p      float64
+	sorted []float64
+}
 
-	return
+func (a *sortedAgg) add(x float64, i int) {
+	k := sort.SearchFloat64s(a.sorted, x)
+	a.sorted = append(a.sorted, 0)
+	copy(a.sorted[k+1:], a.sorted[k:])
+	a.sorted[k] = x
 }
 
-func (r RollingWindow) getBlocks() (blocks []Series) {
-	for i := 1; i <= r.series.Len(); i++ {
-		if i < r.window {
-			blocks = append(blocks, r.series.Empty())
-			continue
+func (a *sortedAgg) remove(x float64, i int) {
+	k := sort.SearchFloat64s(a.sorted, x)
+	a.sorted = append(a.sorted[:k], a.sorted[k+1:]...)
+}
+
+func (a *sortedAgg) value() float64 {
+	n := len(a.sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	if a.median {
+		if n%2 != 0 {
+			return a.sorted[n/2]
 		}
+		return (a.sorted[n/2-1] + a.sorted[n/2]) * 0.5
+	}
+	// Same as stat.Quantile with stat.Empirical
+	k := int(math.Ceil(a.p*float64(n))) - 1
+	return a.sorted[imax(k, 0)]
+}
+
+// ExpandingWindow is used for expanding window calculations, where the window
+// at every element holds all the elements up to it.
+//
+// As in Series.Float(false), non-valid elements are an error, while NaN
+// elements are skipped.
+type ExpandingWindow struct {
+	rolling RollingWindow
+}
+
+// Expanding creates a new ExpandingWindow, with MinPeriods set to 1
+func (s Series) Expanding() ExpandingWindow {
+	return ExpandingWindow{s.Rolling(s.Len()).MinPeriods(1)}
+}
+
+// MinPeriods sets the minimum number of valid elements a window must hold
+// to yield a value
+func (e ExpandingWindow) MinPeriods(n int) ExpandingWindow {
+	e.rolling = e.rolling.MinPeriods(n)
+	return e
+}
+
+// Mean returns the cumulative mean.
+func (e ExpandingWindow) Mean() Series {
+	return e.calc("Mean", RollingWindow.Mean)
+}
+
+// Sum returns the cumulative sum.
+func (e ExpandingWindow) Sum() Series {
+	return e.calc("Sum", RollingWindow.Sum)
+}
+
+// Min returns the cumulative minimum.
+func (e ExpandingWindow) Min() Series {
+	return e.calc("Min", RollingWindow.Min)
+}
+
+// Max returns the cumulative maximum.
+func (e ExpandingWindow) Max() Series {
+	return e.calc("Max", RollingWindow.Max)
+}
+
+// StdDev returns the cumulative standard deviation.
+func (e ExpandingWindow) StdDev() Series {
+	return e.calc("StdDev", RollingWindow.StdDev)
+}
+
+// Var returns the cumulative unbiased variance.
+func (e ExpandingWindow) Var() Series {
+	return e.calc("Var", RollingWindow.Var)
+}
+
+func (e ExpandingWindow) calc(name string, f func(RollingWindow) Series) Series {
+	if _, err := e.rolling.series.Float(false); err != nil {
+		ret := New([]float64{}, Float, name)
+		ret.Err = fmt.Errorf("expanding: %v", err)
+		return ret
+	}
+	return f(e.rolling)
+}
+
+// Decay sets the smoothing factor of an EWMWindow, see Alpha, Span and
+// HalfLife
+type Decay struct {
+	alpha float64
+	err   error
+}
+
+// Alpha sets the smoothing factor directly, 0 < alpha <= 1
+func Alpha(alpha float64) Decay {
+	if !(alpha > 0 && alpha <= 1) {
+		return Decay{err: fmt.Errorf("ewm: alpha must be in (0, 1], got %v", alpha)}
+	}
+	return Decay{alpha: alpha}
+}
+
+// Span sets the smoothing factor as 2/(span+1), span >= 1
+func Span(span float64) Decay {
+	if !(span >= 1) {
+		return Decay{err: fmt.Errorf("ewm: span must be at least 1, got %v", span)}
+	}
+	return Decay{alpha: 2 / (span + 1)}
+}
+
+// HalfLife sets the smoothing factor so that the weight of an element halves
+// every halfLife elements, halfLife > 0
+func HalfLife(halfLife float64) Decay {
+	if !(halfLife > 0) {
+		return Decay{err: fmt.Errorf("ewm: halflife must be positive, got %v", halfLife)}
+	}
+	return Decay{alpha: 1 - math.Exp(-math.Ln2/halfLife)}
+}
+
+// EWMWindow is used for exponentially weighted calculations. The element i
+// positions before the current one is weighted by (1-alpha)^i, normalized by
+// the sum of the weights.
+//
+// As in Series.Float(false), non-valid elements are an error. NaN elements
+// are skipped, although they still count for the weights of the elements
+// before them, and yield the value of the previous element.
+type EWMWindow struct {
+	series     Series
+	decay      Decay
+	minPeriods int
+}
+
+// EWM creates a new EWMWindow, with MinPeriods set to 1
+func (s Series) EWM(decay Decay) EWMWindow {
+	return EWMWindow{
+		series:     s,
+		decay:      decay,
+		minPeriods: 1,
+	}
+}
+
+// MinPeriods sets the minimum number of valid elements seen to yield a value
+func (w EWMWindow) MinPeriods(n int) EWMWindow {
+	w.minPeriods = n
+	return w
+}
+
+// Mean returns the exponentially weighted mean.
+func (w EWMWindow) Mean() Series {
+	return w.calc("Mean", func(a *ewmAgg) float64 { return a.mean })
+}
+
+// Var returns the exponentially weighted variance, with the bias correction
+// for the weights.
+func (w EWMWindow) Var() Series {
+	return w.calc("Var", (*ewmAgg).variance)
+}
+
+// StdDev returns the exponentially weighted standard deviation, the square
+// root of Var.
+func (w EWMWindow) StdDev() Series {
+	return w.calc("StdDev", func(a *ewmAgg) float64 { return math.Sqrt(a.variance()) })
+}
 
-		index := []int{}
-		for j := i - r.window; j < i; j++ {
-			index = append(index, j)
+func (w EWMWindow) calc(name string, f func(*ewmAgg) float64) Series {
+	vals, err := w.series.Float(false)
+	if err != nil {
+		err = fmt.Errorf("ewm: %v", err)
+	} else if w.decay.err != nil {
+		err = w.decay.err
+	} else if w.decay.alpha == 0 {
+		err = fmt.Errorf("ewm: no decay given")
+	}
+	if err != nil {
+		ret := New([]float64{}, Float, name)
+		ret.Err = err
+		return ret
+	}
+	a := ewmAgg{factor: 1 - w.decay.alpha}
+	ret := make([]float64, len(vals))
+	for i, x := range vals {
+		a.add(x)
+		ret[i] = math.NaN()
+		if a.n > 0 && a.n >= w.minPeriods {
+			ret[i] = f(&a)
 		}
-		blocks = append(blocks, r.series.Subset(index))
 	}
+	return New(ret, Float, name)
+}
+
+// ewmAgg keeps the weighted mean and sum of squared differences, along with
+// the sum of the weights and of the squared weights
+type ewmAgg struct {
+	factor float64
+	n      int
+	w, w2  float64
+	mean   float64
+	m2     float64
+}
+
+func (a *ewmAgg) add(x float64) {
+	a.w *= a.factor
+	a.w2 *= a.factor * a.factor
+	a.m2 *= a.factor
+	if math.IsNaN(x) {
+		return
+	}
+	a.n++
+	a.w++
+	a.w2++
+	delta := x - a.mean
+	a.mean += delta / a.w
+	a.m2 += delta * (x - a.mean)
+}
 
-	return
+func (a *ewmAgg) variance() float64 {
+	if a.n < 2 {
+		return math.NaN()
+	}
+	v := a.m2 * a.w / (a.w*a.w - a.w2)
+	if v < 0 {
+		v = 0
+	}
+	return v
 }
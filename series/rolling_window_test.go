@@ -2,8 +2,10 @@ package series
 
 import (
 	"math"
+	"math/rand"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSeries_RollingMean(t *testing.T) {
@@ -85,3 +87,275 @@ func TestSeries_RollingStdDev(t *testing.T) {
 		}
 	}
 }
+
+func TestSeries_Rolling(t *testing.T) {
+	s := New([]interface{}{1, 5, nil, 3, 8, "NaN", 2, 7}, Int, "a")
+	nan := math.NaN()
+	tests := []struct {
+		received Series
+		expected []float64
+	}{
+		{s.Rolling(3).Sum(), []float64{nan, nan, nan, nan, nan, nan, nan, nan}},
+		{s.Rolling(3).MinPeriods(2).Sum(), []float64{nan, 6, 6, 8, 11, 11, 10, 9}},
+		{s.Rolling(3).MinPeriods(1).Count(), []float64{1, 2, 2, 2, 2, 2, 2, 2}},
+		{s.Rolling(3).MinPeriods(1).Min(), []float64{1, 1, 1, 3, 3, 3, 2, 2}},
+		{s.Rolling(3).MinPeriods(1).Max(), []float64{1, 5, 5, 5, 8, 8, 8, 7}},
+		{s.Rolling(3).MinPeriods(1).Mean(), []float64{1, 3, 3, 4, 5.5, 5.5, 5, 4.5}},
+		{s.Rolling(3).MinPeriods(2).Var(), []float64{nan, 8, 8, 2, 12.5, 12.5, 18, 12.5}},
+		{s.Rolling(4).MinPeriods(1).Median(), []float64{1, 3, 3, 3, 5, 5.5, 3, 7}},
+		{s.Rolling(4).MinPeriods(1).Quantile(0.5), []float64{1, 1, 1, 3, 5, 3, 3, 7}},
+		{s.Rolling(3).MinPeriods(1).Center(true).Max(), []float64{5, 5, 5, 8, 8, 8, 7, 7}},
+		{s.Rolling(4).MinPeriods(3).Center(true).Sum(), []float64{nan, nan, 9, 16, nan, 13, 17, nan}},
+		{
+			s.Rolling(2).MinPeriods(0).Apply(func(w Series) float64 { return float64(w.Len()) }),
+			[]float64{1, 2, 2, 2, 2, 2, 2, 2},
+		},
+	}
+	for testnum, test := range tests {
+		if err := test.received.Err; err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+			continue
+		}
+		received, _ := test.received.Float(false)
+		if !equalFloats(test.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", testnum, test.expected, received)
+		}
+	}
+
+	if s.Rolling(-1).Mean().Err == nil {
+		t.Error("Expected error for a negative window")
+	}
+	if s.Rolling(2).Quantile(2).Err == nil {
+		t.Error("Expected error for a quantile out of bounds")
+	}
+}
+
+func TestSeries_RollingTime(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2021, 6, 1, 0, 0, sec, 0, time.UTC) }
+	times := New([]time.Time{at(0), at(1), at(2), at(5), at(6), at(6), at(20)}, Time, "t")
+	s := Floats([]float64{1, 2, 3, 4, 5, 6, 7})
+	nan := math.NaN()
+	tests := []struct {
+		received Series
+		expected []float64
+	}{
+		{s.RollingTime(3*time.Second, times).Sum(), []float64{1, 3, 6, 4, 15, 15, 7}},
+		{s.RollingTime(3*time.Second, times).MinPeriods(2).Count(), []float64{nan, 2, 3, nan, 3, 3, nan}},
+		{s.RollingTime(4*time.Second, times).Center(true).Max(), []float64{3, 3, 3, 6, 6, 6, 7}},
+	}
+	for testnum, test := range tests {
+		if err := test.received.Err; err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+			continue
+		}
+		received, _ := test.received.Float(false)
+		if !equalFloats(test.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", testnum, test.expected, received)
+		}
+	}
+
+	unsorted := New([]time.Time{at(1), at(0), at(2), at(5), at(6), at(6), at(20)}, Time, "t")
+	errors := []Series{
+		s.RollingTime(time.Second, unsorted).Mean(),
+		s.RollingTime(time.Second, times.Subset([]int{0, 1})).Mean(),
+		s.RollingTime(time.Second, Ints([]int{1, 2, 3, 4, 5, 6, 7})).Mean(),
+	}
+	for testnum, received := range errors {
+		if received.Err == nil {
+			t.Errorf("Test:%v\nExpected error", testnum)
+		}
+	}
+}
+
+// Compares the incremental calculations with the Series methods over the
+// same windows
+func TestSeries_Rolling_Subsets(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 200)
+	for i := range values {
+		values[i] = float64(r.Intn(50))
+	}
+	s := Floats(values)
+	for _, window := range []int{1, 2, 5, 17} {
+		rolling := s.Rolling(window)
+		received := map[string]Series{
+			"Sum":      rolling.Sum(),
+			"Mean":     rolling.Mean(),
+			"StdDev":   rolling.StdDev(),
+			"Min":      rolling.Min(),
+			"Max":      rolling.Max(),
+			"Median":   rolling.Median(),
+			"Quantile": rolling.Quantile(0.3),
+		}
+		for i := window - 1; i < s.Len(); i++ {
+			idx := make([]int, window)
+			for j := range idx {
+				idx[j] = i - window + 1 + j
+			}
+			block := s.Subset(idx)
+			sum, _ := block.Sum(false)
+			mean, _ := block.Mean()
+			stddev, _ := block.StdDev()
+			min, _ := block.Min()
+			max, _ := block.Max()
+			median, _ := block.Median()
+			quantile, _ := block.Quantile(0.3)
+			expected := map[string]float64{
+				"Sum": sum, "Mean": mean, "StdDev": stddev, "Min": min,
+				"Max": max, "Median": median, "Quantile": quantile,
+			}
+			for name, exp := range expected {
+				rec, _ := received[name].Elem(i).Float()
+				if !equalFloats([]float64{exp}, []float64{rec}) {
+					t.Errorf("Window %v, %v at %v\nExpected:%v\nReceived:%v", window, name, i, exp, rec)
+				}
+			}
+		}
+	}
+}
+
+// equalFloats compares with a tolerance, NaN being equal to NaN
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			if math.IsNaN(a[i]) != math.IsNaN(b[i]) {
+				return false
+			}
+			continue
+		}
+		if math.Abs(a[i]-b[i]) > 1e-9*math.Max(1, math.Abs(a[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSeries_Expanding(t *testing.T) {
+	s := New([]float64{1, math.NaN(), 3, 2}, Float, "x")
+	nan := math.NaN()
+	table := []struct {
+		received Series
+		expected []float64
+	}{
+		{s.Expanding().Mean(), []float64{1, 1, 2, 2}},
+		{s.Expanding().Sum(), []float64{1, 1, 4, 6}},
+		{s.Expanding().Min(), []float64{1, 1, 1, 1}},
+		{s.Expanding().Max(), []float64{1, 1, 3, 3}},
+		{s.Expanding().Var(), []float64{nan, nan, 2, 1}},
+		{s.Expanding().StdDev(), []float64{nan, nan, math.Sqrt2, 1}},
+		{s.Expanding().MinPeriods(2).Mean(), []float64{nan, nan, 2, 2}},
+		{New([]int{3, 1, 2}, Int, "x").Expanding().Max(), []float64{3, 3, 3}},
+	}
+	for i, tc := range table {
+		if err := tc.received.Err; err != nil {
+			t.Fatalf("Test:%v\nError:%v", i, err)
+		}
+		received, _ := tc.received.Float(true)
+		if !equalFloats(tc.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", i, tc.expected, received)
+		}
+	}
+
+	if received := New([]interface{}{1, nil, 3}, Int, "x").Expanding().Mean(); received.Err == nil {
+		t.Errorf("Expected error for a non-valid element, got:\n%v", received)
+	}
+}
+
+func TestSeries_EWM(t *testing.T) {
+	nan := math.NaN()
+	vals := []float64{1, 2, nan, 4, 3, 3, 10}
+	s := New(vals, Float, "x")
+
+	// ewm computes the weighted mean and variance from scratch at every element
+	ewm := func(alpha float64) (means, variances []float64) {
+		for i := range vals {
+			var w, w2, sum float64
+			var n int
+			for j := 0; j <= i; j++ {
+				if math.IsNaN(vals[j]) {
+					continue
+				}
+				wj := math.Pow(1-alpha, float64(i-j))
+				w += wj
+				w2 += wj * wj
+				sum += wj * vals[j]
+				n++
+			}
+			mean := sum / w
+			var m2 float64
+			for j := 0; j <= i; j++ {
+				if !math.IsNaN(vals[j]) {
+					m2 += math.Pow(1-alpha, float64(i-j)) * (vals[j] - mean) * (vals[j] - mean)
+				}
+			}
+			variance := nan
+			if n > 1 {
+				variance = m2 / w * w * w / (w*w - w2)
+			}
+			means = append(means, mean)
+			variances = append(variances, variance)
+		}
+		return means, variances
+	}
+
+	table := []struct {
+		decay Decay
+		alpha float64
+	}{
+		{Alpha(0.5), 0.5},
+		{Span(3), 0.5},
+		{HalfLife(1), 0.5},
+		{Span(9), 0.2},
+	}
+	for i, tc := range table {
+		expMean, expVar := ewm(tc.alpha)
+		expStd := make([]float64, len(expVar))
+		for j, v := range expVar {
+			expStd[j] = math.Sqrt(v)
+		}
+		w := s.EWM(tc.decay)
+		for _, c := range []struct {
+			received Series
+			expected []float64
+		}{
+			{w.Mean(), expMean},
+			{w.Var(), expVar},
+			{w.StdDev(), expStd},
+		} {
+			if err := c.received.Err; err != nil {
+				t.Fatalf("Test:%v\nError:%v", i, err)
+			}
+			received, _ := c.received.Float(true)
+			if !equalFloats(c.expected, received) {
+				t.Errorf("Test:%v %v\nExpected:\n%v\nReceived:\n%v", i, c.received.Name, c.expected, received)
+			}
+		}
+	}
+
+	// The known value for two elements and alpha 0.5
+	received, _ := New([]int{1, 2}, Int, "x").EWM(Alpha(0.5)).Var().Float(true)
+	if !equalFloats([]float64{nan, 0.5}, received) {
+		t.Errorf("Expected [NaN 0.5], received %v", received)
+	}
+	received, _ = s.EWM(Alpha(0.5)).MinPeriods(3).Mean().Float(true)
+	if !math.IsNaN(received[2]) || math.IsNaN(received[3]) {
+		t.Errorf("Expected NaN before the third valid element, received %v", received)
+	}
+
+	errors := []Series{
+		s.EWM(Alpha(0)).Mean(),
+		s.EWM(Alpha(1.5)).Mean(),
+		s.EWM(Span(0.5)).Mean(),
+		s.EWM(HalfLife(0)).Mean(),
+		s.EWM(Decay{}).Mean(),
+		New([]interface{}{1, nil, 3}, Int, "x").EWM(Alpha(0.5)).Mean(),
+	}
+	for i, received := range errors {
+		if received.Err == nil {
+			t.Errorf("Test:%v\nExpected error, got:\n%v", i, received)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CumSum returns the cumulative sum of an Int, Uint or Float Series, keeping
+// its type. Bool Series are summed as Int. Non-valid and NaN elements are
+// skipped and stay as they are.
+func (s Series) CumSum() Series {
+	return s.cumulative("cumsum", true,
+		func(x, y int64) int64 { return x + y },
+		func(x, y uint64) uint64 { return x + y },
+		func(x, y float64) float64 { return x + y },
+		nil,
+	)
+}
+
+// CumProd returns the cumulative product of an Int, Uint or Float Series,
+// keeping its type. Bool Series are multiplied as Int. Non-valid and NaN
+// elements are skipped and stay as they are.
+func (s Series) CumProd() Series {
+	return s.cumulative("cumprod", true,
+		func(x, y int64) int64 { return x * y },
+		func(x, y uint64) uint64 { return x * y },
+		func(x, y float64) float64 { return x * y },
+		nil,
+	)
+}
+
+// CumMax returns the cumulative maximum of an Int, Uint, Float or Time
+// Series, keeping its type. Non-valid and NaN elements are skipped and stay
+// as they are.
+func (s Series) CumMax() Series {
+	return s.cumulative("cummax", false,
+		func(x, y int64) int64 {
+			if y > x {
+				return y
+			}
+			return x
+		},
+		func(x, y uint64) uint64 {
+			if y > x {
+				return y
+			}
+			return x
+		},
+		math.Max,
+		func(x, y time.Time) time.Time {
+			if y.After(x) {
+				return y
+			}
+			return x
+		},
+	)
+}
+
+// CumMin returns the cumulative minimum of an Int, Uint, Float or Time
+// Series, keeping its type. Non-valid and NaN elements are skipped and stay
+// as they are.
+func (s Series) CumMin() Series {
+	return s.cumulative("cummin", false,
+		func(x, y int64) int64 {
+			if y < x {
+				return y
+			}
+			return x
+		},
+		func(x, y uint64) uint64 {
+			if y < x {
+				return y
+			}
+			return x
+		},
+		math.Min,
+		func(x, y time.Time) time.Time {
+			if y.Before(x) {
+				return y
+			}
+			return x
+		},
+	)
+}
+
+// cumulative runs the function matching the type of the Series over its
+// elements. A nil function means the type is not supported.
+func (s Series) cumulative(name string, boolAsInt bool, fInt func(x, y int64) int64, fUint func(x, y uint64) uint64, fFloat func(x, y float64) float64, fTime func(x, y time.Time) time.Time) Series {
+	if err := s.Err; err != nil {
+		return s
+	}
+	if s.t == Bool && boolAsInt {
+		values, valid, err := Values[int64](s)
+		if err != nil {
+			s.Err = fmt.Errorf("%s: %v", name, err)
+			return s
+		}
+		s = FromSlice(values, valid, s.Name)
+	}
+	ret := s.Copy()
+	switch e := ret.elements.(type) {
+	case intElements:
+		cumulate(e.data, func(i int) bool { return !e.valid.get(i) || e.nan.get(i) }, fInt)
+		return ret
+	case uintElements:
+		cumulate(e.data, func(i int) bool { return !e.valid.get(i) || e.nan.get(i) }, fUint)
+		return ret
+	case floatElements:
+		cumulate(e.data, func(i int) bool { return !e.valid.get(i) || math.IsNaN(e.data[i]) }, fFloat)
+		return ret
+	case timeElements:
+		if fTime != nil {
+			cumulate(e.data, func(i int) bool { return !e.valid.get(i) }, fTime)
+			return ret
+		}
+	}
+	s.Err = fmt.Errorf("%s: not supported for %v series", name, s.t)
+	return s
+}
+
+// cumulate replaces every element of data with f of the running value and
+// itself, skipping the missing elements
+func cumulate[T any](data []T, missing func(int) bool, f func(x, y T) T) {
+	var acc T
+	started := false
+	for i, x := range data {
+		if missing(i) {
+			continue
+		}
+		if started {
+			acc = f(acc, x)
+		} else {
+			acc, started = x, true
+		}
+		data[i] = acc
+	}
+}
+
+// Shift returns the Series with its elements moved n positions forward, or
+// backward if n is negative, keeping its type. The positions left empty are
+// filled with non-valid elements.
+func (s Series) Shift(n int) Series {
+	if err := s.Err; err != nil {
+		return s
+	}
+	l := s.Len()
+	idx := make([]int, l)
+	for i := range idx {
+		if j := i - n; j >= 0 && j < l {
+			idx[i] = j
+		}
+	}
+	ret := s.Subset(idx)
+	for i := range idx {
+		if j := i - n; j < 0 || j >= l {
+			ret.elements.set(i, nil)
+		}
+	}
+	return ret
+}
+
+// Diff returns the difference between every element and the one n positions
+// before it, or after it if n is negative. Int and Float Series keep their
+// type, Uint Series give Int and Time Series give the Int number of
+// nanoseconds. Positions with no element to compare to, or with a non-valid
+// element on either side, are non-valid.
+func (s Series) Diff(n int) Series {
+	if err := s.Err; err != nil {
+		return s
+	}
+	l := s.Len()
+	pair := func(i int, valid bitmap) (int, bool) {
+		j := i - n
+		return j, j >= 0 && j < l && valid.get(i) && valid.get(j)
+	}
+	ret := Series{Name: s.Name}
+	switch e := native(s.elements).(type) {
+	case intElements:
+		elements := newIntElements(l)
+		for i := 0; i < l; i++ {
+			if j, ok := pair(i, e.valid); ok {
+				elements.store(i, IntElement{e.data[i] - e.data[j], true, e.nan.get(i) || e.nan.get(j)})
+			}
+		}
+		ret.t, ret.elements = Int, elements
+	case uintElements:
+		elements := newIntElements(l)
+		for i := 0; i < l; i++ {
+			if j, ok := pair(i, e.valid); ok {
+				elements.store(i, IntElement{int64(e.data[i] - e.data[j]), true, e.nan.get(i) || e.nan.get(j)})
+			}
+		}
+		ret.t, ret.elements = Int, elements
+	case floatElements:
+		elements := newFloatElements(l)
+		for i := 0; i < l; i++ {
+			if j, ok := pair(i, e.valid); ok {
+				elements.store(i, floatElement{e.data[i] - e.data[j], true})
+			}
+		}
+		ret.t, ret.elements = Float, elements
+	case timeElements:
+		elements := newIntElements(l)
+		for i := 0; i < l; i++ {
+			if j, ok := pair(i, e.valid); ok {
+				elements.store(i, IntElement{int64(e.data[i].Sub(e.data[j])), true, false})
+			}
+		}
+		ret.t, ret.elements = Int, elements
+	default:
+		s.Err = fmt.Errorf("diff: not supported for %v series", s.t)
+		return s
+	}
+	return ret
+}
+
+// PctChange returns the relative change between every element and the one n
+// positions before it, or after it if n is negative, as a Float Series.
+// Positions with no element to compare to, or with a non-valid element on
+// either side, are non-valid.
+func (s Series) PctChange(n int) Series {
+	if err := s.Err; err != nil {
+		return s
+	}
+	switch s.t {
+	case Int, Uint, Float:
+	default:
+		s.Err = fmt.Errorf("pct change: not supported for %v series", s.t)
+		return s
+	}
+	vals, err := s.Float(true)
+	if err != nil {
+		s.Err = fmt.Errorf("pct change: %v", err)
+		return s
+	}
+	valid := s.IsValid()
+	l := len(vals)
+	elements := newFloatElements(l)
+	for i := range vals {
+		if j := i - n; j >= 0 && j < l && valid[i] && valid[j] {
+			elements.store(i, floatElement{vals[i]/vals[j] - 1, true})
+		}
+	}
+	return Series{Name: s.Name, t: Float, elements: elements}
+}
@@ -0,0 +1,111 @@
+package series
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeries_Cumulative(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2021, 6, 1, 0, 0, sec, 0, time.UTC) }
+	table := []struct {
+		received Series
+		expType  Type
+		expected []string
+	}{
+		{New([]interface{}{1, 2, nil, "NaN", 4}, Int, "A").CumSum(), Int, []string{"1", "3", "", "NaN", "7"}},
+		{New([]interface{}{2.5, "NaN", nil, 1.5}, Float, "A").CumSum(), Float, []string{"2.500000", "NaN", "", "4.000000"}},
+		{New([]uint{1, 2, 3}, Uint, "A").CumSum(), Uint, []string{"1", "3", "6"}},
+		{New([]bool{true, false, true}, Bool, "A").CumSum(), Int, []string{"1", "1", "2"}},
+		{New([]interface{}{2, nil, 3, 4}, Int, "A").CumProd(), Int, []string{"2", "", "6", "24"}},
+		{New([]float64{1.5, 2, 2}, Float, "A").CumProd(), Float, []string{"1.500000", "3.000000", "6.000000"}},
+		{New([]interface{}{1, 3, nil, 2, 5}, Int, "A").CumMax(), Int, []string{"1", "3", "", "3", "5"}},
+		{New([]interface{}{1, 3, nil, 2, 5}, Int, "A").CumMin(), Int, []string{"1", "1", "", "1", "1"}},
+		{New([]interface{}{"NaN", 3.5, 1.5}, Float, "A").CumMin(), Float, []string{"NaN", "3.500000", "1.500000"}},
+		{New([]time.Time{at(2), at(1), at(3)}, Time, "A").CumMax(), Time, []string{at(2).Format(time.RFC3339), at(2).Format(time.RFC3339), at(3).Format(time.RFC3339)}},
+	}
+	for i, tc := range table {
+		if err := tc.received.Err; err != nil {
+			t.Fatalf("Test:%v\nError:%v", i, err)
+		}
+		if tc.received.Type() != tc.expType {
+			t.Errorf("Test:%v\nExpected type %v, received %v", i, tc.expType, tc.received.Type())
+		}
+		if tc.received.Name != "A" {
+			t.Errorf("Test:%v\nExpected name A, received %v", i, tc.received.Name)
+		}
+		received, _ := tc.received.Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", i, tc.expected, received)
+		}
+	}
+
+	// The original Series is left as it was
+	s := New([]int{1, 2, 3}, Int, "A")
+	s.CumSum()
+	if received, _ := s.Records(true); !reflect.DeepEqual([]string{"1", "2", "3"}, received) {
+		t.Errorf("Expected the Series to be unchanged, received %v", received)
+	}
+
+	errors := []Series{
+		New([]string{"a", "b"}, String, "A").CumSum(),
+		New([]bool{true}, Bool, "A").CumMax(),
+		New([]time.Time{at(1)}, Time, "A").CumSum(),
+	}
+	for i, received := range errors {
+		if received.Err == nil {
+			t.Errorf("Test:%v\nExpected error, got:\n%v", i, received)
+		}
+	}
+}
+
+func TestSeries_Shift_Diff_PctChange(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2021, 6, 1, 0, 0, sec, 0, time.UTC) }
+	table := []struct {
+		received Series
+		expType  Type
+		expected []string
+	}{
+		{New([]int{1, 2, 3, 4}, Int, "A").Shift(1), Int, []string{"", "1", "2", "3"}},
+		{New([]int{1, 2, 3, 4}, Int, "A").Shift(-2), Int, []string{"3", "4", "", ""}},
+		{New([]int{1, 2}, Int, "A").Shift(5), Int, []string{"", ""}},
+		{New([]string{"a", "b", "c"}, String, "A").Shift(1), String, []string{"", "a", "b"}},
+		{New([]string{"a", "b", "a"}, Categorical, "A").Shift(-1), Categorical, []string{"b", "a", ""}},
+		{New([]interface{}{1, 3, nil, 10, "NaN", 20}, Int, "A").Diff(1), Int, []string{"", "2", "", "", "NaN", "NaN"}},
+		{New([]int{1, 3, 6, 10}, Int, "A").Diff(2), Int, []string{"", "", "5", "7"}},
+		{New([]int{1, 3, 6, 10}, Int, "A").Diff(-1), Int, []string{"-2", "-3", "-4", ""}},
+		{New([]uint{5, 3}, Uint, "A").Diff(1), Int, []string{"", "-2"}},
+		{New([]float64{1.5, 1, 4}, Float, "A").Diff(1), Float, []string{"", "-0.500000", "3.000000"}},
+		{New([]time.Time{at(1), at(3)}, Time, "A").Diff(1), Int, []string{"", "2000000000"}},
+		{New([]int{2, 3, 6, 0, 5}, Int, "A").PctChange(1), Float, []string{"", "0.500000", "1.000000", "-1.000000", "+Inf"}},
+		{New([]interface{}{2.0, nil, 3.0, 6.0}, Float, "A").PctChange(2), Float, []string{"", "", "0.500000", ""}},
+	}
+	for i, tc := range table {
+		if err := tc.received.Err; err != nil {
+			t.Fatalf("Test:%v\nError:%v", i, err)
+		}
+		if tc.received.Type() != tc.expType {
+			t.Errorf("Test:%v\nExpected type %v, received %v", i, tc.expType, tc.received.Type())
+		}
+		received, _ := tc.received.Records(true)
+		if !reflect.DeepEqual(tc.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", i, tc.expected, received)
+		}
+	}
+
+	// Shift fills with non-valid elements
+	if valid := New([]float64{1, 2}, Float, "A").Shift(1).IsValid(); !reflect.DeepEqual([]bool{false, true}, valid) {
+		t.Errorf("Expected [false true], received %v", valid)
+	}
+
+	errors := []Series{
+		New([]string{"a", "b"}, String, "A").Diff(1),
+		New([]bool{true, false}, Bool, "A").Diff(1),
+		New([]time.Time{at(1)}, Time, "A").PctChange(1),
+	}
+	for i, received := range errors {
+		if received.Err == nil {
+			t.Errorf("Test:%v\nExpected error, got:\n%v", i, received)
+		}
+	}
+}
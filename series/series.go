@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
 	"math"
 
@@ -18,7 +19,7 @@ import (
 // subset Series of different types.
 type Series struct {
 	Name         string      // The name of the series
-	elements     Elements    // The values of the elements
+	elements     column      // The values of the elements
 	defaultValue interface{} // value to use for nil, if nil then element will be set InValid
 	t            Type        // The type of the series
 	Err          error       // If there are errors they are stored here
@@ -31,6 +32,27 @@ type Elements interface {
 	Len() int
 }
 
+// column is the typed storage backing a Series. Each type keeps its values in
+// flat slices next to a validity bitmap, instead of one struct per element.
+type column interface {
+	Elements
+	set(i int, value interface{}) error
+	assign(i int, x column, j int) // x must hold the same type
+	subset(idx []int) column
+	append(x column) column // new storage, see concat
+	copy() column
+}
+
+// concat returns a new slice holding the values of a followed by those of b.
+// Unlike the builtin append, the result never shares its storage with a, as
+// Series copied from the same one would otherwise append into each other.
+func concat[T any](a, b []T) []T {
+	ret := make([]T, len(a)+len(b))
+	copy(ret, a)
+	copy(ret[len(a):], b)
+	return ret
+}
+
 // Element is the interface that defines the types of methods to be present for elements of a Series
 type Element interface {
 	// Setter method
@@ -63,36 +85,6 @@ type Element interface {
 // Place holder for elements that are NaN. Complex rules for how this is promoted depending on the series type
 type NaNElement struct{}
 
-// intElements is the concrete implementation of Elements for Int elements.
-type intElements []IntElement
-
-func (e intElements) Len() int           { return len(e) }
-func (e intElements) Elem(i int) Element { return &e[i] }
-
-// uintElements is the concrete implementation of Elements for Int elements.
-type uintElements []uintElement
-
-func (e uintElements) Len() int           { return len(e) }
-func (e uintElements) Elem(i int) Element { return &e[i] }
-
-// stringElements is the concrete implementation of Elements for String elements.
-type stringElements []stringElement
-
-func (e stringElements) Len() int           { return len(e) }
-func (e stringElements) Elem(i int) Element { return &e[i] }
-
-// floatElements is the concrete implementation of Elements for Float elements.
-type floatElements []floatElement
-
-func (e floatElements) Len() int           { return len(e) }
-func (e floatElements) Elem(i int) Element { return &e[i] }
-
-// boolElements is the concrete implementation of Elements for Bool elements.
-type boolElements []boolElement
-
-func (e boolElements) Len() int           { return len(e) }
-func (e boolElements) Elem(i int) Element { return &e[i] }
-
 // ElementValue represents the value that can be used for marshaling or unmarshaling Elements.
 type ElementValue interface{}
 
@@ -130,6 +122,9 @@ const (
 	Float   Type = "float64" // same as Float64
 	Float64 Type = "float64"
 	Bool    Type = "bool"
+	Time    Type = "time" // stored as time.Time, numeric conversions use Unix nanoseconds
+	// strings stored as integer codes into a dictionary shared by the Series
+	Categorical Type = "categorical"
 	// not series types. these are string representations used for conversions
 	NaN string = "NaN"
 	Nil string = ""
@@ -153,6 +148,14 @@ func imax(x, y int) int {
 	return y
 }
 
+// int min function
+func imin(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
 // New is the generic Series constructor
 // For a non-empty series when passing a nil value, set size to 1
 func New(values interface{}, t Type, name string, size ...int) Series {
@@ -173,241 +176,79 @@ func NewDefault(values interface{}, defaultValue interface{}, t Type, name strin
 		alloc_size = size[0]
 	}
 
-	// Pre-allocate elements
-	preAlloc := func(n int) {
-		switch t {
-		case String:
-			ret.elements = make(stringElements, n)
-		case Int:
-			ret.elements = make(intElements, n)
-		case Uint:
-			ret.elements = make(uintElements, n)
-		case Float:
-			ret.elements = make(floatElements, n)
-		case Bool:
-			ret.elements = make(boolElements, n)
-		default:
-			panic(fmt.Sprintf("unknown type %v", t))
-		}
-	}
-
 	if values == nil {
 		l := imax(alloc_size, 0) // so we can create an empty DataFrame (no rows)
-		preAlloc(l)
+		ret.elements = newElements(t, l)
 		for i := 0; i < l; i++ {
-			ret.elements.Elem(i).Set(defaultValue)
+			ret.elements.set(i, defaultValue)
 		}
 		return ret
 	}
 
-	switch values.(type) {
+	switch v := values.(type) {
 	case []string:
-		v := values.([]string)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(v[i])
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []float32:
-		v := values.([]float32)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(float64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []float64:
-		v := values.([]float64)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(v[i])
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []int:
-		v := values.([]int)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(int64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []int8:
-		v := values.([]int8)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(int64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []int16:
-		v := values.([]int16)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(int64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []int32:
-		v := values.([]int32)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(int64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []int64:
-		v := values.([]int64)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(v[i])
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []uint:
-		v := values.([]uint)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(uint64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []uint8:
-		v := values.([]uint8)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(uint64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []uint16:
-		v := values.([]uint16)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(uint64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []uint32:
-		v := values.([]uint32)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(uint64(v[i]))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []uint64:
-		v := values.([]uint64)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(v[i])
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
 	case []bool:
-		v := values.([]bool)
-		l := len(v)
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(v[i])
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
-		}
+		ret.elements = newColumn(v, t, alloc_size)
+	case []time.Time:
+		ret.elements = newColumn(v, t, alloc_size)
 	case Series:
-		v := values.(Series)
 		l := v.Len()
-		a := imax(l, alloc_size)
-		preAlloc(a)
-		for i := 0; i < a; i++ {
-			if i < l {
-				ret.elements.Elem(i).Set(v.elements.Elem(i))
-			} else {
-				ret.elements.Elem(i).Set(nil)
-			}
+		// Categorical is left out as converting rebuilds the dictionary
+		if v.t == t && t != Categorical && l >= alloc_size {
+			ret.elements = v.elements.copy()
+			return ret
+		}
+		ret.elements = newElements(t, imax(l, alloc_size))
+		for i := 0; i < l; i++ {
+			ret.elements.set(i, v.elements.Elem(i))
 		}
 	default:
 		switch reflect.TypeOf(values).Kind() {
 		case reflect.Slice:
 			v := reflect.ValueOf(values)
 			l := v.Len()
-			a := imax(l, alloc_size)
-			preAlloc(a)
-			for i := 0; i < a; i++ {
-				if i < l {
-					val := v.Index(i).Interface()
-					if val == nil {
-						ret.elements.Elem(i).Set(defaultValue)
-					} else {
-						ret.elements.Elem(i).Set(val)
-					}
+			ret.elements = newElements(t, imax(l, alloc_size))
+			for i := 0; i < l; i++ {
+				val := v.Index(i).Interface()
+				if val == nil {
+					ret.elements.set(i, defaultValue)
 				} else {
-					ret.elements.Elem(i).Set(nil)
+					ret.elements.set(i, val)
 				}
 			}
 		default:
-			preAlloc(1)
+			ret.elements = newElements(t, 1)
 			v := reflect.ValueOf(values)
 			val := v.Interface()
 			if val == nil {
-				ret.elements.Elem(0).Set(defaultValue)
+				ret.elements.set(0, defaultValue)
 			} else {
-				ret.elements.Elem(0).Set(val)
+				ret.elements.set(0, val)
 			}
 		}
 	}
@@ -415,6 +256,28 @@ func NewDefault(values interface{}, defaultValue interface{}, t Type, name strin
 	return ret
 }
 
+// newElements allocates the storage for n non-valid elements of type t
+func newElements(t Type, n int) column {
+	switch t {
+	case String:
+		return newStringElements(n)
+	case Int:
+		return newIntElements(n)
+	case Uint:
+		return newUintElements(n)
+	case Float:
+		return newFloatElements(n)
+	case Bool:
+		return newBoolElements(n)
+	case Time:
+		return newTimeElements(n)
+	case Categorical:
+		return newCategoricalElements(n)
+	default:
+		panic(fmt.Sprintf("unknown type %v", t))
+	}
+}
+
 // Strings is a constructor for a String Series
 func Strings(values interface{}) Series {
 	return New(values, String, "")
@@ -440,6 +303,16 @@ func Bools(values interface{}) Series {
 	return New(values, Bool, "")
 }
 
+// Times is a constructor for a Time Series
+func Times(values interface{}) Series {
+	return New(values, Time, "")
+}
+
+// Categoricals is a constructor for a Categorical Series
+func Categoricals(values interface{}) Series {
+	return New(values, Categorical, "")
+}
+
 // Empty returns an empty Series of the same type
 func (s Series) Empty() Series {
 	return New([]int{}, s.t, s.Name)
@@ -453,20 +326,7 @@ func (s *Series) Append(values interface{}) {
 		return
 	}
 	news := NewDefault(values, s.defaultValue, s.t, s.Name)
-	switch s.t {
-	case String:
-		s.elements = append(s.elements.(stringElements), news.elements.(stringElements)...)
-	case Int:
-		s.elements = append(s.elements.(intElements), news.elements.(intElements)...)
-	case Uint:
-		s.elements = append(s.elements.(uintElements), news.elements.(uintElements)...)
-	case Float:
-		s.elements = append(s.elements.(floatElements), news.elements.(floatElements)...)
-	case Bool:
-		s.elements = append(s.elements.(boolElements), news.elements.(boolElements)...)
-	default:
-		panic(fmt.Sprintf("unknown type %v", s.t))
-	}
+	s.elements = s.elements.append(news.elements)
 }
 
 // Concat concatenates two series together.
@@ -499,40 +359,7 @@ func (s Series) Subset(indexes Indexes) Series {
 		Name: s.Name,
 		t:    s.t,
 	}
-	switch s.t {
-	case String:
-		elements := make(stringElements, len(idx))
-		for k, i := range idx {
-			elements[k] = s.elements.(stringElements)[i]
-		}
-		ret.elements = elements
-	case Int:
-		elements := make(intElements, len(idx))
-		for k, i := range idx {
-			elements[k] = s.elements.(intElements)[i]
-		}
-		ret.elements = elements
-	case Uint:
-		elements := make(uintElements, len(idx))
-		for k, i := range idx {
-			elements[k] = s.elements.(uintElements)[i]
-		}
-		ret.elements = elements
-	case Float:
-		elements := make(floatElements, len(idx))
-		for k, i := range idx {
-			elements[k] = s.elements.(floatElements)[i]
-		}
-		ret.elements = elements
-	case Bool:
-		elements := make(boolElements, len(idx))
-		for k, i := range idx {
-			elements[k] = s.elements.(boolElements)[i]
-		}
-		ret.elements = elements
-	default:
-		panic("unknown series type")
-	}
+	ret.elements = s.elements.subset(idx)
 	return ret
 }
 
@@ -544,11 +371,10 @@ func (s Series) Set(index int, value interface{}) Series {
 	if index < 0 || index >= s.elements.Len() {
 		s.Err = fmt.Errorf("index out of bounds: %d", index)
 	}
-	elem := s.elements.Elem(index)
 	if value == nil {
-		elem.Set(s.defaultValue)
+		s.elements.set(index, s.defaultValue)
 	} else {
-		elem.Set(value)
+		s.elements.set(index, value)
 	}
 	return s
 }
@@ -572,12 +398,17 @@ func (s Series) Update(indexes Indexes, newvalues Series) Series {
 		s.Err = fmt.Errorf("set error: dimensions mismatch")
 		return s
 	}
+	values := native(newvalues.elements)
 	for k, i := range idx {
 		if i < 0 || i >= s.Len() {
 			s.Err = fmt.Errorf("set error: index out of range")
 			return s
 		}
-		s.elements.Elem(i).Set(newvalues.elements.Elem(k))
+		if newvalues.t == s.t {
+			s.elements.assign(i, values, k)
+		} else {
+			s.elements.set(i, values.Elem(k))
+		}
 	}
 	return s
 }
@@ -608,6 +439,11 @@ func (s Series) Factorize(sort bool) (Series, Series) {
 		return codeSeries, unqiuesSeries
 	}
 
+	if s.t == Categorical {
+		codes, uniques := s.elements.(categoricalElements).factorize(sort)
+		return New(codes, Int, "codes"), New(uniques, Categorical, "uniques")
+	}
+
 	var sortedValues Series
 	dropna := true
 	codes := make([]int, s.Len())
@@ -670,6 +506,144 @@ exit:
 	return New(codes, Int, "codes"), New(uniques, s.Type(), "uniques")
 }
 
+// GroupCodes encodes the Series for grouping, hashing the typed values. Equal
+// values get the same code, numbered in order of first appearance. Unlike
+// Factorize, non-valid elements form a group of their own, and so do NaNs.
+// Returns the codes and the number of groups.
+func (s Series) GroupCodes() ([]int, int) {
+	codes := make([]int, s.Len())
+	switch e := native(s.elements).(type) {
+	case intElements:
+		g := newGroupCoder[int64]()
+		for i := range codes {
+			switch {
+			case !e.valid.get(i):
+				codes[i] = g.special(&g.nulls)
+			case e.nan.get(i):
+				codes[i] = g.special(&g.nans)
+			default:
+				codes[i] = g.code(e.data[i])
+			}
+		}
+		return codes, g.n
+	case uintElements:
+		g := newGroupCoder[uint64]()
+		for i := range codes {
+			switch {
+			case !e.valid.get(i):
+				codes[i] = g.special(&g.nulls)
+			case e.nan.get(i):
+				codes[i] = g.special(&g.nans)
+			default:
+				codes[i] = g.code(e.data[i])
+			}
+		}
+		return codes, g.n
+	case floatElements:
+		g := newGroupCoder[float64]()
+		for i := range codes {
+			switch {
+			case !e.valid.get(i):
+				codes[i] = g.special(&g.nulls)
+			case math.IsNaN(e.data[i]):
+				codes[i] = g.special(&g.nans)
+			default:
+				codes[i] = g.code(e.data[i])
+			}
+		}
+		return codes, g.n
+	case stringElements:
+		g := newGroupCoder[string]()
+		for i := range codes {
+			if !e.valid.get(i) {
+				codes[i] = g.special(&g.nulls)
+			} else {
+				codes[i] = g.code(e.data[i])
+			}
+		}
+		return codes, g.n
+	case boolElements:
+		g := newGroupCoder[bool]()
+		for i := range codes {
+			if !e.valid.get(i) {
+				codes[i] = g.special(&g.nulls)
+			} else {
+				codes[i] = g.code(e.data[i])
+			}
+		}
+		return codes, g.n
+	case timeElements:
+		// Equal instants in different locations belong to the same group
+		type instant struct {
+			sec  int64
+			nsec int
+		}
+		g := newGroupCoder[instant]()
+		for i := range codes {
+			if !e.valid.get(i) {
+				codes[i] = g.special(&g.nulls)
+			} else {
+				codes[i] = g.code(instant{e.data[i].Unix(), e.data[i].Nanosecond()})
+			}
+		}
+		return codes, g.n
+	case categoricalElements:
+		g := newGroupCoder[int]()
+		for i, c := range e.codes {
+			if c < 0 {
+				codes[i] = g.special(&g.nulls)
+			} else {
+				codes[i] = g.code(c)
+			}
+		}
+		return codes, g.n
+	}
+	panic(fmt.Sprintf("unknown type %v", s.t))
+}
+
+// groupCoder hands out group codes in order of first appearance
+type groupCoder[K comparable] struct {
+	index map[K]int
+	nulls int
+	nans  int
+	n     int
+}
+
+func newGroupCoder[K comparable]() *groupCoder[K] {
+	return &groupCoder[K]{index: make(map[K]int), nulls: -1, nans: -1}
+}
+
+func (g *groupCoder[K]) code(k K) int {
+	if c, ok := g.index[k]; ok {
+		return c
+	}
+	c := g.n
+	g.index[k] = c
+	g.n++
+	return c
+}
+
+// special returns the code of a group without a key, such as the nulls
+func (g *groupCoder[K]) special(c *int) int {
+	if *c < 0 {
+		*c = g.n
+		g.n++
+	}
+	return *c
+}
+
+// Categories returns the dictionary of a Categorical Series, in order of code.
+// The dictionary may hold values no longer present in the Series.
+func (s Series) Categories() ([]string, error) {
+	if s.t != Categorical {
+		return nil, fmt.Errorf("categories: not a categorical series: %v", s.t)
+	}
+	dict := s.elements.(categoricalElements).dict
+	ret := make([]string, len(dict.values))
+	copy(ret, dict.values)
+	return ret, nil
+}
+
 // HasNaN checks whether the Series contain NaN elements.
 // These are elements that e.Float() would return as an NaN
 func (s Series) HasNaN() bool {
@@ -693,6 +667,12 @@ func (s Series) IsNaN() []bool {
 // IsValid returns an array that identifies which of the elements are valid (not nil).
 func (s Series) IsValid() []bool {
 	ret := make([]bool, s.Len())
+	if valid := validBitmap(s.elements); valid != nil {
+		for i := range ret {
+			ret[i] = valid.get(i)
+		}
+		return ret
+	}
 	for i := 0; i < s.Len(); i++ {
 		ret[i] = s.elements.Elem(i).IsValid()
 	}
@@ -771,6 +751,9 @@ func (s Series) Compare(comparator Comparator, comparando interface{}) Series {
 
 	// Single element comparison
 	if comp.Len() == 1 {
+		if compareColumns(s.elements, comp.elements, comparator, bools) {
+			return Bools(bools)
+		}
 		for i := 0; i < s.Len(); i++ {
 			e := s.elements.Elem(i)
 			c, err := compareElements(e, comp.elements.Elem(0), comparator)
@@ -790,6 +773,9 @@ func (s Series) Compare(comparator Comparator, comparando interface{}) Series {
 		s.Err = fmt.Errorf("can't compare: length mismatch")
 		return s
 	}
+	if compareColumns(s.elements, comp.elements, comparator, bools) {
+		return Bools(bools)
+	}
 	for i := 0; i < s.Len(); i++ {
 		e := s.elements.Elem(i)
 		c, err := compareElements(e, comp.elements.Elem(i), comparator)
@@ -803,31 +789,51 @@ func (s Series) Compare(comparator Comparator, comparando interface{}) Series {
 	return Bools(bools)
 }
 
+// compareColumns compares the typed storage of a and b without going through
+// the Element interface. When b holds a single value all the elements of a are
+// compared against it. It returns false if there's no fast path for the type.
+func compareColumns(a, b column, comparator Comparator, bools []bool) bool {
+	switch comparator {
+	case Eq, Neq, Greater, GreaterEq, Less, LessEq:
+	default:
+		return false
+	}
+	step := 1
+	if b.Len() == 1 {
+		step = 0
+	}
+	if _, ok := a.(*arrowElements); ok {
+		return false
+	}
+	b = native(b)
+	switch ae := a.(type) {
+	case intElements:
+		be := b.(intElements)
+		for i := range bools {
+			bools[i] = compareIntElements(ae.load(i), be.load(i*step), comparator)
+		}
+	case floatElements:
+		be := b.(floatElements)
+		for i := range bools {
+			bools[i] = compareFloatElements(ae.load(i), be.load(i*step), comparator)
+		}
+	case stringElements:
+		be := b.(stringElements)
+		for i := range bools {
+			bools[i] = compareStringElements(ae.load(i), be.load(i*step), comparator)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
 // Copy will return a copy of the Series.
 func (s Series) Copy() Series {
 	name := s.Name
 	t := s.t
 	err := s.Err
-	var elements Elements
-	switch s.t {
-	case String:
-		elements = make(stringElements, s.Len())
-		copy(elements.(stringElements), s.elements.(stringElements))
-	case Float:
-		elements = make(floatElements, s.Len())
-		copy(elements.(floatElements), s.elements.(floatElements))
-	case Bool:
-		elements = make(boolElements, s.Len())
-		copy(elements.(boolElements), s.elements.(boolElements))
-	case Int:
-		elements = make(intElements, s.Len())
-		copy(elements.(intElements), s.elements.(intElements))
-	case Uint:
-		elements = make(uintElements, s.Len())
-		copy(elements.(uintElements), s.elements.(uintElements))
-	default:
-		panic(fmt.Sprintf("unsupported type %v", s.t))
-	}
+	elements := s.elements.copy()
 	ret := Series{
 		Name:     name,
 		t:        t,
@@ -840,10 +846,18 @@ func (s Series) Copy() Series {
 // Records returns the elements of a Series as a []string
 // If force is true and an element is not valid, an empty string will be inserted (promoted). Otherwise an error will be generated.
 func (s Series) Records(force bool) ([]string, error) {
+	str := func(i int) (string, error) { return s.elements.Elem(i).String() }
+	switch e := s.elements.(type) {
+	case intElements:
+		str = func(i int) (string, error) { return e.load(i).String() }
+	case floatElements:
+		str = func(i int) (string, error) { return e.load(i).String() }
+	case stringElements:
+		str = func(i int) (string, error) { return e.load(i).String() }
+	}
 	ret := make([]string, s.Len())
 	for i := 0; i < s.Len(); i++ {
-		e := s.elements.Elem(i)
-		val, err := e.String()
+		val, err := str(i)
 		if err != nil && !force {
 			return nil, err
 		}
@@ -890,9 +904,25 @@ func (s Series) Any(skipnan bool) (bool, error) {
 // If foce is true and an element can not be converted to float64, an NaN will be inserted (promoted). Otherwise an error will be generated.
 func (s Series) Float(force bool) ([]float64, error) {
 	ret := make([]float64, s.Len())
+	float := func(i int) (float64, error) { return s.elements.Elem(i).Float() }
+	switch e := s.elements.(type) {
+	case intElements:
+		if e.noNaN() {
+			for i, v := range e.data {
+				ret[i] = float64(v)
+			}
+			return ret, nil
+		}
+		float = func(i int) (float64, error) { return e.load(i).Float() }
+	case floatElements:
+		if e.valid.all(len(e.data)) {
+			copy(ret, e.data)
+			return ret, nil
+		}
+		float = func(i int) (float64, error) { return e.load(i).Float() }
+	}
 	for i := 0; i < s.Len(); i++ {
-		e := s.elements.Elem(i)
-		val, err := e.Float()
+		val, err := float(i)
 		if err != nil && !force {
 			return nil, err
 		}
@@ -1160,8 +1190,20 @@ func (s Series) StdDev() (float64, error) {
 	return stdDev, nil
 }
 
+// Var calculates the unbiased variance of a series
+func (s Series) Var() (float64, error) {
+	vals, err := s.Float(false)
+	if err != nil {
+		return 0, err
+	}
+	return stat.Variance(vals, nil), nil
+}
+
 // Mean calculates the average value of a series
 func (s Series) Mean() (float64, error) {
+	if e, ok := s.elements.(floatElements); ok && e.valid.all(len(e.data)) {
+		return stat.Mean(e.data, nil), nil
+	}
 	vals, err := s.Float(false)
 	if err != nil {
 		return 0, err
@@ -1175,6 +1217,7 @@ func (s Series) Mean() (float64, error) {
 func (s Series) Median() (float64, error) {
 	if s.elements.Len() == 0 ||
 		s.Type() == String ||
+		s.Type() == Categorical ||
 		s.Type() == Bool {
 		return math.NaN(), nil
 	}
@@ -1203,7 +1246,7 @@ func (s Series) Median() (float64, error) {
 
 // Max return the biggest element in the series
 func (s Series) Max() (float64, error) {
-	if s.elements.Len() == 0 || s.Type() == String {
+	if s.elements.Len() == 0 || s.Type() == String || s.Type() == Categorical {
 		return math.NaN(), nil
 	}
 
@@ -1219,7 +1262,7 @@ func (s Series) Max() (float64, error) {
 
 // MaxStr return the biggest element in a series of type String
 func (s Series) MaxStr() (string, error) {
-	if s.elements.Len() == 0 || s.Type() != String {
+	if s.elements.Len() == 0 || (s.Type() != String && s.Type() != Categorical) {
 		return "", nil
 	}
 
@@ -1235,7 +1278,7 @@ func (s Series) MaxStr() (string, error) {
 
 // Min return the lowest element in the series
 func (s Series) Min() (float64, error) {
-	if s.elements.Len() == 0 || s.Type() == String {
+	if s.elements.Len() == 0 || s.Type() == String || s.Type() == Categorical {
 		return math.NaN(), nil
 	}
 
@@ -1251,7 +1294,7 @@ func (s Series) Min() (float64, error) {
 
 // MinStr return the lowest element in a series of type String
 func (s Series) MinStr() (string, error) {
-	if s.elements.Len() == 0 || s.Type() != String {
+	if s.elements.Len() == 0 || (s.Type() != String && s.Type() != Categorical) {
 		return "", nil
 	}
 
@@ -1268,7 +1311,7 @@ func (s Series) MinStr() (string, error) {
 // Quantile returns the sample of x such that x is greater than or equal to the fraction p of samples.
 // Note: gonum/stat panics when called with strings
 func (s Series) Quantile(p float64) (float64, error) {
-	if s.Type() == String || s.Len() == 0 {
+	if s.Type() == String || s.Type() == Categorical || s.Len() == 0 {
 		return math.NaN(), nil
 	}
 
@@ -1299,9 +1342,27 @@ func (s Series) Map(f MapFunction) Series {
 // Sum calculates the sum value of a series
 // If foce is true and an element can not be converted to float64, an NaN will be inserted (promoted). Otherwise an error will be generated.
 func (s Series) Sum(force bool) (float64, error) {
-	if s.elements.Len() == 0 || s.Type() == String || s.Type() == Bool {
+	if s.elements.Len() == 0 || s.Type() == String || s.Type() == Categorical || s.Type() == Bool {
 		return math.NaN(), nil
 	}
+	switch e := s.elements.(type) {
+	case intElements:
+		if e.noNaN() {
+			sum := float64(e.data[0])
+			for _, v := range e.data[1:] {
+				sum += float64(v)
+			}
+			return sum, nil
+		}
+	case floatElements:
+		if e.valid.all(len(e.data)) {
+			sum := e.data[0]
+			for _, v := range e.data[1:] {
+				sum += v
+			}
+			return sum, nil
+		}
+	}
 	sFloat, err := s.Float(force)
 	if err != nil {
 		return math.NaN(), err
@@ -1313,3 +1374,20 @@ func (s Series) Sum(force bool) (float64, error) {
 	}
 	return sum, nil
 }
+
+// Prod calculates the product of the values of a series
+// If foce is true and an element can not be converted to float64, an NaN will be inserted (promoted). Otherwise an error will be generated.
+func (s Series) Prod(force bool) (float64, error) {
+	if s.elements.Len() == 0 || s.Type() == String || s.Type() == Categorical || s.Type() == Bool {
+		return math.NaN(), nil
+	}
+	sFloat, err := s.Float(force)
+	if err != nil {
+		return math.NaN(), err
+	}
+	prod := 1.0
+	for _, v := range sFloat {
+		prod *= v
+	}
+	return prod, nil
+}
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Check that there are no shared memory addreses between the elements of two Series
@@ -1385,6 +1386,170 @@ func TestBools(t *testing.T) {
 	}
 }
 
+func TestTimes(t *testing.T) {
+	table := []struct {
+		series   Series
+		expected string
+	}{
+		{
+			Times([]string{"2021-06-01T10:00:00Z", "", "2021-06-02", "NaN"}),
+			"[2021-06-01T10:00:00Z  2021-06-02T00:00:00Z ]",
+		},
+		{
+			Times(time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)),
+			"[2020-01-02T03:04:05.000000006Z]",
+		},
+		{
+			Times([]time.Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))}),
+			"[2020-01-02T03:04:05+01:00]",
+		},
+		{
+			Times([]int64{0, 1e9}),
+			"[1970-01-01T00:00:00Z 1970-01-01T00:00:01Z]",
+		},
+		{
+			Times(Strings([]string{"2021-06-01 10:00:00", ""})),
+			"[2021-06-01T10:00:00Z ]",
+		},
+		{
+			Times(nil),
+			"[]",
+		},
+		{
+			ParseTimes([]string{"01/02/2021 13:00", ""}, "01/02/2006 15:04", time.FixedZone("EST", -5*3600), ""),
+			"[2021-01-02T13:00:00-05:00 ]",
+		},
+	}
+	for testnum, test := range table {
+		if err := test.series.Err; err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+		expected := test.expected
+		received := fmt.Sprint(test.series)
+		if expected != received {
+			t.Errorf(
+				"Test:%v\nExpected:\n%v\nReceived:\n%v",
+				testnum, expected, received,
+			)
+		}
+		if err := checkTypes(test.series); err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+	}
+
+	if s := ParseTimes([]string{"2021-13-45"}, "2006-01-02", nil, ""); s.Err == nil {
+		t.Errorf("Expected error parsing an invalid date")
+	}
+}
+
+func TestSeries_Compare_Time(t *testing.T) {
+	s := Times([]string{"2021-06-01", "2021-06-02", "", "2021-06-03"})
+	table := []struct {
+		comparator Comparator
+		comparando interface{}
+		expected   []bool
+	}{
+		{Eq, "2021-06-02", []bool{false, true, false, false}},
+		{Eq, time.Date(2021, 6, 2, 2, 0, 0, 0, time.FixedZone("X", 2*3600)), []bool{false, true, false, false}},
+		{Greater, "2021-06-01", []bool{false, true, false, true}},
+		{LessEq, "2021-06-02", []bool{true, true, false, false}},
+		{In, []string{"2021-06-01", "2021-06-03"}, []bool{true, false, false, true}},
+	}
+	for testnum, test := range table {
+		received, err := s.Compare(test.comparator, test.comparando).Bool()
+		if err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+		if !reflect.DeepEqual(test.expected, received) {
+			t.Errorf(
+				"Test:%v\nExpected:\n%v\nReceived:\n%v",
+				testnum, test.expected, received,
+			)
+		}
+	}
+}
+
+func TestCategoricals(t *testing.T) {
+	table := []struct {
+		series     Series
+		expected   string
+		categories []string
+	}{
+		{
+			Categoricals([]string{"a", "b", "", "a", "NaN"}),
+			"[a b  a NaN]",
+			[]string{"a", "b", "NaN"},
+		},
+		{
+			Categoricals([]int{3, 1, 3}),
+			"[3 1 3]",
+			[]string{"3", "1"},
+		},
+		{
+			Categoricals(Strings([]string{"x", "", "y"})),
+			"[x  y]",
+			[]string{"x", "y"},
+		},
+		{
+			Categoricals(nil),
+			"[]",
+			[]string{},
+		},
+	}
+	for testnum, test := range table {
+		if err := test.series.Err; err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+		expected := test.expected
+		received := fmt.Sprint(test.series)
+		if expected != received {
+			t.Errorf(
+				"Test:%v\nExpected:\n%v\nReceived:\n%v",
+				testnum, expected, received,
+			)
+		}
+		if err := checkTypes(test.series); err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+		categories, err := test.series.Categories()
+		if err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+		if !reflect.DeepEqual(test.categories, categories) {
+			t.Errorf(
+				"Test:%v\nExpected:\n%v\nReceived:\n%v",
+				testnum, test.categories, categories,
+			)
+		}
+	}
+
+	// appended elements are translated to the dictionary of the Series
+	a := Categoricals([]string{"a", "b"})
+	a.Append(Categoricals([]string{"c", "a", ""}))
+	if received := fmt.Sprint(a); received != "[a b c a ]" {
+		t.Errorf("Append: received %v", received)
+	}
+	if categories, _ := a.Categories(); !reflect.DeepEqual(categories, []string{"a", "b", "c"}) {
+		t.Errorf("Append: received categories %v", categories)
+	}
+
+	// copies don't share their dictionary
+	b := a.Copy()
+	b.Set(0, "d")
+	if categories, _ := a.Categories(); !reflect.DeepEqual(categories, []string{"a", "b", "c"}) {
+		t.Errorf("Copy: received categories %v", categories)
+	}
+
+	eq, _ := a.Compare(Eq, Categoricals([]string{"a", "a", "c", "b", ""})).Bool()
+	if !reflect.DeepEqual(eq, []bool{true, false, true, false, true}) {
+		t.Errorf("Compare: received %v", eq)
+	}
+
+	if _, err := Strings([]string{"a"}).Categories(); err == nil {
+		t.Errorf("Expected error getting the categories of a String series")
+	}
+}
+
 // TODO: finish adding tests
 func TestSeries_Factorize(t *testing.T) {
 	table := []struct {
@@ -1423,6 +1588,24 @@ func TestSeries_Factorize(t *testing.T) {
 			Floats([]float64{1.1, 2, 3}),
 			true,
 		},
+		{
+			Categoricals([]string{"b", "", "a", "b", "c"}),
+			Ints([]int{0, -1, 1, 0, 2}),
+			Categoricals([]string{"b", "a", "c"}),
+			false,
+		},
+		{
+			Categoricals([]string{"b", "", "a", "b", "c"}),
+			Ints([]int{1, -1, 0, 1, 2}),
+			Categoricals([]string{"a", "b", "c"}),
+			true,
+		},
+		{
+			Categoricals([]string{"b", "a", "b", "c"}).Subset([]int{2, 3}),
+			Ints([]int{0, 1}),
+			Categoricals([]string{"b", "c"}),
+			false,
+		},
 	}
 	for testnum, test := range table {
 		b := test.series
@@ -1448,6 +1631,27 @@ func TestSeries_Factorize(t *testing.T) {
 	}
 }
 
+func TestSeries_GroupCodes(t *testing.T) {
+	tests := []struct {
+		series Series
+		codes  []int
+		n      int
+	}{
+		{Ints([]string{"1", "NaN", "1", "", "2", "NaN"}), []int{0, 1, 0, 2, 3, 1}, 4},
+		{Floats([]interface{}{1e-9, 2e-9, math.NaN(), nil, 1e-9}), []int{0, 1, 2, 3, 0}, 4},
+		{New([]interface{}{"b", nil, "a", "b"}, String, ""), []int{0, 1, 2, 0}, 3},
+		{New([]interface{}{true, nil, false}, Bool, ""), []int{0, 1, 2}, 3},
+		{Categoricals([]string{"x", "y", "x"}), []int{0, 1, 0}, 2},
+		{Times([]interface{}{"2021-06-01T01:00:00+01:00", "2021-06-01T00:00:00Z", nil}), []int{0, 0, 1}, 2},
+	}
+	for testnum, test := range tests {
+		codes, n := test.series.GroupCodes()
+		if !reflect.DeepEqual(test.codes, codes) || n != test.n {
+			t.Errorf("Test:%v\nExpected:\n%v %v\nReceived:\n%v %v", testnum, test.codes, test.n, codes, n)
+		}
+	}
+}
+
 func TestSeries_Copy(t *testing.T) {
 	tests := []Series{
 		Strings([]string{"1", "2", "3", "a", "b", "c"}),
@@ -1683,6 +1887,37 @@ func TestSeries_New(t *testing.T) {
 	}
 }
 
+func TestSeries_Append_Copies(t *testing.T) {
+	// Series copied from the same one don't append into each other
+	s := Ints([]int{1, 2, 3})
+	a := s
+	a.Append([]int{4})
+	b := s
+	b.Append([]string{""})
+	if received, _ := a.Records(true); !reflect.DeepEqual([]string{"1", "2", "3", "4"}, received) {
+		t.Errorf("Expected [1 2 3 4], received %v", received)
+	}
+	if received := a.IsValid(); !reflect.DeepEqual([]bool{true, true, true, true}, received) {
+		t.Errorf("Expected all the elements to be valid, received %v", received)
+	}
+	if received, _ := b.Records(true); !reflect.DeepEqual([]string{"1", "2", "3", ""}, received) {
+		t.Errorf("Expected [1 2 3 <nil>], received %v", received)
+	}
+
+	for _, s := range []Series{
+		Strings([]string{"a", "b"}),
+		Categoricals([]string{"a", "b"}),
+	} {
+		s.Append([]string{"c"})
+		a, b := s, s
+		a.Append([]string{"d"})
+		b.Append([]interface{}{nil})
+		if received, _ := a.Records(true); !reflect.DeepEqual([]string{"a", "b", "c", "d"}, received) {
+			t.Errorf("%v: expected [a b c d], received %v", s.Type(), received)
+		}
+	}
+}
+
 func TestSeries_Concat(t *testing.T) {
 	tests := []struct {
 		a        Series
@@ -1741,6 +1976,56 @@ func TestSeries_Concat(t *testing.T) {
 	}
 }
 
+func TestSeries_Elem_Set(t *testing.T) {
+	a := Ints([]int{1, 2, 3})
+	b := a.Copy()
+	if err := a.Elem(1).Set("NaN"); err != nil {
+		t.Fatal(err)
+	}
+	a.Elem(2).Set(nil)
+	received, _ := a.Records(true)
+	expected := []string{"1", "NaN", ""}
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Expected:\n%v\nReceived:\n%v", expected, received)
+	}
+	received, _ = b.Records(true)
+	expected = []string{"1", "2", "3"}
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("Copy was modified:\nExpected:\n%v\nReceived:\n%v", expected, received)
+	}
+
+	// Missing elements store the zero value, not the previous one
+	if v := a.elements.(intElements).data[1:]; v[0] != 0 || v[1] != 0 {
+		t.Errorf("Expected zero values for NaN and missing elements, received %v", v)
+	}
+	for _, s := range []Series{
+		Uints([]uint{1}),
+		Floats([]float64{1}),
+		Strings([]string{"a"}),
+		Bools([]bool{true}),
+		Times([]time.Time{time.Unix(1, 0)}),
+	} {
+		s.Elem(0).Set(nil)
+		if v := reflect.ValueOf(s.elements).FieldByName("data").Index(0); !v.IsZero() {
+			t.Errorf("%v: expected the zero value for a missing element, received %v", s.Type(), v)
+		}
+	}
+
+	// Validity has to survive appending across bitmap words
+	values := make([]interface{}, 100)
+	for i := range values {
+		if i%3 != 0 {
+			values[i] = float64(i)
+		}
+	}
+	c := Floats(values[:60]).Concat(Floats(values[60:]))
+	for i := 0; i < c.Len(); i++ {
+		if c.Elem(i).IsValid() != (i%3 != 0) {
+			t.Errorf("Element %v: wrong validity %v", i, c.Elem(i).IsValid())
+		}
+	}
+}
+
 func TestSeries_Order(t *testing.T) {
 	tests := []struct {
 		series   Series
@@ -1802,6 +2087,16 @@ func TestSeries_Order(t *testing.T) {
 			true,
 			[]int{0, 4, 1, 2, 3},
 		},
+		{
+			Times([]string{"2021-06-02", "", "2021-06-01", "2021-06-03T00:00:00+01:00"}),
+			false,
+			[]int{2, 0, 3, 1},
+		},
+		{
+			Times([]string{"2021-06-02", "", "2021-06-01", "2021-06-03T00:00:00+01:00"}),
+			true,
+			[]int{3, 0, 2, 1},
+		},
 	}
 	for testnum, test := range tests {
 		received := test.series.Order(test.reverse)
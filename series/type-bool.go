@@ -246,3 +246,73 @@ func (e boolElement) GreaterEq(elem Element) bool {
 	}
 	return e.e || !b
 }
+
+// boolElements is the concrete implementation of Elements for Bool elements.
+// The values are stored in a flat slice, next to the validity bitmap.
+type boolElements struct {
+	data  []bool
+	valid bitmap
+}
+
+func newBoolElements(n int) boolElements {
+	return boolElements{make([]bool, n), newBitmap(n)}
+}
+
+func (e boolElements) Len() int           { return len(e.data) }
+func (e boolElements) Elem(i int) Element { return &boolElementRef{e.load(i), e, i} }
+
+func (e boolElements) load(i int) boolElement {
+	return boolElement{e.data[i], e.valid.get(i)}
+}
+
+func (e boolElements) store(i int, v boolElement) {
+	e.data[i] = v.e
+	e.valid.set(i, v.valid)
+}
+
+func (e boolElements) set(i int, value interface{}) error {
+	var v boolElement
+	err := v.Set(value)
+	e.store(i, v)
+	return err
+}
+
+func (e boolElements) assign(i int, x column, j int) {
+	e.store(i, x.(boolElements).load(j))
+}
+
+func (e boolElements) subset(idx []int) column {
+	data := make([]bool, len(idx))
+	for k, i := range idx {
+		data[k] = e.data[i]
+	}
+	return boolElements{data, e.valid.subset(idx)}
+}
+
+func (e boolElements) append(x column) column {
+	xe := x.(boolElements)
+	return boolElements{
+		concat(e.data, xe.data),
+		e.valid.append(e.Len(), xe.valid, xe.Len()),
+	}
+}
+
+func (e boolElements) copy() column {
+	data := make([]bool, len(e.data))
+	copy(data, e.data)
+	return boolElements{data, e.valid.copy(len(data))}
+}
+
+// boolElementRef is the Element returned by boolElements.Elem. Setting it
+// writes the value through to the Series.
+type boolElementRef struct {
+	boolElement
+	col boolElements
+	i   int
+}
+
+func (r *boolElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.boolElement = r.col.load(r.i)
+	return err
+}
@@ -0,0 +1,264 @@
+package series
+
+import "sort"
+
+// categoricalDict is the dictionary shared by all the elements of a Categorical
+// Series. It only ever grows, so the codes handed out remain stable.
+type categoricalDict struct {
+	values []string
+	index  map[string]int
+}
+
+func newCategoricalDict() *categoricalDict {
+	return &categoricalDict{index: make(map[string]int)}
+}
+
+// code returns the code of the given value, adding it to the dictionary if needed
+func (d *categoricalDict) code(value string) int {
+	if c, ok := d.index[value]; ok {
+		return c
+	}
+	c := len(d.values)
+	d.values = append(d.values, value)
+	d.index[value] = c
+	return c
+}
+
+func (d *categoricalDict) copy() *categoricalDict {
+	ret := &categoricalDict{
+		values: make([]string, len(d.values)),
+		index:  make(map[string]int, len(d.index)),
+	}
+	copy(ret.values, d.values)
+	for k, v := range d.index {
+		ret.index[k] = v
+	}
+	return ret
+}
+
+// categoricalElement stores the code of a string in the dictionary of its
+// Series. Non-valid elements have a negative code. Apart from the storage it
+// behaves the same as a String element.
+type categoricalElement struct {
+	code int
+	dict *categoricalDict
+}
+
+func (e *categoricalElement) Set(value interface{}) error {
+	var str stringElement
+	err := str.Set(value)
+	if !str.valid {
+		e.code = -1
+		return err
+	}
+	e.code = e.dict.code(str.e)
+	return err
+}
+
+// str returns the equivalent String element
+func (e categoricalElement) str() stringElement {
+	if e.code < 0 {
+		return stringElement{}
+	}
+	return stringElement{e.dict.values[e.code], true}
+}
+
+func (e categoricalElement) Copy() Element {
+	return &categoricalElement{e.code, e.dict}
+}
+
+func (e categoricalElement) IsNaN() bool {
+	return e.str().IsNaN()
+}
+
+func (e categoricalElement) IsValid() bool {
+	return e.code >= 0
+}
+
+func (e categoricalElement) IsInf(sign int) bool {
+	return e.str().IsInf(sign)
+}
+
+func (e categoricalElement) Type() Type {
+	return Categorical
+}
+
+func (e categoricalElement) Val() ElementValue {
+	return e.str().Val()
+}
+
+func (e categoricalElement) String() (string, error) {
+	return e.str().String()
+}
+
+func (e categoricalElement) Int() (int64, error) {
+	return e.str().Int()
+}
+
+func (e categoricalElement) Uint() (uint64, error) {
+	return e.str().Uint()
+}
+
+func (e categoricalElement) Float() (float64, error) {
+	return e.str().Float()
+}
+
+func (e categoricalElement) Bool() (bool, error) {
+	return e.str().Bool()
+}
+
+// Eq compares the codes directly when both elements share the same dictionary
+func (e categoricalElement) Eq(elem Element) bool {
+	var c categoricalElement
+	switch v := elem.(type) {
+	case *categoricalElementRef:
+		c = v.categoricalElement
+	case *categoricalElement:
+		c = *v
+	}
+	if c.dict == e.dict && e.dict != nil {
+		return c.code == e.code || (c.code < 0 && e.code < 0)
+	}
+	return e.str().Eq(elem)
+}
+
+func (e categoricalElement) Neq(elem Element) bool {
+	return !e.Eq(elem)
+}
+
+func (e categoricalElement) Less(elem Element) bool {
+	return e.str().Less(elem)
+}
+
+func (e categoricalElement) LessEq(elem Element) bool {
+	return e.str().LessEq(elem)
+}
+
+func (e categoricalElement) Greater(elem Element) bool {
+	return e.str().Greater(elem)
+}
+
+func (e categoricalElement) GreaterEq(elem Element) bool {
+	return e.str().GreaterEq(elem)
+}
+
+// categoricalElements is the concrete implementation of Elements for
+// Categorical elements. The codes are stored in a flat slice and all of them
+// refer to the same dictionary.
+type categoricalElements struct {
+	codes []int
+	dict  *categoricalDict
+}
+
+func newCategoricalElements(n int) categoricalElements {
+	codes := make([]int, n)
+	for i := range codes {
+		codes[i] = -1
+	}
+	return categoricalElements{codes, newCategoricalDict()}
+}
+
+func (e categoricalElements) Len() int { return len(e.codes) }
+func (e categoricalElements) Elem(i int) Element {
+	return &categoricalElementRef{categoricalElement{e.codes[i], e.dict}, e, i}
+}
+
+func (e categoricalElements) set(i int, value interface{}) error {
+	v := categoricalElement{-1, e.dict}
+	err := v.Set(value)
+	e.codes[i] = v.code
+	return err
+}
+
+func (e categoricalElements) assign(i int, x column, j int) {
+	xe := x.(categoricalElements)
+	c := xe.codes[j]
+	if c >= 0 && xe.dict != e.dict {
+		c = e.dict.code(xe.dict.values[c])
+	}
+	e.codes[i] = c
+}
+
+func (e categoricalElements) subset(idx []int) column {
+	codes := make([]int, len(idx))
+	for k, i := range idx {
+		codes[k] = e.codes[i]
+	}
+	return categoricalElements{codes, e.dict}
+}
+
+// append adds the elements of x to e, translating their codes to the dictionary of e
+func (e categoricalElements) append(x column) column {
+	xe := x.(categoricalElements)
+	if len(e.codes) == 0 {
+		return xe.copy()
+	}
+	codes := make([]int, len(e.codes), len(e.codes)+len(xe.codes))
+	copy(codes, e.codes)
+	for _, c := range xe.codes {
+		if c >= 0 {
+			c = e.dict.code(xe.dict.values[c])
+		}
+		codes = append(codes, c)
+	}
+	return categoricalElements{codes, e.dict}
+}
+
+// copy returns a deep copy of the elements, including the dictionary
+func (e categoricalElements) copy() column {
+	codes := make([]int, len(e.codes))
+	copy(codes, e.codes)
+	return categoricalElements{codes, e.dict.copy()}
+}
+
+// factorize encodes the elements reusing the codes of the dictionary, so no
+// values need to be hashed. Non-valid elements get a code of -1.
+func (e categoricalElements) factorize(sorted bool) ([]int, []string) {
+	codes := make([]int, len(e.codes))
+	dict := e.dict
+	remap := make([]int, len(dict.values))
+	for i := range remap {
+		remap[i] = -1
+	}
+	uniques := []string{}
+	if sorted {
+		var used []int
+		for _, c := range e.codes {
+			if c >= 0 && remap[c] == -1 {
+				remap[c] = 0
+				used = append(used, c)
+			}
+		}
+		sort.Slice(used, func(i, j int) bool { return dict.values[used[i]] < dict.values[used[j]] })
+		for u, c := range used {
+			remap[c] = u
+			uniques = append(uniques, dict.values[c])
+		}
+	}
+	for i, c := range e.codes {
+		if c < 0 {
+			codes[i] = -1
+			continue
+		}
+		if remap[c] == -1 {
+			remap[c] = len(uniques)
+			uniques = append(uniques, dict.values[c])
+		}
+		codes[i] = remap[c]
+	}
+	return codes, uniques
+}
+
+// categoricalElementRef is the Element returned by categoricalElements.Elem.
+// Setting it writes the new code through to the Series.
+type categoricalElementRef struct {
+	categoricalElement
+	col categoricalElements
+	i   int
+}
+
+func (r *categoricalElementRef) Set(value interface{}) error {
+	err := r.categoricalElement.Set(value)
+	r.col.codes[r.i] = r.code
+	return err
+}
@@ -242,3 +242,99 @@ func (e floatElement) GreaterEq(elem Element) bool {
 	}
 	return e.e >= f
 }
+
+// floatElements is the concrete implementation of Elements for Float elements.
+// The values are stored in a flat slice, next to the validity bitmap.
+type floatElements struct {
+	data  []float64
+	valid bitmap
+}
+
+func newFloatElements(n int) floatElements {
+	return floatElements{make([]float64, n), newBitmap(n)}
+}
+
+func (e floatElements) Len() int           { return len(e.data) }
+func (e floatElements) Elem(i int) Element { return &floatElementRef{e.load(i), e, i} }
+
+func (e floatElements) load(i int) floatElement {
+	return floatElement{e.data[i], e.valid.get(i)}
+}
+
+func (e floatElements) store(i int, v floatElement) {
+	e.data[i] = v.e
+	e.valid.set(i, v.valid)
+}
+
+func (e floatElements) set(i int, value interface{}) error {
+	var v floatElement
+	err := v.Set(value)
+	e.store(i, v)
+	return err
+}
+
+func (e floatElements) assign(i int, x column, j int) {
+	e.store(i, x.(floatElements).load(j))
+}
+
+func (e floatElements) subset(idx []int) column {
+	data := make([]float64, len(idx))
+	for k, i := range idx {
+		data[k] = e.data[i]
+	}
+	return floatElements{data, e.valid.subset(idx)}
+}
+
+func (e floatElements) append(x column) column {
+	xe := x.(floatElements)
+	return floatElements{
+		concat(e.data, xe.data),
+		e.valid.append(e.Len(), xe.valid, xe.Len()),
+	}
+}
+
+func (e floatElements) copy() column {
+	data := make([]float64, len(e.data))
+	copy(data, e.data)
+	return floatElements{data, e.valid.copy(len(data))}
+}
+
+// floatElementRef is the Element returned by floatElements.Elem. Setting it
+// writes the value through to the Series.
+type floatElementRef struct {
+	floatElement
+	col floatElements
+	i   int
+}
+
+func (r *floatElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.floatElement = r.col.load(r.i)
+	return err
+}
+
+// compareFloatElements is the typed equivalent of comparing two Float elements with
+// the floatElement comparison methods.
+func compareFloatElements(a, b floatElement, comparator Comparator) bool {
+	switch comparator {
+	case Eq, Neq:
+		eq := a.valid == b.valid && (!a.valid || a.e == b.e)
+		if comparator == Neq {
+			return !eq
+		}
+		return eq
+	}
+	if a.IsNaN() || b.IsNaN() {
+		return false
+	}
+	switch comparator {
+	case Greater:
+		return a.e > b.e
+	case GreaterEq:
+		return a.e >= b.e
+	case Less:
+		return a.e < b.e
+	default:
+		return a.e <= b.e
+	}
+}
@@ -290,3 +290,108 @@ func (e IntElement) GreaterEq(elem Element) bool {
 	}
 	return e.e >= i
 }
+
+// intElements is the concrete implementation of Elements for Int elements. The
+// values are stored in a flat slice, next to the validity and NaN bitmaps.
+type intElements struct {
+	data  []int64
+	valid bitmap
+	nan   bitmap
+}
+
+func newIntElements(n int) intElements {
+	return intElements{make([]int64, n), newBitmap(n), newBitmap(n)}
+}
+
+func (e intElements) Len() int           { return len(e.data) }
+func (e intElements) Elem(i int) Element { return &intElementRef{e.load(i), e, i} }
+
+func (e intElements) load(i int) IntElement {
+	return IntElement{e.data[i], e.valid.get(i), e.nan.get(i)}
+}
+
+func (e intElements) store(i int, v IntElement) {
+	e.data[i] = v.e
+	e.valid.set(i, v.valid)
+	e.nan.set(i, v.nan)
+}
+
+func (e intElements) set(i int, value interface{}) error {
+	var v IntElement
+	err := v.Set(value)
+	e.store(i, v)
+	return err
+}
+
+func (e intElements) assign(i int, x column, j int) {
+	e.store(i, x.(intElements).load(j))
+}
+
+func (e intElements) subset(idx []int) column {
+	data := make([]int64, len(idx))
+	for k, i := range idx {
+		data[k] = e.data[i]
+	}
+	return intElements{data, e.valid.subset(idx), e.nan.subset(idx)}
+}
+
+func (e intElements) append(x column) column {
+	n, m := e.Len(), x.Len()
+	xe := x.(intElements)
+	return intElements{
+		concat(e.data, xe.data),
+		e.valid.append(n, xe.valid, m),
+		e.nan.append(n, xe.nan, m),
+	}
+}
+
+func (e intElements) copy() column {
+	data := make([]int64, len(e.data))
+	copy(data, e.data)
+	return intElements{data, e.valid.copy(len(data)), e.nan.copy(len(data))}
+}
+
+// noNaN returns true if all the elements are valid and not NaN
+func (e intElements) noNaN() bool {
+	return e.valid.all(len(e.data)) && !e.nan.any(len(e.data))
+}
+
+// intElementRef is the Element returned by intElements.Elem. Setting it
+// writes the value through to the Series.
+type intElementRef struct {
+	IntElement
+	col intElements
+	i   int
+}
+
+func (r *intElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.IntElement = r.col.load(r.i)
+	return err
+}
+
+// compareIntElements is the typed equivalent of comparing two Int elements with the
+// IntElement comparison methods.
+func compareIntElements(a, b IntElement, comparator Comparator) bool {
+	switch comparator {
+	case Eq, Neq:
+		eq := a.valid == b.valid && (!a.valid || (!b.nan && a.e == b.e))
+		if comparator == Neq {
+			return !eq
+		}
+		return eq
+	}
+	if a.IsNaN() || b.IsNaN() {
+		return false
+	}
+	switch comparator {
+	case Greater:
+		return a.e > b.e
+	case GreaterEq:
+		return a.e >= b.e
+	case Less:
+		return a.e < b.e
+	default:
+		return a.e <= b.e
+	}
+}
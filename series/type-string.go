@@ -220,3 +220,99 @@ func (e stringElement) GreaterEq(elem Element) bool {
 	}
 	return e.e >= s
 }
+
+// stringElements is the concrete implementation of Elements for String
+// elements. The values are stored in a flat slice, next to the validity bitmap.
+type stringElements struct {
+	data  []string
+	valid bitmap
+}
+
+func newStringElements(n int) stringElements {
+	return stringElements{make([]string, n), newBitmap(n)}
+}
+
+func (e stringElements) Len() int           { return len(e.data) }
+func (e stringElements) Elem(i int) Element { return &stringElementRef{e.load(i), e, i} }
+
+func (e stringElements) load(i int) stringElement {
+	return stringElement{e.data[i], e.valid.get(i)}
+}
+
+func (e stringElements) store(i int, v stringElement) {
+	e.data[i] = v.e
+	e.valid.set(i, v.valid)
+}
+
+func (e stringElements) set(i int, value interface{}) error {
+	var v stringElement
+	err := v.Set(value)
+	e.store(i, v)
+	return err
+}
+
+func (e stringElements) assign(i int, x column, j int) {
+	e.store(i, x.(stringElements).load(j))
+}
+
+func (e stringElements) subset(idx []int) column {
+	data := make([]string, len(idx))
+	for k, i := range idx {
+		data[k] = e.data[i]
+	}
+	return stringElements{data, e.valid.subset(idx)}
+}
+
+func (e stringElements) append(x column) column {
+	xe := x.(stringElements)
+	return stringElements{
+		concat(e.data, xe.data),
+		e.valid.append(e.Len(), xe.valid, xe.Len()),
+	}
+}
+
+func (e stringElements) copy() column {
+	data := make([]string, len(e.data))
+	copy(data, e.data)
+	return stringElements{data, e.valid.copy(len(data))}
+}
+
+// stringElementRef is the Element returned by stringElements.Elem. Setting it
+// writes the value through to the Series.
+type stringElementRef struct {
+	stringElement
+	col stringElements
+	i   int
+}
+
+func (r *stringElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.stringElement = r.col.load(r.i)
+	return err
+}
+
+// compareStringElements is the typed equivalent of comparing two String elements with
+// the stringElement comparison methods.
+func compareStringElements(a, b stringElement, comparator Comparator) bool {
+	switch comparator {
+	case Eq, Neq:
+		eq := a.valid == b.valid && (!a.valid || a.e == b.e)
+		if comparator == Neq {
+			return !eq
+		}
+		return eq
+	}
+	if !a.valid || !b.valid {
+		return false
+	}
+	switch comparator {
+	case Greater:
+		return a.e > b.e
+	case GreaterEq:
+		return a.e >= b.e
+	case Less:
+		return a.e < b.e
+	default:
+		return a.e <= b.e
+	}
+}
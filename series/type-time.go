@@ -0,0 +1,334 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimeLayouts are the layouts tried, in order, when a string is set on a Time
+// element. Callers needing a specific layout or location should parse the
+// values themselves (see ParseTimes) and pass time.Time values instead.
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Numeric conversions of Time elements use nanoseconds since the Unix epoch.
+type timeElement struct {
+	e     time.Time
+	valid bool
+}
+
+func (e *timeElement) Set(value interface{}) error {
+	e.valid = true
+	e.e = time.Time{}
+	if value == nil {
+		e.valid = false
+		return nil
+	}
+	switch value.(type) {
+	case string:
+		switch value.(string) {
+		case Nil, NaN:
+			e.valid = false
+			return nil
+		default:
+			t, err := parseTime(value.(string), TimeLayouts, time.UTC)
+			if err != nil {
+				e.valid = false
+				return err
+			}
+			e.e = t
+		}
+	case time.Time:
+		e.e = value.(time.Time)
+	case *time.Time:
+		if value.(*time.Time) == nil {
+			e.valid = false
+			return nil
+		}
+		e.e = *value.(*time.Time)
+	case int:
+		e.e = time.Unix(0, int64(value.(int))).UTC()
+	case int64:
+		e.e = time.Unix(0, value.(int64)).UTC()
+	case uint64:
+		e.e = time.Unix(0, int64(value.(uint64))).UTC()
+	case float64:
+		f := value.(float64)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			e.valid = false
+			return nil
+		}
+		e.e = time.Unix(0, int64(f)).UTC()
+	case NaNElement:
+		e.valid = false
+	case Element:
+		if !value.(Element).IsValid() {
+			e.valid = false
+			return nil
+		}
+		t, err := ElementTime(value.(Element))
+		if err != nil {
+			e.valid = false
+			return err
+		}
+		e.e = t
+	default:
+		e.valid = false
+		return fmt.Errorf("Unsupported type '%T' conversion to a time", value)
+	}
+	return nil
+}
+
+func (e timeElement) Copy() Element {
+	return &timeElement{e.e, e.valid}
+}
+
+func (e timeElement) IsValid() bool {
+	return e.valid
+}
+
+func (e timeElement) IsNaN() bool {
+	return !e.valid
+}
+
+func (e timeElement) IsInf(sign int) bool {
+	return false
+}
+
+func (e timeElement) Type() Type {
+	return Time
+}
+
+func (e timeElement) Val() ElementValue {
+	if !e.valid {
+		return nil
+	}
+	return e.e
+}
+
+func (e timeElement) String() (string, error) {
+	if !e.valid {
+		return "", fmt.Errorf("can't convert nil to string")
+	}
+	return e.e.Format(time.RFC3339Nano), nil
+}
+
+func (e timeElement) Int() (int64, error) {
+	if !e.valid {
+		return 0, fmt.Errorf("can't convert nil to int64")
+	}
+	return e.e.UnixNano(), nil
+}
+
+func (e timeElement) Uint() (uint64, error) {
+	if !e.valid {
+		return 0, fmt.Errorf("can't convert nil to uint64")
+	}
+	n := e.e.UnixNano()
+	if n < 0 {
+		return 0, fmt.Errorf("can't convert time before the Unix epoch to uint64")
+	}
+	return uint64(n), nil
+}
+
+func (e timeElement) Float() (float64, error) {
+	if !e.valid {
+		return math.NaN(), fmt.Errorf("can't convert nil to float64")
+	}
+	return float64(e.e.UnixNano()), nil
+}
+
+func (e timeElement) Bool() (bool, error) {
+	return false, fmt.Errorf("can't convert time to bool")
+}
+
+func (e timeElement) Eq(elem Element) bool {
+	if e.valid != elem.IsValid() {
+		// xor
+		return false
+	}
+	if !e.valid && !elem.IsValid() {
+		// nil == nil is true
+		return true
+	}
+	t, err := ElementTime(elem)
+	if err != nil {
+		return false
+	}
+	return e.e.Equal(t)
+}
+
+func (e timeElement) Neq(elem Element) bool {
+	return !e.Eq(elem)
+}
+
+func (e timeElement) Less(elem Element) bool {
+	if !e.valid || !elem.IsValid() {
+		return false
+	}
+	t, err := ElementTime(elem)
+	if err != nil {
+		return false
+	}
+	return e.e.Before(t)
+}
+
+func (e timeElement) LessEq(elem Element) bool {
+	if !e.valid || !elem.IsValid() {
+		return false
+	}
+	t, err := ElementTime(elem)
+	if err != nil {
+		return false
+	}
+	return !e.e.After(t)
+}
+
+func (e timeElement) Greater(elem Element) bool {
+	if !e.valid || !elem.IsValid() {
+		return false
+	}
+	t, err := ElementTime(elem)
+	if err != nil {
+		return false
+	}
+	return e.e.After(t)
+}
+
+func (e timeElement) GreaterEq(elem Element) bool {
+	if !e.valid || !elem.IsValid() {
+		return false
+	}
+	t, err := ElementTime(elem)
+	if err != nil {
+		return false
+	}
+	return !e.e.Before(t)
+}
+
+// ElementTime converts a valid Element of any type to a time.Time. Strings are
+// parsed using TimeLayouts, numbers are taken as nanoseconds since the epoch.
+func ElementTime(elem Element) (time.Time, error) {
+	switch v := elem.Val().(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return parseTime(v, TimeLayouts, time.UTC)
+	}
+	if elem.IsNaN() {
+		return time.Time{}, fmt.Errorf("can't convert NaN to time")
+	}
+	n, err := elem.Int()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n).UTC(), nil
+}
+
+func parseTime(value string, layouts []string, loc *time.Location) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't parse '%s' as a time", value)
+}
+
+// ParseTimes parses the given strings with layout in the given location and
+// returns them as a Time Series. Missing values (Nil and NaN) become non-valid
+// elements. A nil location is treated as UTC. Values that can't be parsed
+// return an error.
+func ParseTimes(values []string, layout string, loc *time.Location, name string) Series {
+	if loc == nil {
+		loc = time.UTC
+	}
+	ret := New(nil, Time, name, len(values))
+	for i, v := range values {
+		if v == Nil || v == NaN {
+			continue
+		}
+		t, err := time.ParseInLocation(layout, v, loc)
+		if err != nil {
+			ret.Err = fmt.Errorf("can't parse '%s' with layout '%s': %v", v, layout, err)
+			return ret
+		}
+		ret.elements.Elem(i).Set(t)
+	}
+	return ret
+}
+
+// timeElements is the concrete implementation of Elements for Time elements.
+// The values are stored in a flat slice, next to the validity bitmap.
+type timeElements struct {
+	data  []time.Time
+	valid bitmap
+}
+
+func newTimeElements(n int) timeElements {
+	return timeElements{make([]time.Time, n), newBitmap(n)}
+}
+
+func (e timeElements) Len() int           { return len(e.data) }
+func (e timeElements) Elem(i int) Element { return &timeElementRef{e.load(i), e, i} }
+
+func (e timeElements) load(i int) timeElement {
+	return timeElement{e.data[i], e.valid.get(i)}
+}
+
+func (e timeElements) store(i int, v timeElement) {
+	e.data[i] = v.e
+	e.valid.set(i, v.valid)
+}
+
+func (e timeElements) set(i int, value interface{}) error {
+	var v timeElement
+	err := v.Set(value)
+	e.store(i, v)
+	return err
+}
+
+func (e timeElements) assign(i int, x column, j int) {
+	e.store(i, x.(timeElements).load(j))
+}
+
+func (e timeElements) subset(idx []int) column {
+	data := make([]time.Time, len(idx))
+	for k, i := range idx {
+		data[k] = e.data[i]
+	}
+	return timeElements{data, e.valid.subset(idx)}
+}
+
+func (e timeElements) append(x column) column {
+	xe := x.(timeElements)
+	return timeElements{
+		concat(e.data, xe.data),
+		e.valid.append(e.Len(), xe.valid, xe.Len()),
+	}
+}
+
+func (e timeElements) copy() column {
+	data := make([]time.Time, len(e.data))
+	copy(data, e.data)
+	return timeElements{data, e.valid.copy(len(data))}
+}
+
+// timeElementRef is the Element returned by timeElements.Elem. Setting it
+// writes the value through to the Series.
+type timeElementRef struct {
+	timeElement
+	col timeElements
+	i   int
+}
+
+func (r *timeElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.timeElement = r.col.load(r.i)
+	return err
+}
@@ -279,3 +279,82 @@ func (e uintElement) GreaterEq(elem Element) bool {
 	}
 	return e.e >= i
 }
+
+// uintElements is the concrete implementation of Elements for Uint elements. The
+// values are stored in a flat slice, next to the validity and NaN bitmaps.
+type uintElements struct {
+	data  []uint64
+	valid bitmap
+	nan   bitmap
+}
+
+func newUintElements(n int) uintElements {
+	return uintElements{make([]uint64, n), newBitmap(n), newBitmap(n)}
+}
+
+func (e uintElements) Len() int           { return len(e.data) }
+func (e uintElements) Elem(i int) Element { return &uintElementRef{e.load(i), e, i} }
+
+func (e uintElements) load(i int) uintElement {
+	return uintElement{e.data[i], e.valid.get(i), e.nan.get(i)}
+}
+
+func (e uintElements) store(i int, v uintElement) {
+	e.data[i] = v.e
+	e.valid.set(i, v.valid)
+	e.nan.set(i, v.nan)
+}
+
+func (e uintElements) set(i int, value interface{}) error {
+	var v uintElement
+	err := v.Set(value)
+	e.store(i, v)
+	return err
+}
+
+func (e uintElements) assign(i int, x column, j int) {
+	e.store(i, x.(uintElements).load(j))
+}
+
+func (e uintElements) subset(idx []int) column {
+	data := make([]uint64, len(idx))
+	for k, i := range idx {
+		data[k] = e.data[i]
+	}
+	return uintElements{data, e.valid.subset(idx), e.nan.subset(idx)}
+}
+
+func (e uintElements) append(x column) column {
+	n, m := e.Len(), x.Len()
+	xe := x.(uintElements)
+	return uintElements{
+		concat(e.data, xe.data),
+		e.valid.append(n, xe.valid, m),
+		e.nan.append(n, xe.nan, m),
+	}
+}
+
+func (e uintElements) copy() column {
+	data := make([]uint64, len(e.data))
+	copy(data, e.data)
+	return uintElements{data, e.valid.copy(len(data)), e.nan.copy(len(data))}
+}
+
+// noNaN returns true if all the elements are valid and not NaN
+func (e uintElements) noNaN() bool {
+	return e.valid.all(len(e.data)) && !e.nan.any(len(e.data))
+}
+
+// uintElementRef is the Element returned by uintElements.Elem. Setting it
+// writes the value through to the Series.
+type uintElementRef struct {
+	uintElement
+	col uintElements
+	i   int
+}
+
+func (r *uintElementRef) Set(value interface{}) error {
+	err := r.col.set(r.i, value)
+	r.uintElement = r.col.load(r.i)
+	return err
+}
@@ -0,0 +1,321 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Native is the set of Go types that can be read from and written to a Series
+// without going through interface{}.
+type Native interface {
+	int | int8 | int16 | int32 | int64 |
+		uint | uint8 | uint16 | uint32 | uint64 |
+		float32 | float64 | string | bool | time.Time
+}
+
+// TypeOf returns the Series Type used to store values of type T
+func TypeOf[T Native]() Type {
+	var zero T
+	switch any(zero).(type) {
+	case int, int8, int16, int32, int64:
+		return Int
+	case uint, uint8, uint16, uint32, uint64:
+		return Uint
+	case float32, float64:
+		return Float
+	case string:
+		return String
+	case bool:
+		return Bool
+	default:
+		return Time
+	}
+}
+
+// FromSlice creates a Series from a slice of values, choosing the Series Type
+// from T (see TypeOf). If valid is not nil, elements whose entry is false are
+// set as non-valid.
+func FromSlice[T Native](values []T, valid []bool, name string) Series {
+	ret := Series{
+		Name:     name,
+		t:        TypeOf[T](),
+		elements: newColumn(values, TypeOf[T](), -1),
+	}
+	if valid != nil && len(valid) != len(values) {
+		ret.Err = fmt.Errorf("from slice: validity mask length mismatch")
+		return ret
+	}
+	for i, ok := range valid {
+		if !ok {
+			ret.elements.set(i, nil)
+		}
+	}
+	return ret
+}
+
+// Values returns the elements of a Series converted to T, along with a mask
+// which is false for the non-valid elements. Non-valid elements are returned
+// as the zero value of T. An error is returned if a valid element can't be
+// converted, or is out of the range of T.
+func Values[T Native](s Series) ([]T, []bool, error) {
+	if err := s.Err; err != nil {
+		return nil, nil, err
+	}
+	n := s.Len()
+	ret := make([]T, n)
+	valid := s.IsValid()
+	// Values already stored as T are copied over, the non-valid ones being
+	// zeroed as the storage may hold anything there
+	if copyValues(ret, s.elements) {
+		var zero T
+		for i := range ret {
+			if !valid[i] {
+				ret[i] = zero
+			}
+		}
+		return ret, valid, nil
+	}
+	for i := 0; i < n; i++ {
+		if !valid[i] {
+			continue
+		}
+		v, err := elementAs[T](s.elements.Elem(i))
+		if err != nil {
+			return nil, nil, fmt.Errorf("values: element %d: %v", i, err)
+		}
+		ret[i] = v
+	}
+	return ret, valid, nil
+}
+
+// copyValues copies the values of c to dst if they are stored as T, and
+// returns whether they were
+func copyValues[T Native](dst []T, c column) bool {
+	c = native(c)
+	switch v := any(dst).(type) {
+	case []int64:
+		if e, ok := c.(intElements); ok && !e.nan.any(len(e.data)) {
+			copy(v, e.data)
+			return true
+		}
+	case []uint64:
+		if e, ok := c.(uintElements); ok && !e.nan.any(len(e.data)) {
+			copy(v, e.data)
+			return true
+		}
+	case []float64:
+		if e, ok := c.(floatElements); ok {
+			copy(v, e.data)
+			return true
+		}
+	case []string:
+		if e, ok := c.(stringElements); ok {
+			copy(v, e.data)
+			return true
+		}
+	case []bool:
+		if e, ok := c.(boolElements); ok {
+			copy(v, e.data)
+			return true
+		}
+	case []time.Time:
+		if e, ok := c.(timeElements); ok {
+			copy(v, e.data)
+			return true
+		}
+	}
+	return false
+}
+
+// MapTyped applies f to the valid elements of a Series converted to T and
+// returns a new Series of the type matching U. Non-valid elements remain
+// non-valid.
+func MapTyped[T, U Native](s Series, f func(T) U) Series {
+	values, valid, err := Values[T](s)
+	if err != nil {
+		ret := New([]U{}, TypeOf[U](), s.Name)
+		ret.Err = fmt.Errorf("map typed: %v", err)
+		return ret
+	}
+	mapped := make([]U, len(values))
+	for i, v := range values {
+		if valid[i] {
+			mapped[i] = f(v)
+		}
+	}
+	return FromSlice(mapped, valid, s.Name)
+}
+
+// elementAs converts a valid Element to T
+func elementAs[T Native](e Element) (T, error) {
+	var ret T
+	var err error
+	switch p := any(&ret).(type) {
+	case *int:
+		var v int64
+		if v, err = e.Int(); err == nil {
+			*p, err = narrow[int](v)
+		}
+	case *int8:
+		var v int64
+		if v, err = e.Int(); err == nil {
+			*p, err = narrow[int8](v)
+		}
+	case *int16:
+		var v int64
+		if v, err = e.Int(); err == nil {
+			*p, err = narrow[int16](v)
+		}
+	case *int32:
+		var v int64
+		if v, err = e.Int(); err == nil {
+			*p, err = narrow[int32](v)
+		}
+	case *int64:
+		*p, err = e.Int()
+	case *uint:
+		var v uint64
+		if v, err = e.Uint(); err == nil {
+			*p, err = narrow[uint](v)
+		}
+	case *uint8:
+		var v uint64
+		if v, err = e.Uint(); err == nil {
+			*p, err = narrow[uint8](v)
+		}
+	case *uint16:
+		var v uint64
+		if v, err = e.Uint(); err == nil {
+			*p, err = narrow[uint16](v)
+		}
+	case *uint32:
+		var v uint64
+		if v, err = e.Uint(); err == nil {
+			*p, err = narrow[uint32](v)
+		}
+	case *uint64:
+		*p, err = e.Uint()
+	case *float32:
+		var v float64
+		if v, err = e.Float(); err == nil {
+			*p = float32(v)
+			if math.IsInf(float64(*p), 0) && !math.IsInf(v, 0) {
+				err = fmt.Errorf("%v overflows float32", v)
+			}
+		}
+	case *float64:
+		*p, err = e.Float()
+	case *string:
+		*p, err = e.String()
+	case *bool:
+		*p, err = e.Bool()
+	case *time.Time:
+		*p, err = ElementTime(e)
+	}
+	return ret, err
+}
+
+// narrow converts an integer to T, failing if it is out of the range of T
+func narrow[T int | int8 | int16 | int32 | uint | uint8 | uint16 | uint32, V int64 | uint64](v V) (T, error) {
+	if V(T(v)) != v {
+		return 0, fmt.Errorf("%v overflows %T", v, T(0))
+	}
+	return T(v), nil
+}
+
+// newColumn builds the storage of a Series of type t from a slice of values.
+// Values already matching the storage of t are copied directly, the rest are
+// converted element by element. Positions beyond the values, up to size, are
+// left non-valid.
+func newColumn[T Native](values []T, t Type, size int) column {
+	n := imax(len(values), size)
+	switch v := any(values).(type) {
+	case []int:
+		if t == Int {
+			e := newIntElements(n)
+			for i, x := range v {
+				e.data[i] = int64(x)
+			}
+			e.valid.setAll(len(v), true)
+			return e
+		}
+	case []int64:
+		if t == Int {
+			e := newIntElements(n)
+			copy(e.data, v)
+			e.valid.setAll(len(v), true)
+			return e
+		}
+	case []uint64:
+		if t == Uint {
+			e := newUintElements(n)
+			copy(e.data, v)
+			e.valid.setAll(len(v), true)
+			return e
+		}
+	case []float64:
+		if t == Float {
+			e := newFloatElements(n)
+			copy(e.data, v)
+			e.valid.setAll(len(v), true)
+			return e
+		}
+	case []bool:
+		if t == Bool {
+			e := newBoolElements(n)
+			copy(e.data, v)
+			e.valid.setAll(len(v), true)
+			return e
+		}
+	case []string:
+		if t == String {
+			e := newStringElements(n)
+			for i, x := range v {
+				if x != Nil {
+					e.data[i] = x
+					e.valid.set(i, true)
+				}
+			}
+			return e
+		}
+	case []time.Time:
+		if t == Time {
+			e := newTimeElements(n)
+			copy(e.data, v)
+			e.valid.setAll(len(v), true)
+			return e
+		}
+	}
+	e := newElements(t, n)
+	for i, x := range values {
+		e.set(i, widen(x))
+	}
+	return e
+}
+
+// widen converts the sized numeric types to the ones handled by Element.Set
+func widen[T Native](value T) interface{} {
+	switch v := any(value).(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,129 @@
+package series
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromSlice(t *testing.T) {
+	tests := []struct {
+		series   Series
+		expType  Type
+		expected []string
+	}{
+		{FromSlice([]int{1, 2, 3}, nil, "a"), Int, []string{"1", "2", "3"}},
+		{FromSlice([]int8{1, -2}, []bool{true, false}, "a"), Int, []string{"1", "NaN"}},
+		{FromSlice([]uint16{7, 8}, nil, "a"), Uint, []string{"7", "8"}},
+		{FromSlice([]float32{1.5, 2}, nil, "a"), Float, []string{"1.500000", "2.000000"}},
+		{FromSlice([]string{"a", "NA", "c"}, []bool{true, true, false}, "a"), String, []string{"a", "NA", "NaN"}},
+		{FromSlice([]bool{true, false}, nil, "a"), Bool, []string{"true", "false"}},
+		{FromSlice([]time.Time{time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)}, nil, "a"), Time, []string{"2021-06-01T00:00:00Z"}},
+	}
+	for testnum, test := range tests {
+		if err := test.series.Err; err != nil {
+			t.Errorf("Test:%v\nError:%v", testnum, err)
+		}
+		if test.series.Type() != test.expType {
+			t.Errorf("Test:%v\nExpected type:%v\nReceived type:%v", testnum, test.expType, test.series.Type())
+		}
+		received := make([]string, test.series.Len())
+		for i := range received {
+			e := test.series.Elem(i)
+			if !e.IsValid() {
+				received[i] = "NaN"
+				continue
+			}
+			received[i], _ = e.String()
+		}
+		if !reflect.DeepEqual(test.expected, received) {
+			t.Errorf("Test:%v\nExpected:\n%v\nReceived:\n%v", testnum, test.expected, received)
+		}
+	}
+
+	s := FromSlice([]int{1, 2}, []bool{true}, "a")
+	if s.Err == nil {
+		t.Error("Expected error for a validity mask of the wrong length")
+	}
+}
+
+func TestValues(t *testing.T) {
+	s := New([]interface{}{1, nil, 3}, Int, "a")
+	ints, valid, err := Values[int64](s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]int64{1, 0, 3}, ints) || !reflect.DeepEqual([]bool{true, false, true}, valid) {
+		t.Errorf("Received:\n%v %v", ints, valid)
+	}
+
+	floats, _, err := Values[float32](s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]float32{1, 0, 3}, floats) {
+		t.Errorf("Received:\n%v", floats)
+	}
+
+	// Non-valid elements are zero whatever the storage holds
+	s = Ints([]int{1, 2, 3})
+	s.Elem(1).Set(nil)
+	if ints, _, _ := Values[int64](s); !reflect.DeepEqual([]int64{1, 0, 3}, ints) {
+		t.Errorf("Received:\n%v", ints)
+	}
+	s = Strings([]string{"a", "b"})
+	s.elements.(stringElements).valid.set(0, false)
+	if strs, _, _ := Values[string](s); !reflect.DeepEqual([]string{"", "b"}, strs) {
+		t.Errorf("Received:\n%v", strs)
+	}
+
+	strs, _, err := Values[string](Categoricals([]string{"x", "y", "x"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"x", "y", "x"}, strs) {
+		t.Errorf("Received:\n%v", strs)
+	}
+
+	if _, _, err := Values[int](Ints([]string{"1", "NaN"})); err == nil {
+		t.Error("Expected error converting NaN to int")
+	}
+	if _, _, err := Values[bool](Strings([]string{"a"})); err == nil {
+		t.Error("Expected error converting string to bool")
+	}
+	if _, _, err := Values[int8](Ints([]int{300, -200})); err == nil || !strings.Contains(err.Error(), "element 0: 300 overflows int8") {
+		t.Errorf("Expected error for 300 overflowing int8, received %v", err)
+	}
+	if _, _, err := Values[uint16](Uints([]uint{1, 70000})); err == nil || !strings.Contains(err.Error(), "element 1: 70000 overflows uint16") {
+		t.Errorf("Expected error for 70000 overflowing uint16, received %v", err)
+	}
+	if _, _, err := Values[float32](Floats([]float64{1e40})); err == nil {
+		t.Error("Expected error for 1e40 overflowing float32")
+	}
+	if values, _, err := Values[int8](Ints([]int{127, -128})); err != nil || !reflect.DeepEqual([]int8{127, -128}, values) {
+		t.Errorf("Expected [127 -128], received %v (%v)", values, err)
+	}
+}
+
+func TestMapTyped(t *testing.T) {
+	s := New([]interface{}{"a", nil, "bc"}, String, "name")
+	received := MapTyped(s, func(v string) int { return len(v) })
+	if err := received.Err; err != nil {
+		t.Fatal(err)
+	}
+	if received.Type() != Int || received.Name != "name" {
+		t.Errorf("Received type %v, name %v", received.Type(), received.Name)
+	}
+	if received.Elem(1).IsValid() {
+		t.Error("Expected non-valid element to remain non-valid")
+	}
+	if v, _ := received.Elem(2).Int(); v != 2 {
+		t.Errorf("Expected 2, received %v", v)
+	}
+
+	received = MapTyped(Strings([]string{"a"}), func(v bool) bool { return !v })
+	if received.Err == nil || !strings.Contains(received.Err.Error(), "map typed") {
+		t.Errorf("Expected error, received %v", received.Err)
+	}
+}